@@ -0,0 +1,689 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	coinCommands = []command{
+		{"add", "register a coin", runCoinAdd},
+		{"remove", "soft-delete a coin with its repositories, or purge it with its snapshots", runCoinRemove},
+		{"deactivate", "stop collecting a coin's repositories, keeping their data", runCoinDeactivate},
+		{"reactivate", "collect a deactivated or removed coin again", runCoinReactivate},
+		{"list", "list coins", runCoinList},
+	}
+
+	repoCommands = []command{
+		{"add", "attach a repository to a coin", runRepoAdd},
+		{"remove", "soft-delete a repository, or purge it with its snapshots", runRepoRemove},
+		{"deactivate", "stop collecting a repository, keeping its data", runRepoDeactivate},
+		{"reactivate", "collect a deactivated or removed repository again", runRepoReactivate},
+		{"list", "list repositories", runRepoList},
+	}
+
+	errDuplicate = errors.New("already exists")
+	errNotFound  = errors.New("not found")
+)
+
+func runCoin(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	dispatch(os.Args[0]+" coin", coinCommands, args[0], args[1:])
+}
+
+func runRepo(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	dispatch(os.Args[0]+" repo", repoCommands, args[0], args[1:])
+}
+
+// repositoryLocation is where a repository lives, parsed from user input.
+// BaseURL is only set for self-hosted forges.
+type repositoryLocation struct {
+	Provider string
+	Owner    string
+	Name     string
+	BaseURL  string
+}
+
+// parseRepositoryURL accepts "owner/name" (assumed to be on GitHub) or a
+// github.com, gitlab.com, bitbucket.org or Gitea instance URL. URLs are
+// normalized the way they get pasted: without a scheme, with www., as an SSH
+// remote, with a .git suffix, trailing slashes or a page of the repository
+// such as /tree/main. The owner of a GitLab project is its whole namespace,
+// e.g. group/subgroup.
+func parseRepositoryURL(raw string) (repositoryLocation, error) {
+	raw = strings.TrimSpace(raw)
+	provider := providerGitHub
+	path := raw
+	var baseURL string
+
+	normalized := raw
+	if rest, ok := strings.CutPrefix(normalized, "git@"); ok && !strings.Contains(rest, "://") {
+		normalized = "https://" + strings.Replace(rest, ":", "/", 1)
+	}
+	if host, _, _ := strings.Cut(normalized, "/"); !strings.Contains(normalized, "://") && strings.Contains(host, ".") {
+		normalized = "https://" + normalized
+	}
+	if strings.Contains(normalized, "://") {
+		u, err := url.Parse(normalized)
+		if err != nil {
+			return repositoryLocation{}, err
+		}
+		host := strings.ToLower(u.Host)
+		path = u.Path
+		switch strings.TrimPrefix(host, "www.") {
+		case "github.com":
+			provider = providerGitHub
+			path = firstSegments(path, 2)
+		case "gitlab.com":
+			provider = providerGitLab
+			path, _, _ = strings.Cut(path, "/-/")
+		case "bitbucket.org":
+			provider = providerBitbucket
+			path = firstSegments(path, 2)
+		default:
+			if !isGiteaHost(host) {
+				return repositoryLocation{}, fmt.Errorf("unsupported host %q, list Gitea instances in [Gitea] hosts", u.Host)
+			}
+			provider = providerGitea
+			baseURL = u.Scheme + "://" + host
+		}
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// GitLab nests projects in subgroups, which belong to the namespace.
+	if provider == providerGitLab && len(parts) > 2 {
+		parts = []string{strings.Join(parts[:len(parts)-1], "/"), parts[len(parts)-1]}
+	}
+	if len(parts) == 2 {
+		parts[1] = strings.TrimSuffix(parts[1], ".git")
+	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return repositoryLocation{}, fmt.Errorf("%q is not an owner/name pair", raw)
+	}
+	return repositoryLocation{Provider: provider, Owner: parts[0], Name: parts[1], BaseURL: baseURL}, nil
+}
+
+// firstSegments cuts path down to its first n segments.
+func firstSegments(path string, n int) string {
+	parts := strings.SplitN(strings.Trim(path, "/"), "/", n+1)
+	if len(parts) > n {
+		parts = parts[:n]
+	}
+	return strings.Join(parts, "/")
+}
+
+// verifyRepository asks the provider for the repository, so a mistyped or
+// deleted one is rejected before it is added. It returns the location the
+// provider knows it under, which on GitHub follows renames and fixes the
+// letter case.
+func verifyRepository(ctx context.Context, config Config, loc repositoryLocation) (repositoryLocation, error) {
+	h, err := newHTTPClients(config.HTTP)
+	if err != nil {
+		return loc, fmt.Errorf("invalid [HTTP] config: %w", err)
+	}
+	var body json.RawMessage
+	switch loc.Provider {
+	case providerGitHub:
+		if problems := config.GitHub.problems(true); len(problems) > 0 {
+			return loc, fmt.Errorf("[GitHub] %s", strings.Join(problems, ", "))
+		}
+		loc, err = newGitHubClient(config.GitHub, h).resolveLocation(ctx, loc)
+	case providerGitLab:
+		_, err = newGitLabClient(config.GitLab, h).get(ctx, "/projects/"+url.PathEscape(loc.Owner+"/"+loc.Name), nil, &body)
+	case providerBitbucket:
+		err = newBitbucketClient(config.Bitbucket, h).get(ctx, "/repositories/"+url.PathEscape(loc.Owner)+"/"+url.PathEscape(loc.Name), nil, &body)
+	case providerGitea:
+		_, err = newGiteaClient(config.Gitea, h).get(ctx, loc.BaseURL, "/repos/"+url.PathEscape(loc.Owner)+"/"+url.PathEscape(loc.Name), nil, &body)
+	}
+	if errors.Is(err, errRepositoryMissing) {
+		return loc, fmt.Errorf("repository %s/%s does not exist on %s or is private", loc.Owner, loc.Name, loc.Provider)
+	}
+	return loc, err
+}
+
+// parseOwner accepts a bare owner or a profile URL such as
+// https://github.com/bitcoin.
+func parseOwner(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", err
+		}
+		raw = u.Path
+	}
+	owner := strings.Trim(raw, "/")
+	if owner == "" || strings.Contains(owner, "/") {
+		return "", fmt.Errorf("%q is not a valid owner", raw)
+	}
+	return owner, nil
+}
+
+// findRepository looks a repository of coin up by name. Pass db.Unscoped()
+// to find removed ones too.
+func findRepository(db *gorm.DB, coin Coin, name string) (Repository, error) {
+	var repo Repository
+	err := db.Where("coin_id = ? AND name = ?", coin.Id, name).First(&repo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repo, fmt.Errorf("repository %s/%s %w", coin.Owner, name, errNotFound)
+	}
+	return repo, err
+}
+
+func findCoin(db *gorm.DB, symbol string) (Coin, error) {
+	var coin Coin
+	err := db.Where("UPPER(symbol) = ?", strings.ToUpper(symbol)).First(&coin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return coin, fmt.Errorf("coin %s %w", symbol, errNotFound)
+	}
+	return coin, err
+}
+
+// addCoin refuses the symbol of a removed coin too, which is reactivated
+// instead.
+func addCoin(db *gorm.DB, coin Coin) (Coin, error) {
+	if old, err := findCoin(db.Unscoped(), coin.Symbol); err == nil {
+		if old.DeletedAt.Valid {
+			return coin, fmt.Errorf("coin %s %w, removed", coin.Symbol, errDuplicate)
+		}
+		return coin, fmt.Errorf("coin %s %w", coin.Symbol, errDuplicate)
+	}
+	err := db.Create(&coin).Error
+	return coin, err
+}
+
+func addRepository(db *gorm.DB, coin Coin, loc repositoryLocation) (Repository, error) {
+	if !strings.EqualFold(loc.Owner, coin.Owner) {
+		return Repository{}, fmt.Errorf("repository owner %s does not match the owner %s of coin %s", loc.Owner, coin.Owner, coin.Symbol)
+	}
+
+	// A removed repository is reactivated rather than added again.
+	var count int64
+	if err := db.Unscoped().Model(&Repository{}).Where("coin_id = ? AND name = ?", coin.Id, loc.Name).Count(&count).Error; err != nil {
+		return Repository{}, err
+	}
+	if count > 0 {
+		return Repository{}, fmt.Errorf("repository %s/%s %w", coin.Owner, loc.Name, errDuplicate)
+	}
+
+	// The unique index still catches a repository added concurrently.
+	repo := Repository{CoinId: coin.Id, Provider: loc.Provider, BaseURL: loc.BaseURL, Name: loc.Name}
+	err := db.Create(&repo).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return repo, fmt.Errorf("repository %s/%s %w", coin.Owner, loc.Name, errDuplicate)
+	}
+	return repo, err
+}
+
+// deleteRepositories removes repositories together with their snapshots and
+// errors, which SQLite would otherwise keep since it has no foreign keys.
+// Soft-deleted repositories in scope are purged as well.
+func deleteRepositories(db *gorm.DB, scope *gorm.DB) error {
+	var ids []int
+	if err := scope.Unscoped().Model(&Repository{}).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositorySnapshot{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&CollectionError{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryCommitWindow{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryContributor{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryCodeFrequency{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryGrowth{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryLanguage{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryTopic{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RunRepository{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&Anomaly{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryAuthorDomain{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("repository_id IN (?)", ids).Delete(&RepositoryTopContributor{}).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Where("id IN (?)", ids).Delete(&Repository{}).Error
+}
+
+// softDeleteCoin removes a coin and its repositories from every query while
+// keeping their rows, so they can be reactivated with their history.
+func softDeleteCoin(db *gorm.DB, coin Coin) error {
+	// The repositories share the coin's deletion time, which tells them
+	// apart from the ones removed before.
+	now := clock.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Repository{}).Where("coin_id = ?", coin.Id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&coin).Update("deleted_at", now).Error
+	})
+}
+
+// purgeCoin deletes a coin with its repositories, their snapshots and the
+// coin's rollups.
+func purgeCoin(db *gorm.DB, coin Coin) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteRepositories(tx, tx.Where("coin_id = ?", coin.Id)); err != nil {
+			return err
+		}
+		for _, rollup := range []interface{}{CoinStat{}, CoinScore{}, CoinRank{}, CoinMarketSnapshot{}} {
+			if err := tx.Where("coin_id = ?", coin.Id).Delete(rollup).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&coin).Error
+	})
+}
+
+// reactivateCoin undoes deactivate and remove. The repositories removed with
+// the coin come back with it; the ones removed before stay removed.
+func reactivateCoin(db *gorm.DB, coin Coin) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if coin.DeletedAt.Valid {
+			err := tx.Unscoped().Model(&Repository{}).
+				Where("coin_id = ? AND deleted_at >= ?", coin.Id, coin.DeletedAt.Time).
+				Update("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Model(&coin).Updates(map[string]interface{}{"active": true, "deleted_at": nil}).Error
+	})
+}
+
+func runCoinAdd(args []string) {
+	fs := flag.NewFlagSet("coin add", flag.ExitOnError)
+	addConfigFlag(fs)
+	name := fs.String("name", "", "coin name, e.g. Bitcoin")
+	symbol := fs.String("symbol", "", "ticker symbol, e.g. BTC")
+	owner := fs.String("owner", "", "GitHub owner of the coin's repositories, or its profile URL")
+	fs.Parse(args)
+	if *name == "" || *symbol == "" || *owner == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	o, err := parseOwner(*owner)
+	if err != nil {
+		fatal("Invalid owner.", "error", err)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	coin, err := addCoin(db, Coin{Name: *name, Symbol: strings.ToUpper(*symbol), Owner: o})
+	if err != nil {
+		fatal("Failed to add the coin.", "error", err)
+	}
+	fmt.Printf("Added %s (%s) with id %d.\n", coin.Name, coin.Symbol, coin.Id)
+}
+
+func runCoinRemove(args []string) {
+	fs := flag.NewFlagSet("coin remove", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("symbol", "", "ticker symbol of the coin to delete")
+	purge := fs.Bool("purge", false, "delete the coin's repositories and snapshots for good instead of hiding them")
+	fs.Parse(args)
+	if *symbol == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	// A removed coin can still be purged.
+	scope := db
+	if *purge {
+		scope = db.Unscoped()
+	}
+	coin, err := findCoin(scope, *symbol)
+	if err != nil {
+		fatal("Failed to remove the coin.", "error", err)
+	}
+	if *purge {
+		err = purgeCoin(db, coin)
+	} else {
+		err = softDeleteCoin(db, coin)
+	}
+	if err != nil {
+		fatal("Failed to remove the coin.", "error", err)
+	}
+	if *purge {
+		fmt.Printf("Purged %s (%s).\n", coin.Name, coin.Symbol)
+		return
+	}
+	fmt.Printf("Removed %s (%s), reactivate it to restore it.\n", coin.Name, coin.Symbol)
+}
+
+func runCoinDeactivate(args []string) {
+	fs := flag.NewFlagSet("coin deactivate", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("symbol", "", "ticker symbol of the coin to stop collecting")
+	fs.Parse(args)
+	if *symbol == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	coin, err := findCoin(db, *symbol)
+	if err != nil {
+		fatal("Failed to deactivate the coin.", "error", err)
+	}
+	if err := db.Model(&coin).Update("active", false).Error; err != nil {
+		fatal("Failed to deactivate the coin.", "error", err)
+	}
+	fmt.Printf("Deactivated %s (%s).\n", coin.Name, coin.Symbol)
+}
+
+func runCoinReactivate(args []string) {
+	fs := flag.NewFlagSet("coin reactivate", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("symbol", "", "ticker symbol of the coin to collect again")
+	fs.Parse(args)
+	if *symbol == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	coin, err := findCoin(db.Unscoped(), *symbol)
+	if err != nil {
+		fatal("Failed to reactivate the coin.", "error", err)
+	}
+	if err := reactivateCoin(db, coin); err != nil {
+		fatal("Failed to reactivate the coin.", "error", err)
+	}
+	fmt.Printf("Reactivated %s (%s).\n", coin.Name, coin.Symbol)
+}
+
+func runCoinList(args []string) {
+	fs := flag.NewFlagSet("coin list", flag.ExitOnError)
+	addConfigFlag(fs)
+	removed := fs.Bool("removed", false, "also list removed coins")
+	fs.Parse(args)
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	scope := db
+	if *removed {
+		scope = db.Unscoped()
+	}
+	var coins []Coin
+	if err := scope.Preload("Repositories").Order("symbol").Find(&coins).Error; err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSYMBOL\tNAME\tOWNER\tREPOSITORIES\tSTATE")
+	for _, coin := range coins {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\n", coin.Id, coin.Symbol, coin.Name, coin.Owner, len(coin.Repositories), activeState(coin.Active, coin.DeletedAt))
+	}
+	w.Flush()
+}
+
+func runRepoAdd(args []string) {
+	fs := flag.NewFlagSet("repo add", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("coin", "", "ticker symbol of the coin")
+	rawURL := fs.String("url", "", "repository URL or owner/name")
+	allBranches := fs.Bool("all-branches", false, "count the recent commits of every branch")
+	noVerify := fs.Bool("no-verify", false, "add the repository without checking that it exists on its provider")
+	fs.Parse(args)
+	if *symbol == "" || *rawURL == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// The config must be loaded first, it lists the Gitea hosts.
+	config := loadConfig()
+	db := dbConnect(config)
+	defer closeDB(db)
+
+	loc, err := parseRepositoryURL(*rawURL)
+	if err != nil {
+		fatal("Invalid repository.", "error", err)
+	}
+	if !*noVerify {
+		ctx, cancel := signalContext()
+		loc, err = verifyRepository(ctx, config, loc)
+		cancel()
+		if err != nil {
+			fatal("Invalid repository, pass -no-verify to add it anyway.", "error", err)
+		}
+	}
+
+	coin, err := findCoin(db, *symbol)
+	if err != nil {
+		fatal("Failed to add the repository.", "error", err)
+	}
+	repo, err := addRepository(db, coin, loc)
+	if err != nil {
+		fatal("Failed to add the repository.", "error", err)
+	}
+	if *allBranches {
+		if err := db.Model(&repo).Update("all_branches", true).Error; err != nil {
+			fatal("Failed to add the repository.", "error", err)
+		}
+	}
+	fmt.Printf("Added %s/%s to %s with id %d.\n", loc.Owner, repo.Name, coin.Symbol, repo.Id)
+}
+
+func runRepoRemove(args []string) {
+	fs := flag.NewFlagSet("repo remove", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("coin", "", "ticker symbol of the coin")
+	name := fs.String("name", "", "repository name")
+	purge := fs.Bool("purge", false, "delete the repository and its snapshots for good instead of hiding it")
+	fs.Parse(args)
+	if *symbol == "" || *name == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	coin, err := findCoin(db, *symbol)
+	if err != nil {
+		fatal("Failed to remove the repository.", "error", err)
+	}
+	if *purge {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return deleteRepositories(tx, tx.Where("coin_id = ? AND name = ?", coin.Id, *name))
+		})
+		if err != nil {
+			fatal("Failed to remove the repository.", "error", err)
+		}
+		fmt.Printf("Purged %s/%s.\n", coin.Owner, *name)
+		return
+	}
+	repo, err := findRepository(db, coin, *name)
+	if err == nil {
+		err = db.Delete(&repo).Error
+	}
+	if err != nil {
+		fatal("Failed to remove the repository.", "error", err)
+	}
+	fmt.Printf("Removed %s/%s, reactivate it to restore it.\n", coin.Owner, *name)
+}
+
+func runRepoDeactivate(args []string) {
+	fs := flag.NewFlagSet("repo deactivate", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("coin", "", "ticker symbol of the coin")
+	name := fs.String("name", "", "repository name")
+	fs.Parse(args)
+	if *symbol == "" || *name == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	coin, err := findCoin(db, *symbol)
+	if err != nil {
+		fatal("Failed to deactivate the repository.", "error", err)
+	}
+	repo, err := findRepository(db, coin, *name)
+	if err == nil {
+		err = db.Model(&repo).Update("active", false).Error
+	}
+	if err != nil {
+		fatal("Failed to deactivate the repository.", "error", err)
+	}
+	fmt.Printf("Deactivated %s/%s.\n", coin.Owner, repo.Name)
+}
+
+// runRepoReactivate undoes repo deactivate and repo remove.
+func runRepoReactivate(args []string) {
+	fs := flag.NewFlagSet("repo reactivate", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("coin", "", "ticker symbol of the coin")
+	name := fs.String("name", "", "repository name")
+	fs.Parse(args)
+	if *symbol == "" || *name == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	coin, err := findCoin(db, *symbol)
+	if err != nil {
+		fatal("Failed to reactivate the repository.", "error", err)
+	}
+	repo, err := findRepository(db.Unscoped(), coin, *name)
+	if err == nil {
+		err = db.Unscoped().Model(&repo).Updates(map[string]interface{}{"active": true, "deleted_at": nil}).Error
+	}
+	if err != nil {
+		fatal("Failed to reactivate the repository.", "error", err)
+	}
+	fmt.Printf("Reactivated %s/%s.\n", coin.Owner, repo.Name)
+}
+
+func runRepoList(args []string) {
+	fs := flag.NewFlagSet("repo list", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("coin", "", "only list the repositories of this coin")
+	removed := fs.Bool("removed", false, "also list removed repositories")
+	fs.Parse(args)
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	scope := db.Preload("Coin").Order("coin_id, name")
+	if *removed {
+		scope = scope.Unscoped()
+	}
+	if *symbol != "" {
+		coin, err := findCoin(db, *symbol)
+		if err != nil {
+			fatal("Failed to read the DB.", "error", err)
+		}
+		scope = scope.Where("coin_id = ?", coin.Id)
+	}
+	var repos []Repository
+	if err := scope.Find(&repos).Error; err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCOIN\tPROVIDER\tREPOSITORY\tUPDATED\tSTATE")
+	for _, repo := range repos {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", repo.Id, repo.Coin.Symbol, repo.Provider, repoName(repo), repo.UpdatedAt.Format("2006-01-02 15:04"), activeState(repo.Active, repo.DeletedAt))
+	}
+	w.Flush()
+}
+
+// activeState is the state column of the list commands.
+func activeState(active bool, deletedAt gorm.DeletedAt) string {
+	switch {
+	case deletedAt.Valid:
+		return "removed"
+	case !active:
+		return "inactive"
+	}
+	return "active"
+}
+
+// applyMove points a renamed or transferred repository at its new location.
+// The owner is stored on the coin, so a transfer is only applied to a coin
+// with no other repository: the others were not seen to move. Otherwise it
+// is left to the redirect of the old location and only logged. A rename to
+// the name of another repository of the coin is logged and skipped too.
+func applyMove(db *gorm.DB, repo *Repository, loc repositoryLocation) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if !strings.EqualFold(repo.Coin.Owner, loc.Owner) {
+			var others int64
+			err := tx.Unscoped().Model(&Repository{}).Where("coin_id = ? AND id <> ?", repo.CoinId, repo.Id).Count(&others).Error
+			if err != nil {
+				return err
+			}
+			if others > 0 {
+				slog.Warn("Repository transferred to another owner, keeping the coin's owner as its other repositories did not move.",
+					"coin_id", repo.CoinId, "repo", repoName(*repo), "to", loc.Owner+"/"+loc.Name)
+			} else if err := tx.Model(&repo.Coin).Update("owner", loc.Owner).Error; err != nil {
+				return err
+			}
+		}
+		if strings.EqualFold(repo.Name, loc.Name) {
+			return nil
+		}
+		// The rename runs in a savepoint of its own, as a failed statement
+		// aborts the whole transaction on PostgreSQL.
+		name := repo.Name
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return tx.Model(repo).Update("name", loc.Name).Error
+		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			repo.Name = name
+			slog.Warn("Repository renamed to the name of another repository of its coin, keeping its name.",
+				"coin_id", repo.CoinId, "repo", repoName(*repo), "to", loc.Owner+"/"+loc.Name)
+			return nil
+		}
+		return err
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// writeMove writes the repository of BTC named name as found at loc, and
+// returns it as written.
+func writeMove(t *testing.T, db *gorm.DB, name string, loc repositoryLocation) Repository {
+	t.Helper()
+	var repo Repository
+	if err := db.Preload("Coin").Where("name = ?", name).First(&repo).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	run, err := startRun(db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &pendingWrite{
+		result: result{job: job{Repository: repo}, Metrics: Repository{StargazersCount: 100}, Location: loc},
+		Before: repo,
+		Logger: slog.Default(),
+	}
+	if done := flushWrites(db, run.Id, []*pendingWrite{w}, nil, now); len(done) != 1 {
+		t.Fatalf("the move of %s to %s/%s was not written", name, loc.Owner, loc.Name)
+	}
+	var written Repository
+	if err := db.Preload("Coin").First(&written, repo.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return written
+}
+
+func TestApplyMoveRenamesTransferredRepository(t *testing.T) {
+	db := dbConnect(testConfig(t))
+	defer closeDB(db)
+	coins := []seedCoin{{Symbol: "BTC", Name: "Bitcoin", Owner: "bitcoin", Repositories: []string{"bitcoin", "secp256k1", "bips"}}}
+	if _, err := seed(db, coins); err != nil {
+		t.Fatal(err)
+	}
+
+	// The coin keeps its owner, as its other repositories did not move.
+	repo := writeMove(t, db, "bitcoin", repositoryLocation{Provider: providerGitHub, Owner: "bitcoin-core", Name: "bitcoin-core"})
+	if repo.Name != "bitcoin-core" || repo.Coin.Owner != "bitcoin" {
+		t.Errorf("moved to %s, want bitcoin/bitcoin-core", repoName(repo))
+	}
+	if repo.StargazersCount != 100 {
+		t.Errorf("stargazers_count = %d, want 100", repo.StargazersCount)
+	}
+
+	// A rename to the name of another repository of the coin is skipped,
+	// but the metrics are still written.
+	repo = writeMove(t, db, "secp256k1", repositoryLocation{Provider: providerGitHub, Owner: "bitcoin", Name: "bips"})
+	if repo.Name != "secp256k1" {
+		t.Errorf("renamed to %s, want it to keep secp256k1", repoName(repo))
+	}
+	if repo.StargazersCount != 100 {
+		t.Errorf("stargazers_count = %d, want 100", repo.StargazersCount)
+	}
+}
+
+func TestParseRepositoryURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want repositoryLocation
+	}{
+		{"bitcoin/bitcoin", repositoryLocation{Provider: providerGitHub, Owner: "bitcoin", Name: "bitcoin"}},
+		{"https://github.com/bitcoin/bitcoin/tree/master", repositoryLocation{Provider: providerGitHub, Owner: "bitcoin", Name: "bitcoin"}},
+		{"gitlab.com/tezos/tezos", repositoryLocation{Provider: providerGitLab, Owner: "tezos", Name: "tezos"}},
+		{"https://gitlab.com/group/subgroup/project/-/tree/main", repositoryLocation{Provider: providerGitLab, Owner: "group/subgroup", Name: "project"}},
+		{"git@gitlab.com:group/a/b/project.git", repositoryLocation{Provider: providerGitLab, Owner: "group/a/b", Name: "project"}},
+	}
+	for _, tt := range tests {
+		got, err := parseRepositoryURL(tt.raw)
+		if err != nil || got != tt.want {
+			t.Errorf("parseRepositoryURL(%q) = %+v, %v, want %+v", tt.raw, got, err, tt.want)
+		}
+	}
+	for _, raw := range []string{"bitcoin", "bitcoin/bitcoin/extra", "https://bitbucket.org/owner"} {
+		if got, err := parseRepositoryURL(raw); err == nil {
+			t.Errorf("parseRepositoryURL(%q) = %+v, want an error", raw, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	anomalyStarsDropped       = "stars_dropped"
+	anomalyCommitsZero        = "commits_zero"
+	anomalyContributorsHalved = "contributors_halved"
+
+	// anomalyStarsDrop is the share of its stars a repository has to lose
+	// at once to be flagged. Stars are only ever lost slowly, so a sudden
+	// drop is a broken scrape or a repository replaced by another one.
+	anomalyStarsDrop = 0.2
+)
+
+// detectAnomalies compares the metrics m collected for a repository with
+// the ones it had before.
+func detectAnomalies(runId int, before, m Repository, now time.Time) []Anomaly {
+	var anomalies []Anomaly
+	flag := func(kind string, from, to int) {
+		anomalies = append(anomalies, Anomaly{
+			RunId:        runId,
+			RepositoryId: before.Id,
+			Kind:         kind,
+			Previous:     from,
+			Current:      to,
+			CreatedAt:    now,
+		})
+	}
+	if before.StargazersCount > 0 && float64(m.StargazersCount) < float64(before.StargazersCount)*(1-anomalyStarsDrop) {
+		flag(anomalyStarsDropped, before.StargazersCount, m.StargazersCount)
+	}
+	if before.CommitsCountForTheLastMonth > 0 && m.CommitsCountForTheLastMonth == 0 {
+		flag(anomalyCommitsZero, before.CommitsCountForTheLastMonth, m.CommitsCountForTheLastMonth)
+	}
+	if before.ContributorsCount > 1 && m.ContributorsCount*2 <= before.ContributorsCount {
+		flag(anomalyContributorsHalved, before.ContributorsCount, m.ContributorsCount)
+	}
+	return anomalies
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"golang.org/x/net/publicsuffix"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+// communityDomain is the domain the commits of personal and unknown email
+// addresses are counted under.
+const communityDomain = ""
+
+// defaultPersonalDomains are the email domains of personal accounts, in
+// addition to [Commits] personalDomains. Their commits are the community's
+// rather than an organization's.
+var defaultPersonalDomains = []string{
+	"users.noreply.github.com", "users.noreply.gitlab.com",
+	"gmail.com", "googlemail.com", "outlook.com", "hotmail.com", "live.com",
+	"yahoo.com", "icloud.com", "me.com", "protonmail.com", "proton.me", "pm.me",
+	"gmx.de", "gmx.net", "mail.ru", "yandex.ru", "qq.com", "163.com", "126.com",
+}
+
+// authorDomain is the organization an email address belongs to, named after
+// its registered domain, or communityDomain. A personal domain covers its
+// subdomains.
+func (s commitSettings) authorDomain(email string) string {
+	_, host, ok := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !ok || host == "" {
+		return communityDomain
+	}
+	for d := host; d != ""; {
+		if s.personal[d] {
+			return communityDomain
+		}
+		_, d, _ = strings.Cut(d, ".")
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return communityDomain
+	}
+	return domain
+}
+
+// authorDomains splits the commits of the last 90 days by the domain of
+// their author's email, largest first.
+func (s commitSettings) authorDomains(nodes []commitNode, now time.Time) []RepositoryAuthorDomain {
+	last90 := now.AddDate(0, 0, -contributorsHistoryDays)
+	counts := map[string]int{}
+	total := 0
+	for _, n := range nodes {
+		if n.CommittedDate.Before(last90) {
+			continue
+		}
+		counts[s.authorDomain(n.Author.Email)]++
+		total++
+	}
+
+	domains := make([]RepositoryAuthorDomain, 0, len(counts))
+	for domain, count := range counts {
+		domains = append(domains, RepositoryAuthorDomain{
+			Domain:       domain,
+			CommitsCount: count,
+			Share:        100 * float64(count) / float64(total),
+		})
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].CommitsCount != domains[j].CommitsCount {
+			return domains[i].CommitsCount > domains[j].CommitsCount
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+	return domains
+}
+
+// saveAuthorDomains replaces the author domain rows of a repository. Nil
+// domains leave them as they are, as the collector did not read commits.
+func saveAuthorDomains(db *gorm.DB, repositoryId int, domains []RepositoryAuthorDomain, now time.Time) error {
+	if domains == nil {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repositoryId).Delete(&RepositoryAuthorDomain{}).Error; err != nil {
+			return err
+		}
+		for _, d := range domains {
+			d.RepositoryId = repositoryId
+			d.UpdatedAt = now
+			if err := tx.Create(&d).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/shurcooL/githubv4"
+	"gorm.io/gorm"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// stargazersQuery lists the stargazers of a repository, oldest star first.
+type stargazersQuery struct {
+	Repository struct {
+		Stargazers struct {
+			Edges []struct {
+				StarredAt time.Time
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   githubv4.String
+			}
+		} `graphql:"stargazers(first: 100, after: $cursor, orderBy: {field: STARRED_AT, direction: ASC})"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+	RateLimit rateLimit
+}
+
+// starredBefore returns when each star given before until was given, oldest
+// first.
+func (c *githubClient) starredBefore(ctx context.Context, owner, name string, until time.Time) ([]time.Time, error) {
+	var stars []time.Time
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(name),
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	for {
+		var query stargazersQuery
+		if err := c.budget.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+		c.budget.update(query.RateLimit)
+
+		page := query.Repository.Stargazers
+		for _, e := range page.Edges {
+			if !e.StarredAt.Before(until) {
+				return stars, nil
+			}
+			stars = append(stars, e.StarredAt)
+		}
+		if !page.PageInfo.HasNextPage {
+			return stars, nil
+		}
+		variables["cursor"] = githubv4.NewString(page.PageInfo.EndCursor)
+	}
+}
+
+// commitHistoryQuery pages through the default branch history, starting
+// with a nil cursor.
+type commitHistoryQuery struct {
+	Repository struct {
+		DefaultBranchRef struct {
+			Target struct {
+				Commit struct {
+					TotalHistory struct {
+						TotalCount int
+					} `graphql:"totalHistory: history"`
+					History historyConnection `graphql:"history(since: $since, first: 100, after: $cursor)"`
+				} `graphql:"... on Commit"`
+			}
+		}
+	} `graphql:"repository(owner: $owner, name: $name)"`
+	RateLimit rateLimit
+}
+
+// commitsSince returns the default branch commits since since, newest first,
+// and the total number of commits of the branch.
+func (c *githubClient) commitsSince(ctx context.Context, owner, name string, since time.Time) ([]commitNode, int, error) {
+	var nodes []commitNode
+	var total int
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(name),
+		"since":  githubv4.GitTimestamp{Time: since},
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	for {
+		var query commitHistoryQuery
+		if err := c.budget.wait(ctx); err != nil {
+			return nil, 0, err
+		}
+		if err := c.Query(ctx, &query, variables); err != nil {
+			return nil, 0, err
+		}
+		c.budget.update(query.RateLimit)
+
+		commit := query.Repository.DefaultBranchRef.Target.Commit
+		total = commit.TotalHistory.TotalCount
+		nodes = append(nodes, commit.History.Nodes...)
+		if !commit.History.PageInfo.HasNextPage {
+			return nodes, total, nil
+		}
+		variables["cursor"] = githubv4.NewString(commit.History.PageInfo.EndCursor)
+	}
+}
+
+// monthStart is the first day 00:00 UTC of the month of t.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart is the Monday 00:00 UTC starting the week of t.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC().Truncate(24 * time.Hour)
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// weeklyStars counts the stars given before each week boundary after the
+// first star and up to until. stars must be sorted, oldest first.
+func weeklyStars(stars []time.Time, until time.Time) map[time.Time]int {
+	counts := map[time.Time]int{}
+	if len(stars) == 0 {
+		return counts
+	}
+	i := 0
+	for week := weekStart(stars[0]).AddDate(0, 0, 7); week.Before(until); week = week.AddDate(0, 0, 7) {
+		for i < len(stars) && stars[i].Before(week) {
+			i++
+		}
+		counts[week] = i
+	}
+	return counts
+}
+
+// backfillUntil is when the collected history of a repository starts: the
+// first snapshot written by a run, or now if there is none yet.
+func backfillUntil(db *gorm.DB, repositoryId int, now time.Time) (time.Time, error) {
+	var first RepositorySnapshot
+	err := db.Where("repository_id = ? AND backfilled = ?", repositoryId, false).Order("captured_at").First(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return now, nil
+	}
+	return first.CapturedAt, err
+}
+
+// saveBackfill writes values into the backfilled snapshot of a repository
+// captured at capturedAt, creating it if needed, so several backfills can
+// fill different columns of the same snapshot.
+func saveBackfill(db *gorm.DB, repositoryId int, capturedAt time.Time, values map[string]interface{}) error {
+	row := RepositorySnapshot{RepositoryId: repositoryId, CapturedAt: capturedAt, Backfilled: true}
+	return db.Where(row).Assign(values).FirstOrCreate(&row).Error
+}
+
+// backfillStars writes the weekly star counts of a GitHub repository that
+// predate its first collected snapshot. It returns the number of weeks.
+func backfillStars(ctx context.Context, db *gorm.DB, client *githubClient, repo Repository, now time.Time) (int, error) {
+	until, err := backfillUntil(db, repo.Id, now)
+	if err != nil {
+		return 0, err
+	}
+	loc := locationOf(repo)
+	stars, err := client.starredBefore(ctx, loc.Owner, loc.Name, until)
+	if err != nil {
+		return 0, apiError(err)
+	}
+
+	weeks := weeklyStars(stars, until)
+	tx := db.Begin()
+	for week, count := range weeks {
+		if err := saveBackfill(tx, repo.Id, week, map[string]interface{}{"stargazers_count": count}); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return len(weeks), tx.Commit().Error
+}
+
+// backfillCommits writes the monthly commit counts of a GitHub repository for
+// up to months months before its first collected snapshot. Each snapshot,
+// captured on the first of a month, holds the commits of the week and month
+// before it, counted like a run counts them, and the total commits of the
+// default branch at that time. It returns the number of months.
+func backfillCommits(ctx context.Context, db *gorm.DB, c *clients, repo Repository, months int, now time.Time) (int, error) {
+	if months < 1 {
+		return 0, fmt.Errorf("months must be at least 1, got %d", months)
+	}
+	until, err := backfillUntil(db, repo.Id, now)
+	if err != nil {
+		return 0, err
+	}
+	// The totals are worked back from today's, so the history is read up to
+	// now and not only up to until.
+	first := monthStart(until).AddDate(0, -months+1, 0)
+	loc := locationOf(repo)
+	all, total, err := c.github.commitsSince(ctx, loc.Owner, loc.Name, first.AddDate(0, -1, 0))
+	if err != nil {
+		return 0, apiError(err)
+	}
+	counted := c.commits.filter(all)
+
+	written := 0
+	tx := db.Begin()
+	for month := first; month.Before(until); month = month.AddDate(0, 1, 0) {
+		values := map[string]interface{}{
+			"commits_count_for_the_last_week":  commitsCountSince(counted, month.AddDate(0, 0, -7)) - commitsCountSince(counted, month),
+			"commits_count_for_the_last_month": commitsCountSince(counted, month.AddDate(0, -1, 0)) - commitsCountSince(counted, month),
+			"commits_count":                    total - commitsCountSince(all, month),
+		}
+		if err := saveBackfill(tx, repo.Id, month, values); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		written++
+	}
+	return written, tx.Commit().Error
+}
+
+func runBackfillStars(args []string) {
+	fs := flag.NewFlagSet("backfill-stars", flag.ExitOnError)
+	runBackfill(fs, args, "weeks", func(ctx context.Context, db *gorm.DB, c *clients, repo Repository, now time.Time) (int, error) {
+		return backfillStars(ctx, db, c.github, repo, now)
+	})
+}
+
+func runBackfillCommits(args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	months := fs.Int("months", 24, "how many months of commit history to backfill")
+	runBackfill(fs, args, "months", func(ctx context.Context, db *gorm.DB, c *clients, repo Repository, now time.Time) (int, error) {
+		return backfillCommits(ctx, db, c, repo, *months, now)
+	})
+}
+
+// runBackfill adds the flags shared by the backfill commands to fs, parses
+// args and runs backfill over every selected GitHub repository, printing how
+// many units of history each one got.
+func runBackfill(fs *flag.FlagSet, args []string, unit string, backfill func(ctx context.Context, db *gorm.DB, c *clients, repo Repository, now time.Time) (int, error)) {
+	addConfigFlag(fs)
+	var filter repositoryFilter
+	fs.StringVar(&filter.Coin, "coin", "", "only backfill the repositories of the coin with this symbol")
+	fs.StringVar(&filter.Repo, "repo", "", "only backfill this owner/name repository")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	config := loadGitHubConfig()
+	db := dbConnect(config)
+	defer closeDB(db)
+
+	now := clock.Now()
+	repos, err := selectRepositories(db, filter, now)
+	if err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+	repos = config.Repositories.apply(repos)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	c := newClients(config, now)
+
+	failed := false
+	for _, repo := range repos {
+		if locationOf(repo).Provider != providerGitHub {
+			slog.Info("Only GitHub repositories can be backfilled, skipping.", "coin_id", repo.CoinId, "repo", repoName(repo))
+			continue
+		}
+		n, err := backfill(ctx, db, c, repo, now)
+		if err != nil {
+			slog.Error("Backfill ERROR.", "coin_id", repo.CoinId, "repo", repoName(repo), "error", err)
+			failed = true
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		fmt.Printf("%s: %d %s.\n", repoName(repo), n, unit)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -2,254 +2,545 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"github.com/BurntSushi/toml"
-	"github.com/PuerkitoBio/goquery"
-	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/mysql"
-	"github.com/shurcooL/githubv4"
-	"golang.org/x/oauth2"
-	"io"
-	"log"
+	"errors"
+	"flag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"log/slog"
 	"os"
-	"strconv"
-	"strings"
+	"os/signal"
+	"runtime/debug"
+	"sync"
+	"syscall"
 	"time"
 )
 
-const (
-	confDir             = "./config/env/"
-	logFile             = "batch.log"
-	repository_base_url = "https://github.com"
-)
+const repository_base_url = "https://github.com"
 
 type (
-	Config struct {
-		Database DbConfig
-	}
-
-	DbConfig struct {
-		Driver    string
-		Host      string
-		Port      string
-		User      string
-		Password  string
-		Database  string
-		Charset   string
-		ParseTime string
-	}
-
-	Coin struct {
-		Id           int `gorm:"primary_key"`
-		Name         string
-		Symbol       string
-		Owner        string
-		Repositories []*Repository `gorm:"foreignkey:CoinId;association_foreignkey:ID"`
-		UpdatedAt    time.Time
-		CreatedAt    time.Time
-	}
-
-	Repository struct {
-		Id                          int `gorm:"primary_key"`
-		CoinId                      int
-		Coin                        Coin
-		Name                        string
-		Language                    string
-		PullRequestsCount           int
-		WatchersCount               int
-		StargazersCount             int
-		IssuesCount                 int
-		CommitsCountForTheLastWeek  int
-		CommitsCountForTheLastMonth int
-		CommitsCount                int
-		ContributorsCount           int
-		UpdatedAt                   time.Time
-		CreatedAt                   time.Time
+	job struct {
+		Repository Repository
+		// Validators are stored with the collected metrics when conditional
+		// requests are enabled.
+		Validators validators
+	}
+
+	result struct {
+		job
+		Metrics  Repository
+		Location repositoryLocation
+		Columns  []string
+		Err      error
+		Duration time.Duration
+		// Unchanged means GitHub answered that the repository did not change
+		// since its last collection, so it was not collected.
+		Unchanged bool
+		// Cached means the metrics come from the cache of a recent run.
+		Cached bool
+		// Span is the span of the collection, which the writes of the
+		// result are traced under.
+		Span trace.SpanContext
 	}
 )
 
-func (c Config) Db() (string, string) {
-	return c.Database.Driver, c.Database.DSN()
+func commitsCountForTheLastWeek(n []commitNode, now time.Time) int {
+	return commitsCountSince(n, now.AddDate(0, 0, -7))
 }
 
-func (d DbConfig) DSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%s",
-		d.User,
-		d.Password,
-		d.Host,
-		d.Port,
-		d.Database,
-		d.Charset,
-		d.ParseTime)
+func commitsCountForTheLastMonth(n []commitNode, now time.Time) int {
+	return commitsCountSince(n, now.AddDate(0, -1, 0))
 }
 
-func dbConnect() *gorm.DB {
-	environment := os.Getenv("ENVIRONMENT")
-	if environment == "" {
-		log.Fatal("Failed to get application mode, check whether ENVIRONMENT is set.")
+// worker collects metrics for every job it receives. A failing repository
+// is reported through its result and never stops the worker.
+func worker(ctx context.Context, client *clients, jobs <-chan []job, results chan<- result, timeout time.Duration) {
+	for batch := range jobs {
+		// Cached and unchanged repositories are answered before the batched
+		// query, so they cost none of it.
+		pending := batch[:0]
+		for _, j := range batch {
+			if r, ok := cachedResult(ctx, client, j); ok {
+				results <- r
+				continue
+			}
+			if r, ok := checkUnchanged(ctx, client, &j, timeout); ok {
+				results <- r
+				continue
+			}
+			pending = append(pending, j)
+		}
+		batch = pending
+		if len(batch) > 1 && client.collector(collectorGitHub) != nil {
+			prefetchBatch(ctx, client, batch, timeout)
+		}
+		for _, j := range batch {
+			results <- collectOne(ctx, client, j, timeout)
+		}
 	}
+}
 
-	config := readConfig(environment)
+// prefetchBatch fetches the GitHub repositories of a batch with a single
+// query. Whatever it misses is queried again one by one by collectGitHub.
+func prefetchBatch(ctx context.Context, client *clients, batch []job, timeout time.Duration) {
+	if err := client.github.breaker.wait(ctx); err != nil {
+		return
+	}
+	if err := client.github.budget.wait(ctx); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "prefetch", trace.WithAttributes(attribute.Int("batch.size", len(batch))))
+	defer span.End()
+	defer func() {
+		if v := recover(); v != nil {
+			slog.Error("Recovered from a panic in the batched query, falling back to single queries.", "error", newPanicError(v), "stack", string(debug.Stack()))
+		}
+	}()
 
-	db, err := gorm.Open(config.Db())
-	if err != nil {
-		log.Fatal(err.Error())
+	repos := make([]Repository, len(batch))
+	for i, j := range batch {
+		repos[i] = j.Repository
+	}
+	if err := client.github.prefetch(ctx, repos, historySince(client.now, client.commits.Windows)); err != nil {
+		slog.Debug("Batched query incomplete, falling back to single queries.", "error", err)
 	}
-	return db
 }
 
-func readConfig(environment string) Config {
-	var config Config
-	confPath := confDir + environment + ".toml"
-	_, err := toml.DecodeFile(confPath, &config)
-	if err != nil {
-		log.Fatal("Failed to read the Config.")
+// jobBatches groups GitHub repositories by size so they can share a GraphQL
+// query. Repositories of other providers get a batch of their own.
+func jobBatches(repos []Repository, size int) [][]job {
+	var batches [][]job
+	var github []job
+	for _, repo := range repos {
+		if p := repo.Provider; p != "" && p != providerGitHub {
+			batches = append(batches, []job{{Repository: repo}})
+			continue
+		}
+		github = append(github, job{Repository: repo})
+		if len(github) == size {
+			batches = append(batches, github)
+			github = nil
+		}
 	}
-
-	config.Database.Password = os.Getenv("DB_PASSWORD")
-
-	return config
+	if len(github) > 0 {
+		batches = append(batches, github)
+	}
+	return batches
 }
 
-func commitsCountForTheLastWeek(n []struct{ CommittedDate string }, now time.Time) int {
-	var count int
-	aWeekago := now.AddDate(0, 0, -7).UTC().Format(time.RFC3339)
-
-	for _, v := range n {
-		if aWeekago <= v.CommittedDate {
-			count++
+// collectOne runs a single job under its own deadline. Waiting for the GitHub
+// rate limit to reset happens before the deadline starts.
+func collectOne(ctx context.Context, client *clients, j job, timeout time.Duration) (r result) {
+	// The span includes the wait for the rate limit budget.
+	loc := locationOf(j.Repository)
+	ctx, span := tracer.Start(ctx, "collect", trace.WithAttributes(
+		attribute.Int("repository.id", j.Repository.Id),
+		attribute.String("repository.name", loc.Owner+"/"+loc.Name),
+		attribute.String("repository.provider", loc.Provider),
+		attribute.String("coin.symbol", j.Repository.Coin.Symbol),
+	))
+	github := j.Repository.Provider == "" || j.Repository.Provider == providerGitHub
+	if github {
+		err := client.github.breaker.wait(ctx)
+		if err == nil {
+			err = client.github.budget.wait(ctx)
+		}
+		if err != nil {
+			endSpan(span, err)
+			return result{job: j, Err: err, Span: span.SpanContext()}
 		}
 	}
 
-	return count
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	// A panic, such as on a partial GraphQL response or an unexpected page,
+	// fails this repository only.
+	defer func() {
+		if v := recover(); v != nil {
+			err := newPanicError(v)
+			slog.Error("Recovered from a panic.", "coin_id", j.Repository.Coin.Id, "repo", repoName(j.Repository), "error", err, "stack", string(debug.Stack()))
+			endSpan(span, err)
+			r = result{job: j, Err: err, Duration: time.Since(start), Span: span.SpanContext()}
+		}
+	}()
+	m, err := collect(ctx, client, j.Repository)
+	if github {
+		client.github.breaker.record(err)
+	}
+	m.Values.ETag, m.Values.LastModified = j.Validators.ETag, j.Validators.LastModified
+	endSpan(span, err)
+	return result{job: j, Metrics: m.Values, Location: m.Location, Columns: m.Columns, Err: err, Duration: time.Since(start), Span: span.SpanContext()}
 }
 
-func commitsCountForTheLastMonth(s []struct{ CommittedDate string }) int {
-	return len(s)
+// signalContext returns a context canceled on SIGINT or SIGTERM.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case s := <-sig:
+			slog.Warn("Received a signal, canceling the run.", "signal", s.String())
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sig)
+	}()
+	return ctx, cancel
 }
 
-func githubv4Client() *githubv4.Client {
-	src := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
-	)
-	httpClient := oauth2.NewClient(context.Background(), src)
-
-	return githubv4.NewClient(httpClient)
+// collectOptions are the flags shared by collect and retry-failed.
+type collectOptions struct {
+	Concurrency int
+	Timeout     time.Duration
+	DryRun      bool
+	LockLease   time.Duration
+	Resume      bool
+	MetricsAddr string
+	Pushgateway string
+	Progress    time.Duration
+	WriteBatch  int
+	Log         *logOptions
 }
 
-func loggingSettings() {
-	logfile, _ := os.OpenFile(logFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	multiLogFile := io.MultiWriter(os.Stdout, logfile)
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-	log.SetOutput(multiLogFile)
+func addCollectFlags(fs *flag.FlagSet) *collectOptions {
+	opts := &collectOptions{}
+	fs.IntVar(&opts.Concurrency, "concurrency", 1, "number of repositories collected in parallel")
+	fs.DurationVar(&opts.Timeout, "timeout", 2*time.Minute, "deadline for collecting a single repository")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "collect and log the changes without writing to the DB")
+	fs.DurationVar(&opts.LockLease, "lock-lease", 5*time.Minute, "lease of the lock that keeps two runs on the same DB apart, 0 disables it")
+	fs.StringVar(&opts.MetricsAddr, "metrics-addr", "", "serve Prometheus metrics on this address during the run")
+	fs.StringVar(&opts.Pushgateway, "pushgateway", "", "push Prometheus metrics to this Pushgateway URL when the run ends")
+	fs.DurationVar(&opts.Progress, "progress", 30*time.Second, "interval between progress lines, 0 disables them")
+	fs.IntVar(&opts.WriteBatch, "write-batch", defaultWriteBatch, "number of collected repositories written to the DB at once")
+	opts.Log = addLogFlags(fs)
+	return opts
 }
 
-var query struct {
-	Repository struct {
-		PullRequests struct {
-			TotalCount int
-		}
-		Stargazers struct {
-			TotalCount int
-		}
-		Watchers struct {
-			TotalCount int
-		}
-		Issues struct {
-			TotalCount int
-		}
-		PrimaryLanguage struct {
-			Name string
-		}
-		DefaultBranchRef struct {
-			Name   string
-			Target struct {
-				Commit struct {
-					History struct {
-						TotalCount int
-						Nodes      []struct {
-							CommittedDate string
-						}
-					} `graphql:"history(since: $since)"`
-				} `graphql:"... on Commit"`
-			}
-		}
-	} `graphql:"repository(owner: $owner, name: $name)"`
+// validate also applies the logging flags, so it must run right after Parse.
+func (o *collectOptions) validate() {
+	loggingSettings(o.Log)
+	if o.Concurrency < 1 {
+		fatal("concurrency must be at least 1.")
+	}
+	if o.Timeout <= 0 {
+		fatal("timeout must be positive.")
+	}
+	if o.LockLease < 0 {
+		fatal("lock-lease must not be negative.")
+	}
+	if o.Progress < 0 {
+		fatal("progress must not be negative.")
+	}
+	if o.WriteBatch < 1 {
+		fatal("write-batch must be at least 1.")
+	}
 }
 
-func main() {
-	var err error
-	db := dbConnect()
-	defer db.Close()
-	now := time.Now()
+// newMetrics creates the metrics of the process, served on MetricsAddr when
+// set. A scheduled collector keeps them across its runs.
+func (o *collectOptions) newMetrics() *runMetrics {
+	metrics := newRunMetrics()
+	if o.MetricsAddr != "" {
+		metrics.listen(o.MetricsAddr)
+	}
+	return metrics
+}
 
-	loggingSettings()
+func runCollect(args []string) {
+	fs := flag.NewFlagSet("collect", flag.ExitOnError)
+	addConfigFlag(fs)
+	opts := addCollectFlags(fs)
+	var filter repositoryFilter
+	fs.StringVar(&filter.Coin, "coin", "", "only collect the repositories of the coin with this symbol")
+	fs.StringVar(&filter.Repo, "repo", "", "only collect this owner/name repository")
+	fs.DurationVar(&filter.SinceStale, "since-stale", 0, "only collect repositories not updated for this long, e.g. 24h")
+	fs.BoolVar(&filter.IncludeMissing, "include-missing", false, "also collect repositories marked as missing")
+	schedule := fs.String("schedule", "", "keep running and collect on this cron schedule, e.g. \"0 3 * * *\"")
+	fs.BoolVar(&opts.Resume, "resume", false, "continue the last run if it was interrupted, skipping the repositories it already processed")
+	fs.Parse(args)
+	opts.validate()
+
+	config := loadGitHubConfig()
+	defer startTracing(config.Tracing)()
+	defer startSentry(config.Sentry)()
+	defer reportPanic()
+	db := dbConnect(config)
+	defer closeDB(db)
+	metrics := opts.newMetrics()
+
+	if *schedule != "" {
+		runScheduled(*schedule, func() {
+			collectSelected(db, config, filter, opts, metrics)
+		})
+		return
+	}
+	collectSelected(db, config, filter, opts, metrics)
+}
 
-	rows, err := db.Model(&Repository{}).Rows()
+// collectSelected runs one collection over the repositories filter selects.
+func collectSelected(db *gorm.DB, config Config, filter repositoryFilter, opts *collectOptions, metrics *runMetrics) {
+	now := clock.Now()
+	repos, err := selectRepositories(db, filter, now)
 	if err != nil {
-		log.Fatal("Failed to read the DB.")
+		fatal("Failed to read the DB.", "error", err)
 	}
+	collectAll(db, config, config.Repositories.apply(repos), opts, metrics, now)
+}
 
-	for rows.Next() {
-		var repo Repository
-		var coin Coin
-		var commitsCount int
-		var contributorsCount int
-		var numbers []int
+// collectAll runs the worker pool over repos and writes the results.
+func collectAll(db *gorm.DB, config Config, repos []Repository, opts *collectOptions, metrics *runMetrics, now time.Time) {
+	var err error
+	if !opts.DryRun && opts.LockLease > 0 {
+		lock, err := acquireLock(db, collectLockName, opts.LockLease)
+		if errors.Is(err, errLockHeld) {
+			slog.Warn("Another run is in progress, skipping this one.", "error", err)
+			return
+		}
+		if err != nil {
+			fatal("Failed to take the run lock.", "error", err)
+		}
+		defer func() {
+			if err := lock.release(); err != nil {
+				slog.Error("Failed to release the run lock.", "error", err)
+			}
+		}()
+	}
 
-		db.ScanRows(rows, &repo)
-		db.Model(&repo).Related(&coin)
-		log.Println("CoinId: " + strconv.Itoa(coin.Id))
+	var run *Run
+	if opts.Resume && !opts.DryRun {
+		if run, repos, err = resumeRun(db, repos); err != nil {
+			fatal("Failed to resume the run.", "error", err)
+		}
+	}
+	slog.Info("Selected repositories.", "count", len(repos))
+	unique, shared := dedupeRepositories(repos)
+	if run == nil {
+		// A dry run keeps an in-memory record only.
+		run = &Run{StartedAt: now}
+		if !opts.DryRun {
+			if run, err = startRun(db, now); err != nil {
+				fatal("Failed to record the run.", "error", err)
+			}
+		}
+	}
 
-		// GithubAPI V4
-		variables := map[string]interface{}{
-			"owner": githubv4.String(coin.Owner),
-			"name":  githubv4.String(repo.Name),
-			"since": githubv4.GitTimestamp{now.AddDate(0, -1, 0)},
+	ctx, cancel := signalContext()
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "collect run", trace.WithAttributes(
+		attribute.Int("run.id", run.Id),
+		attribute.Int("run.repositories", len(repos)),
+		attribute.Bool("run.dry", opts.DryRun),
+	))
+	defer span.End()
+
+	client := newClients(config, now)
+	notify := newNotifier(config.Notifications)
+	series := &timeSeries{}
+	if !opts.DryRun {
+		// The run goes on without the sink rather than not collecting.
+		if series, err = newTimeSeries(config.TimeSeries); err != nil {
+			slog.Error("Failed to open the time series sink.", "error", err)
+			series = &timeSeries{}
+		}
+	}
+	bus := &eventBus{}
+	if !opts.DryRun {
+		if bus, err = newEventBus(config.Events); err != nil {
+			slog.Error("Failed to connect to the event broker.", "error", err)
+			bus = &eventBus{}
 		}
+	}
+	prog := newProgress(len(repos), opts.Progress, client.github.budget)
+	progressCtx, stopProgress := context.WithCancel(ctx)
+	go prog.report(progressCtx)
+	jobs := make(chan []job)
+	collected := make(chan result)
+	results := make(chan result)
+	abort := make(chan struct{})
+	var abortOnce sync.Once
+	aborted := false
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer reportPanic()
+			worker(ctx, client, jobs, collected, opts.Timeout)
+		}()
+	}
 
-		err = githubv4Client().Query(context.Background(), &query, variables)
-		if err != nil {
-			log.Println(err)
-			log.Println("API ERROR. CoinId: " + strconv.Itoa(coin.Id))
+	go func() {
+	feed:
+		for _, batch := range jobBatches(unique, config.GitHub.batchSize()) {
+			select {
+			case jobs <- batch:
+			case <-abort:
+				break feed
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(collected)
+	}()
+	// The repositories sharing a collected one get its result too.
+	go func() {
+		for r := range collected {
+			for _, r := range shared.fanOut(r) {
+				results <- r
+			}
+		}
+		close(results)
+	}()
+
+	// DB writes happen only here, so workers never share the connection state.
+	// They are canceled together with the run, while the bookkeeping below
+	// the loop still completes. The writes of a repository are traced under
+	// its collection, except the collected metrics, which are written
+	// WriteBatch repositories at a time. Those are not canceled, so what was
+	// collected before an interruption is kept.
+	var pending []*pendingWrite
+	flush := func() {
+		writeDB := db.WithContext(context.WithoutCancel(ctx))
+		for _, w := range flushWrites(writeDB, run.Id, pending, client.commits.Windows, now) {
+			if w.Location.moved(locationOf(w.Before)) {
+				w.Logger.Warn("Repository moved.", "to", w.Location.Owner+"/"+w.Location.Name)
+			}
+			for _, a := range w.Anomalies {
+				w.Logger.Warn("Anomaly.", "kind", a.Kind, "previous", a.Previous, "current", a.Current)
+			}
+			run.Anomalies += len(w.Anomalies)
+			// The sinks outside the DB only see what was committed.
+			bus.publish(run.Id, w.Before, w.Repository, now)
+			if !w.Cached {
+				client.cache.put(ctx, w.Repository, Metrics{Values: w.Metrics, Location: w.Location, Columns: w.Columns})
+			}
+			series.add(w.Repository, w.Snapshot)
+		}
+		pending = nil
+	}
+	for r := range results {
+		runDB := db.WithContext(trace.ContextWithSpanContext(ctx, r.Span))
+		logger := slog.With(
+			"run_id", run.Id,
+			"coin_id", r.Repository.Coin.Id,
+			"repo", repoName(r.Repository),
+			"duration_ms", r.Duration.Milliseconds(),
+		)
+		metrics.observe(r)
+		prog.observe(r)
+		run.record(r)
+		if !opts.DryRun {
+			notify.observe(run, r)
+		}
+		if errors.Is(r.Err, errRateLimitExhausted) {
+			abortOnce.Do(func() {
+				logger.Error("Stopping the run.", "error", r.Err)
+				close(abort)
+				aborted = true
+			})
 			continue
 		}
-		nodes := query.Repository.DefaultBranchRef.Target.Commit.History.Nodes
-
-		// Web Scraping (commits and contributors count
-		doc, err := goquery.NewDocument(repository_base_url + "/" + coin.Owner + "/" + repo.Name)
-		if err != nil {
-			log.Println("Scraping ERROR. CoinId: " + strconv.Itoa(coin.Id))
+		// A repository cut short by the interruption is left for --resume.
+		// A collected one is recorded with its metrics.
+		if !opts.DryRun && (r.Unchanged || (r.Err != nil && ctx.Err() == nil)) {
+			if err := markProcessed(runDB, run.Id, r.Repository.Id); err != nil {
+				logger.Error("Failed to record the run progress.", "error", err)
+			}
+		}
+		if r.Unchanged {
+			logger.Info("Unchanged since the last collection, skipping.")
+			continue
+		}
+		if errors.Is(r.Err, errRepositoryMissing) && !opts.DryRun {
+			logger.Warn("Repository is missing, it will be skipped from now on.")
+			if err := runDB.Model(&r.Repository).Update("status", statusMissing).Error; err != nil {
+				logger.Error("Failed to mark the repository as missing.", "error", err)
+			}
+		}
+		if r.Err != nil {
+			logger.Error("Collection ERROR.", "error", r.Err)
+			if !opts.DryRun {
+				reportCollectionError(run.Id, r)
+				if err := recordCollectionError(runDB, run.Id, r.Repository.Id, r.Err, now); err != nil {
+					logger.Error("Failed to record the collection error.", "error", err)
+				}
+			}
+			continue
+		}
+		if opts.DryRun {
+			logger.Info("Dry run, skipping the update.", "changes", metricChanges(r.Repository, r.Metrics))
 			continue
 		}
+		if r.Cached {
+			logger.Info("Collected from the cache.")
+		} else {
+			logger.Info("Collected.")
+		}
+		if old, new := r.Repository.DefaultBranch, r.Metrics.DefaultBranch; old != "" && new != "" && old != new {
+			logger.Info("Default branch renamed.", "from", old, "to", new)
+		}
+		pending = append(pending, &pendingWrite{result: r, Before: r.Repository, Logger: logger})
+		if len(pending) >= opts.WriteBatch {
+			flush()
+		}
+	}
+	flush()
+
+	stopProgress()
+	client.cache.close()
+	client.scrape.close()
+	interrupted := ctx.Err() != nil || aborted
+	if interrupted {
+		slog.Warn("The run was interrupted before every repository was collected.")
+	}
 
-		// commitsCount
-		doc.Find("span.d-sm-inline").Each(func(_ int, s *goquery.Selection) {
-			commitsCount, _ = strconv.Atoi(strings.Replace(s.Find("strong").Text(), ",", "", -1))
-		})
+	if remaining, spent, resetAt, ok := client.github.budget.summary(); ok {
+		slog.Info("GitHub rate limit.", "remaining", remaining, "spent", spent, "reset_at", resetAt)
+		metrics.rateLimitRemaining.Set(float64(remaining))
+		run.RateLimitRemaining = remaining
+	}
+
+	if !opts.DryRun {
+		if err := run.finish(db, interrupted); err != nil {
+			slog.Error("Failed to record the run.", "error", err)
+		}
+		notify.summarize(run, interrupted, time.Since(now))
+		series.close()
+		bus.close()
 
-		// contributorsCount
-		doc.Find("div.BorderGrid-cell").Each(func(_ int, s *goquery.Selection) {
-			text := s.Find("span.Counter ").Text()
-			if text != "" {
-				counter, _ := strconv.Atoi(strings.Replace(strings.TrimSpace(text), ",", "", -1))
-				numbers = append(numbers, counter)
+		if err := refreshCoinStats(db, now); err != nil {
+			slog.Error("Failed to refresh coin stats.", "error", err)
+		}
+		if err := refreshCoinScores(db, run.Id, config.Score, now); err != nil {
+			slog.Error("Failed to refresh coin scores.", "error", err)
+		}
+		if err := refreshCoinRanks(db, run.Id, now); err != nil {
+			slog.Error("Failed to refresh coin ranks.", "error", err)
+		}
+		if client.prices != nil {
+			n, err := recordMarketSnapshots(context.WithoutCancel(ctx), db, client.prices, now)
+			if err != nil {
+				slog.Error("Failed to record the market snapshots.", "error", err)
+			} else {
+				slog.Info("Recorded market snapshots.", "count", n)
 			}
-		})
-		contributorsCount = numbers[len(numbers)-1]
-
-		db.Model(&repo).Updates(Repository{
-			Language:                    query.Repository.PrimaryLanguage.Name,
-			PullRequestsCount:           query.Repository.PullRequests.TotalCount,
-			WatchersCount:               query.Repository.Watchers.TotalCount,
-			StargazersCount:             query.Repository.Stargazers.TotalCount,
-			IssuesCount:                 query.Repository.Issues.TotalCount,
-			CommitsCountForTheLastWeek:  commitsCountForTheLastWeek(nodes, now),
-			CommitsCountForTheLastMonth: commitsCountForTheLastMonth(nodes),
-			CommitsCount:                commitsCount,
-			ContributorsCount:           contributorsCount,
-			UpdatedAt:                   now,
-		})
+		}
+
+		pruned, err := pruneSnapshots(db, config.Snapshot, now)
+		if err != nil {
+			slog.Error("Failed to prune snapshots.", "error", err)
+		} else if pruned > 0 {
+			slog.Info("Pruned snapshots.", "count", pruned)
+		}
 	}
-	log.Println("complate!")
+	metrics.finish(now, interrupted, opts.Pushgateway)
+	prog.summarize(run, interrupted)
 }
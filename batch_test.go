@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	// fakeFailing is the repository the fake collectors fail on.
+	fakeFailing = "failing"
+	fakeStars   = 42
+)
+
+// fakeCollector answers every repository of its provider with the same
+// commits, made over the month before now, without any network access.
+// calls counts the collections per owner/name.
+type fakeCollector struct {
+	provider string
+	now      time.Time
+	calls    *sync.Map
+}
+
+func (c fakeCollector) Name() string     { return "fake-" + c.provider }
+func (c fakeCollector) Provider() string { return c.provider }
+
+func (c fakeCollector) Collect(_ context.Context, _ Coin, repo Repository) (Metrics, error) {
+	loc := locationOf(repo)
+	n, _ := c.calls.LoadOrStore(repoName(repo), new(int64))
+	atomic.AddInt64(n.(*int64), 1)
+	if repo.Name == fakeFailing {
+		return Metrics{Location: loc}, apiError(errors.New("simulated API failure"))
+	}
+	// Three commits in the last week by two authors, five more before it.
+	var nodes []commitNode
+	for i, days := range []int{1, 2, 3, 10, 12, 15, 20, 25} {
+		var n commitNode
+		n.Oid = fmt.Sprintf("%040d", i)
+		n.CommittedDate = c.now.AddDate(0, 0, -days)
+		n.Author.User.Login = []string{"alice", "bob"}[i%2]
+		nodes = append(nodes, n)
+	}
+	return Metrics{
+		Values: Repository{
+			StargazersCount:             fakeStars,
+			CommitsCount:                len(nodes),
+			CommitsCountForTheLastWeek:  commitsCountForTheLastWeek(nodes, c.now),
+			CommitsCountForTheLastMonth: commitsCountForTheLastMonth(nodes, c.now),
+			Contributors:                activeContributors(nodes, c.now),
+			UpdatedAt:                   c.now,
+		},
+		Location: loc,
+		Columns:  []string{"stargazers_count", "commits_count", "commits_count_for_the_last_week", "commits_count_for_the_last_month", "updated_at"},
+	}, nil
+}
+
+// testConfig points the DB at an in-memory SQLite DB of the test's own,
+// which dbConnect migrates.
+func testConfig(t *testing.T) Config {
+	var config Config
+	config.Database = DbConfig{Driver: driverSQLite, Database: "file:" + t.Name() + "?mode=memory&cache=shared", MaxOpenConns: 1}
+	return config
+}
+
+// useFakeCollectors replaces the registered collectors with fakeCollectors
+// for the rest of the test and returns the config enabling them.
+func useFakeCollectors(t *testing.T, now time.Time, calls *sync.Map) Config {
+	saved := collectorFactories
+	t.Cleanup(func() { collectorFactories = saved })
+	collectorFactories = nil
+
+	config := testConfig(t)
+	for _, provider := range []string{providerGitHub, providerGitLab} {
+		c := fakeCollector{provider: provider, now: now, calls: calls}
+		collectorFactories = append(collectorFactories, struct {
+			Name string
+			New  func(c *clients) Collector
+		}{c.Name(), func(*clients) Collector { return c }})
+		config.Collectors.Enabled = append(config.Collectors.Enabled, c.Name())
+	}
+	return config
+}
+
+// TestCollectAll runs a whole collection on an in-memory SQLite DB against
+// fake collectors and checks the rows it wrote.
+func TestCollectAll(t *testing.T) {
+	useClock(t, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC))
+	now := clock.Now()
+	calls := &sync.Map{}
+	config := useFakeCollectors(t, now, calls)
+
+	db := dbConnect(config)
+	defer closeDB(db)
+	// A GitHub coin with one repository that collects and one that fails, a
+	// coin sharing the first one and a GitLab coin.
+	coins := []seedCoin{
+		{Symbol: "BTC", Name: "Bitcoin", Owner: "bitcoin", Repositories: []string{"bitcoin", fakeFailing}},
+		{Symbol: "WBTC", Name: "Wrapped Bitcoin", Owner: "bitcoin", Repositories: []string{"bitcoin"}},
+		{Symbol: "XTZ", Name: "Tezos", Owner: "tezos", Repositories: []string{"https://gitlab.com/tezos/tezos"}},
+	}
+	if _, err := seed(db, coins); err != nil {
+		t.Fatal(err)
+	}
+	selected, err := selectRepositories(db, repositoryFilter{}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &collectOptions{Concurrency: 2, Timeout: time.Minute, WriteBatch: defaultWriteBatch}
+	collectAll(db, config, selected, opts, newRunMetrics(), now)
+
+	var repos []Repository
+	if err := db.Preload("Coin").Order("id").Find(&repos).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 4 {
+		t.Errorf("%d repositories, want 4", len(repos))
+	}
+	for _, repo := range repos {
+		name := repoName(repo)
+		if n, _ := calls.Load(name); n == nil || *n.(*int64) != 1 {
+			t.Errorf("%s was not collected exactly once", name)
+		}
+		if repo.Name == fakeFailing {
+			if repo.StargazersCount != 0 {
+				t.Errorf("%s was updated although it failed", name)
+			}
+			continue
+		}
+		if repo.StargazersCount != fakeStars {
+			t.Errorf("%s has %d stars, want %d", name, repo.StargazersCount, fakeStars)
+		}
+		if repo.CommitsCountForTheLastWeek != 3 {
+			t.Errorf("%s has %d commits in the last week, want 3", name, repo.CommitsCountForTheLastWeek)
+		}
+		if repo.CommitsCountForTheLastMonth != 8 {
+			t.Errorf("%s has %d commits in the last month, want 8", name, repo.CommitsCountForTheLastMonth)
+		}
+		if repo.UpdatedAt.Before(now) {
+			t.Errorf("%s was updated at %s, before the run", name, repo.UpdatedAt)
+		}
+
+		var contributors int64
+		if err := db.Model(&RepositoryContributor{}).Where("repository_id = ?", repo.Id).Count(&contributors).Error; err != nil {
+			t.Fatal(err)
+		}
+		if contributors != 2 {
+			t.Errorf("%s has %d contributors, want 2", name, contributors)
+		}
+	}
+
+	var snapshots, collectionErrors int64
+	if err := db.Model(&RepositorySnapshot{}).Count(&snapshots).Error; err != nil {
+		t.Fatal(err)
+	}
+	if snapshots != 3 {
+		t.Errorf("%d snapshots, want 3", snapshots)
+	}
+	if err := db.Model(&CollectionError{}).Where("kind = ?", errorKindAPI).Count(&collectionErrors).Error; err != nil {
+		t.Fatal(err)
+	}
+	if collectionErrors != 1 {
+		t.Errorf("%d API collection errors, want 1", collectionErrors)
+	}
+
+	var run Run
+	if err := db.Last(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+	if run.FinishedAt == nil || run.Interrupted {
+		t.Error("the run did not finish")
+	}
+	if run.ApiErrors != 1 {
+		t.Errorf("the run counted %d API errors, want 1", run.ApiErrors)
+	}
+}
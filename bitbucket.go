@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"time"
+)
+
+const bitbucketAPIBaseURL = "https://api.bitbucket.org/2.0"
+
+// bitbucketClient talks to the Bitbucket Cloud REST API. BITBUCKET_TOKEN is
+// optional since public repositories can be read anonymously, but it raises
+// the rate limit.
+type bitbucketClient struct {
+	http  *http.Client
+	token string
+}
+
+type bitbucketRepository struct {
+	Language   string `json:"language"`
+	Mainbranch struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+}
+
+// bitbucketPage is the envelope of every paginated listing. Size, the total
+// number of items, is left out by some endpoints.
+type bitbucketPage struct {
+	Size   *int            `json:"size"`
+	Next   string          `json:"next"`
+	Values json.RawMessage `json:"values"`
+}
+
+func newBitbucketClient(config BitbucketConfig, h *httpClients) *bitbucketClient {
+	return &bitbucketClient{
+		http:  h.client("bitbucket"),
+		token: config.Token,
+	}
+}
+
+// get fetches path, relative to the API root, or a full "next" page URL.
+func (c *bitbucketClient) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	endpoint := path
+	if u, err := url.Parse(path); err != nil || !u.IsAbs() {
+		endpoint = bitbucketAPIBaseURL + path
+	}
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("GET %s: %w", endpoint, errRepositoryMissing)
+	default:
+		return fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// count returns the size of a listing fetched with a single item per page.
+func (c *bitbucketClient) count(ctx context.Context, path string, query url.Values) (int, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("pagelen", "1")
+
+	var page bitbucketPage
+	if err := c.get(ctx, path, query, &page); err != nil {
+		return 0, err
+	}
+	if page.Size == nil {
+		return 0, fmt.Errorf("GET %s: no size in the response", path)
+	}
+	return *page.Size, nil
+}
+
+// commitsSince pages through the commits of branch, or of every branch when
+// branch is empty, newest first until they get older than since. Bitbucket
+// has no since filter.
+func (c *bitbucketClient) commitsSince(ctx context.Context, repo, branch string, since time.Time) ([]commitNode, error) {
+	var nodes []commitNode
+	next := repo + "/commits"
+	if branch != "" {
+		next += "/" + url.PathEscape(branch)
+	}
+	query := url.Values{"pagelen": {"100"}}
+
+	for next != "" {
+		var page bitbucketPage
+		if err := c.get(ctx, next, query, &page); err != nil {
+			return nil, err
+		}
+		var commits []struct {
+			Hash   string    `json:"hash"`
+			Date   time.Time `json:"date"`
+			Author struct {
+				Raw  string `json:"raw"`
+				User struct {
+					Nickname string `json:"nickname"`
+				} `json:"user"`
+			} `json:"author"`
+			Parents []struct{} `json:"parents"`
+		}
+		if err := json.Unmarshal(page.Values, &commits); err != nil {
+			return nil, err
+		}
+		for _, commit := range commits {
+			if commit.Date.Before(since) {
+				return nodes, nil
+			}
+			var node commitNode
+			node.Oid = commit.Hash
+			node.CommittedDate = commit.Date
+			// raw is "Name <email>" as written in the commit.
+			if addr, err := mail.ParseAddress(commit.Author.Raw); err == nil {
+				node.Author.Name, node.Author.Email = addr.Name, addr.Address
+			} else {
+				node.Author.Name = commit.Author.Raw
+			}
+			node.Author.User.Login = commit.Author.User.Nickname
+			node.Parents.TotalCount = len(commit.Parents)
+			nodes = append(nodes, node)
+		}
+		// The next URL already carries the query.
+		next, query = page.Next, nil
+	}
+	return nodes, nil
+}
+
+// lastCommitDate is the date of the newest commit of branch, zero for an
+// empty repository.
+func (c *bitbucketClient) lastCommitDate(ctx context.Context, repo, branch string) (time.Time, error) {
+	var page bitbucketPage
+	if err := c.get(ctx, repo+"/commits/"+url.PathEscape(branch), url.Values{"pagelen": {"1"}}, &page); err != nil {
+		return time.Time{}, err
+	}
+	var commits []struct {
+		Date time.Time `json:"date"`
+	}
+	if err := json.Unmarshal(page.Values, &commits); err != nil {
+		return time.Time{}, err
+	}
+	if len(commits) == 0 {
+		return time.Time{}, nil
+	}
+	return commits[0].Date, nil
+}
+
+// issuesCount counts the issues matching q. Repositories without an issue
+// tracker answer 404, which counts as none.
+func (c *bitbucketClient) issuesCount(ctx context.Context, repo, q string) (int, error) {
+	n, err := c.count(ctx, repo+"/issues", url.Values{"q": {q}})
+	if errors.Is(err, errRepositoryMissing) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// bitbucketColumns are the columns collectBitbucket reads.
+var bitbucketColumns = []string{
+	"status", "language", "default_branch", "last_commit_at", "updated_at",
+	"pull_requests_count", "open_pull_requests_count", "closed_pull_requests_count", "merged_pull_requests_count",
+	"watchers_count", "issues_count", "open_issues_count", "closed_issues_count",
+	"commits_count_for_the_last_week", "commits_count_for_the_last_month",
+	"forks_count", "tags_count",
+}
+
+func collectBitbucket(ctx context.Context, client *bitbucketClient, coin Coin, repo Repository, now time.Time, commits commitSettings) (Repository, error) {
+	path := "/repositories/" + url.PathEscape(coin.Owner) + "/" + url.PathEscape(repo.Name)
+
+	var r bitbucketRepository
+	if err := client.get(ctx, path, nil, &r); err != nil {
+		return Repository{}, apiError(err)
+	}
+	pullRequests := map[string]int{}
+	for _, state := range []string{"OPEN", "MERGED", "DECLINED", "SUPERSEDED"} {
+		n, err := client.count(ctx, path+"/pullrequests", url.Values{"state": {state}})
+		if err != nil {
+			return Repository{}, apiError(err)
+		}
+		pullRequests[state] = n
+	}
+	openIssues, err := client.issuesCount(ctx, path, `state="new" OR state="open" OR state="on hold"`)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	closedIssues, err := client.issuesCount(ctx, path, `state="resolved" OR state="closed" OR state="invalid" OR state="duplicate" OR state="wontfix"`)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	watchers, err := client.count(ctx, path+"/watchers", nil)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	forks, err := client.count(ctx, path+"/forks", nil)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	tags, err := client.count(ctx, path+"/refs/tags", nil)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	var lastCommit time.Time
+	if r.Mainbranch.Name != "" {
+		if lastCommit, err = client.lastCommitDate(ctx, path, r.Mainbranch.Name); err != nil {
+			return Repository{}, apiError(err)
+		}
+	}
+	branch := r.Mainbranch.Name
+	if commits.allBranches(repo) {
+		branch = ""
+	}
+	nodes, err := client.commitsSince(ctx, path, branch, historySince(now, commits.Windows))
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	nodes = commits.filter(nodes)
+
+	// Bitbucket has no stars, releases or archiving, and it offers no total
+	// commits or contributors count nor merge dates, so those are left
+	// untouched.
+	closedPullRequests := pullRequests["DECLINED"] + pullRequests["SUPERSEDED"]
+	return Repository{
+		Status:                      statusActive,
+		Language:                    r.Language,
+		PullRequestsCount:           pullRequests["OPEN"] + pullRequests["MERGED"] + closedPullRequests,
+		OpenPullRequestsCount:       pullRequests["OPEN"],
+		ClosedPullRequestsCount:     closedPullRequests,
+		MergedPullRequestsCount:     pullRequests["MERGED"],
+		WatchersCount:               watchers,
+		IssuesCount:                 openIssues + closedIssues,
+		OpenIssuesCount:             openIssues,
+		ClosedIssuesCount:           closedIssues,
+		CommitsCountForTheLastWeek:  commitsCountForTheLastWeek(nodes, now),
+		CommitsCountForTheLastMonth: commitsCountForTheLastMonth(nodes, now),
+		CommitWindows:               commitWindowCounts(nodes, now, commits.Windows),
+		Contributors:                activeContributors(nodes, now),
+		AuthorDomains:               commits.authorDomains(nodes, now),
+		ForksCount:                  forks,
+		TagsCount:                   tags,
+		DefaultBranch:               r.Mainbranch.Name,
+		LastCommitAt:                optionalTime(lastCommit),
+		UpdatedAt:                   now,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"github.com/shurcooL/githubv4"
+	"time"
+)
+
+// branchesQuery lists the branches of a repository with the first page of
+// each one's history.
+type branchesQuery struct {
+	Repository struct {
+		Refs struct {
+			Nodes []struct {
+				Name   string
+				Target struct {
+					Commit struct {
+						History historyConnection `graphql:"history(since: $since, first: 100)"`
+					} `graphql:"... on Commit"`
+				}
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   githubv4.String
+			}
+		} `graphql:"refs(refPrefix: \"refs/heads/\", first: 25, after: $cursor)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+	RateLimit rateLimit
+}
+
+// branchHistoryQuery fetches the pages of one branch's history after the
+// first one.
+type branchHistoryQuery struct {
+	Repository struct {
+		Ref struct {
+			Target struct {
+				Commit struct {
+					History historyConnection `graphql:"history(since: $since, first: 100, after: $cursor)"`
+				} `graphql:"... on Commit"`
+			}
+		} `graphql:"ref(qualifiedName: $ref)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+	RateLimit rateLimit
+}
+
+// allBranchesHistory returns the commits since since of every branch. A
+// commit reachable from several branches is returned once.
+func (c *githubClient) allBranchesHistory(ctx context.Context, owner, name string, since time.Time) ([]commitNode, error) {
+	var nodes []commitNode
+	var cursor *githubv4.String
+	for {
+		var query branchesQuery
+		variables := map[string]interface{}{
+			"owner":  githubv4.String(owner),
+			"name":   githubv4.String(name),
+			"since":  githubv4.GitTimestamp{Time: since},
+			"cursor": cursor,
+		}
+		if err := c.budget.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+		c.budget.update(query.RateLimit)
+
+		refs := query.Repository.Refs
+		for _, branch := range refs.Nodes {
+			history, err := c.followBranchHistory(ctx, owner, name, "refs/heads/"+branch.Name, since, branch.Target.Commit.History)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, history...)
+		}
+		if !refs.PageInfo.HasNextPage {
+			return uniqueCommits(nodes), nil
+		}
+		cursor = &refs.PageInfo.EndCursor
+	}
+}
+
+// followBranchHistory is followHistory for the branch called ref.
+func (c *githubClient) followBranchHistory(ctx context.Context, owner, name, ref string, since time.Time, first historyConnection) ([]commitNode, error) {
+	nodes := first.Nodes
+	pageInfo := first.PageInfo
+
+	for pageInfo.HasNextPage {
+		var query branchHistoryQuery
+		variables := map[string]interface{}{
+			"owner":  githubv4.String(owner),
+			"name":   githubv4.String(name),
+			"ref":    githubv4.String(ref),
+			"since":  githubv4.GitTimestamp{Time: since},
+			"cursor": pageInfo.EndCursor,
+		}
+
+		if err := c.budget.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+		c.budget.update(query.RateLimit)
+
+		history := query.Repository.Ref.Target.Commit.History
+		nodes = append(nodes, history.Nodes...)
+		pageInfo = history.PageInfo
+	}
+	return nodes, nil
+}
+
+// uniqueCommits drops the repeated commits of branches that share history.
+// Commits without an Oid are always kept.
+func uniqueCommits(nodes []commitNode) []commitNode {
+	seen := make(map[string]bool, len(nodes))
+	unique := nodes[:0]
+	for _, n := range nodes {
+		if n.Oid != "" {
+			if seen[n.Oid] {
+				continue
+			}
+			seen[n.Oid] = true
+		}
+		unique = append(unique, n)
+	}
+	return unique
+}
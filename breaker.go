@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 10
+	defaultBreakerCoolDown  = 5 * time.Minute
+)
+
+// circuitBreaker pauses the GitHub API calls of every worker for a cool-down
+// once threshold repositories in a row failed with API errors, so an outage
+// does not fail the rest of the run one repository at a time. After the
+// cool-down a single further failure trips it again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	coolDown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+func newCircuitBreaker(config GitHubConfig) *circuitBreaker {
+	b := &circuitBreaker{threshold: config.BreakerThreshold, coolDown: config.BreakerCoolDown.Duration}
+	if b.threshold <= 0 {
+		b.threshold = defaultBreakerThreshold
+	}
+	if b.coolDown <= 0 {
+		b.coolDown = defaultBreakerCoolDown
+	}
+	return b
+}
+
+// record counts the outcome of collecting a GitHub repository. Only API
+// errors count; any other outcome means GitHub answered.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil || errorKind(err) != errorKindAPI || errors.Is(err, context.Canceled) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold && !time.Now().Before(b.openUntil) {
+		b.openUntil = time.Now().Add(b.coolDown)
+		slog.Warn("GitHub keeps failing, pausing the API calls.", "failures", b.failures, "resume_at", b.openUntil, "error", err)
+	}
+}
+
+// wait blocks while the breaker is open.
+func (b *circuitBreaker) wait(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delay := time.Until(b.openUntil)
+	if delay <= 0 {
+		return nil
+	}
+
+	// Holding the lock makes the other workers wait for the same cool-down.
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+	b.failures = b.threshold - 1
+	slog.Info("Resuming the GitHub API calls.")
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultBrowserWait = 2 * time.Second
+
+// headlessBrowser renders pages in a headless Chrome, for the counts GitHub
+// fills in with JavaScript, which a static fetch never sees. Chrome is
+// started on the first page and shared by every worker, each page in a tab
+// of its own.
+type headlessBrowser struct {
+	options []chromedp.ExecAllocatorOption
+	wait    time.Duration
+
+	once   sync.Once
+	ctx    context.Context
+	cancel context.CancelFunc
+	err    error
+}
+
+func newHeadlessBrowser(config ScrapeConfig, h HTTPConfig) *headlessBrowser {
+	options := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.UserAgent(h.userAgent()))
+	if config.BrowserPath != "" {
+		options = append(options, chromedp.ExecPath(config.BrowserPath))
+	}
+	if h.Proxy != "" {
+		options = append(options, chromedp.ProxyServer(h.Proxy))
+	}
+	wait := config.BrowserWait.Duration
+	if wait == 0 {
+		wait = defaultBrowserWait
+	}
+	return &headlessBrowser{options: options, wait: wait}
+}
+
+func (b *headlessBrowser) start() error {
+	b.once.Do(func() {
+		allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), b.options...)
+		ctx, cancel := chromedp.NewContext(allocCtx)
+		b.ctx, b.cancel = ctx, func() {
+			cancel()
+			cancelAlloc()
+		}
+		// Running no action launches the browser.
+		if b.err = chromedp.Run(ctx); b.err != nil {
+			// Canceling a chromedp context twice blocks.
+			b.cancel()
+			b.cancel = nil
+		}
+	})
+	return b.err
+}
+
+// fetch renders url and returns the page once its scripts had wait to run.
+func (b *headlessBrowser) fetch(ctx context.Context, url string) (*goquery.Document, error) {
+	if err := b.start(); err != nil {
+		return nil, err
+	}
+	tab, cancel := chromedp.NewContext(b.ctx)
+	defer cancel()
+	// The tab derives from the browser, so ctx ends it separately.
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	var html string
+	err := chromedp.Run(tab,
+		chromedp.Navigate(url),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.Sleep(b.wait),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return goquery.NewDocumentFromReader(strings.NewReader(html))
+}
+
+func (b *headlessBrowser) close() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+}
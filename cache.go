@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCacheTTL = time.Hour
+
+	// cacheKeyPrefix starts the Redis keys of the cached metrics, followed
+	// by provider/owner/name.
+	cacheKeyPrefix = "commit-count-collector:repository:"
+
+	cacheTimeout = 5 * time.Second
+)
+
+// repositoryCache keeps the metrics a run wrote in Redis for TTL, so a run
+// started soon after, such as a manual one next to the scheduled one, reuses
+// them instead of spending the rate limit again. Redis failures are logged
+// and only cost the saving.
+type repositoryCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// newRepositoryCache connects lazily to the configured Redis. Without an
+// address it returns a cache that never hits.
+func newRepositoryCache(config RedisConfig) *repositoryCache {
+	if config.Addr == "" {
+		return &repositoryCache{}
+	}
+	ttl := config.TTL.Duration
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+	return &repositoryCache{
+		client: redis.NewClient(&redis.Options{Addr: config.Addr, Password: config.Password, DB: config.DB}),
+		ttl:    ttl,
+	}
+}
+
+// cacheKey is the key of repo's location, ignoring case like GitHub does.
+func cacheKey(repo Repository) string {
+	loc := locationOf(repo)
+	return cacheKeyPrefix + strings.ToLower(loc.Provider+"/"+loc.Owner+"/"+loc.Name)
+}
+
+// get returns the metrics cached for repo. They are gob encoded, as the
+// values bound for the side tables are left out of JSON.
+func (c *repositoryCache) get(ctx context.Context, repo Repository) (Metrics, bool) {
+	if c.client == nil {
+		return Metrics{}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, cacheTimeout)
+	defer cancel()
+	data, err := c.client.Get(ctx, cacheKey(repo)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Metrics{}, false
+	}
+	if err != nil {
+		slog.Warn("Failed to read the cache.", "repo", repoName(repo), "error", err)
+		return Metrics{}, false
+	}
+	var m Metrics
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&m); err != nil {
+		slog.Warn("Failed to decode the cached metrics.", "repo", repoName(repo), "error", err)
+		return Metrics{}, false
+	}
+	return m, true
+}
+
+// put caches the metrics written for repo for the TTL.
+func (c *repositoryCache) put(ctx context.Context, repo Repository, m Metrics) {
+	if c.client == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		slog.Warn("Failed to encode the metrics for the cache.", "repo", repoName(repo), "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, cacheTimeout)
+	defer cancel()
+	if err := c.client.Set(ctx, cacheKey(repo), buf.Bytes(), c.ttl).Err(); err != nil {
+		slog.Warn("Failed to write the cache.", "repo", repoName(repo), "error", err)
+	}
+}
+
+func (c *repositoryCache) close() {
+	if c.client == nil {
+		return
+	}
+	if err := c.client.Close(); err != nil {
+		slog.Error("Failed to close the cache.", "error", err)
+	}
+}
+
+// cachedResult answers j from the cache, when it holds fresh metrics.
+func cachedResult(ctx context.Context, client *clients, j job) (result, bool) {
+	m, ok := client.cache.get(ctx, j.Repository)
+	if !ok {
+		return result{}, false
+	}
+	return result{job: j, Metrics: m.Values, Location: m.Location, Columns: m.Columns, Cached: true}, true
+}
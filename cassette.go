@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shurcooL/githubv4"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	cassetteRecord = "record"
+	cassetteReplay = "replay"
+)
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+type (
+	// graphQLClient runs the GitHub GraphQL queries. githubv4.Client is the
+	// real one; cassetteClient records or replays its answers.
+	graphQLClient interface {
+		Query(ctx context.Context, q interface{}, variables map[string]interface{}) error
+	}
+
+	// cassetteClient keeps one file per query in dir, named after the shape
+	// of the query and its variables. Recording runs the query on next and writes
+	// the answer; replaying reads it back without touching the network, so
+	// a collection can be reproduced offline from a recorded run. The REST
+	// calls are not recorded.
+	cassetteClient struct {
+		next graphQLClient
+		dir  string
+		mode string
+	}
+
+	// cassetteEntry is the recorded answer to a query. A query can fail and
+	// still return data, such as a batch missing one repository.
+	cassetteEntry struct {
+		Data  json.RawMessage `json:"data"`
+		Error string          `json:"error,omitempty"`
+	}
+)
+
+func (c *cassetteClient) Query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
+	path, err := c.path(q, variables)
+	if err != nil {
+		return err
+	}
+	if c.mode == cassetteReplay {
+		return c.replay(path, q)
+	}
+
+	qerr := c.next.Query(ctx, q, variables)
+	entry := cassetteEntry{}
+	if qerr != nil {
+		entry.Error = qerr.Error()
+	}
+	if entry.Data, err = json.Marshal(q); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	return qerr
+}
+
+func (c *cassetteClient) replay(path string, q interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no recorded answer in %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	var entry cassetteEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := json.Unmarshal(entry.Data, q); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if entry.Error != "" {
+		return errors.New(entry.Error)
+	}
+	return nil
+}
+
+// path is the file of a query. It is named after queryShape, so a changed
+// query never replays an answer to the old one. The timestamps are left
+// out, as they move with the time of the run.
+func (c *cassetteClient) path(q interface{}, variables map[string]interface{}) (string, error) {
+	named := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		if _, ok := v.(githubv4.GitTimestamp); !ok {
+			named[k] = v
+		}
+	}
+	vars, err := json.Marshal(named)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(queryShape(reflect.TypeOf(q))), vars...))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// queryShape writes out every field of a query type with its graphql tag,
+// nested fields included, which is what githubv4 builds the query from.
+// The type name alone would not change when a field is added.
+func queryShape(t reflect.Type) string {
+	var b strings.Builder
+	writeQueryShape(&b, t, map[reflect.Type]bool{})
+	return b.String()
+}
+
+func writeQueryShape(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if t.Kind() == reflect.Ptr {
+			b.WriteString("*")
+		} else {
+			b.WriteString("[]")
+		}
+		t = t.Elem()
+	}
+	// Like githubv4, a type that decodes itself, such as time.Time, is a
+	// scalar.
+	if t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(jsonUnmarshaler) || seen[t] {
+		b.WriteString(t.String())
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+	b.WriteString("{")
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fmt.Fprintf(b, "%s %q ", f.Name, f.Tag.Get("graphql"))
+		writeQueryShape(b, f.Type, seen)
+		b.WriteString(";")
+	}
+	b.WriteString("}")
+}
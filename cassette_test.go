@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/shurcooL/githubv4"
+	"testing"
+	"time"
+)
+
+// graphQLFunc answers the queries with a function instead of GitHub.
+type graphQLFunc func(q interface{}, variables map[string]interface{}) error
+
+func (f graphQLFunc) Query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
+	return f(q, variables)
+}
+
+func TestCassetteRecordsAndReplays(t *testing.T) {
+	dir := t.TempDir()
+	variables := map[string]interface{}{"owner": githubv4.String("bitcoin"), "name": githubv4.String("bitcoin")}
+	github := graphQLFunc(func(q interface{}, _ map[string]interface{}) error {
+		q.(*securityPolicyQuery).Repository.IsSecurityPolicyEnabled = true
+		q.(*securityPolicyQuery).RateLimit.Remaining = 4999
+		return errors.New("partial answer")
+	})
+
+	recorder := &cassetteClient{next: github, dir: dir, mode: cassetteRecord}
+	if err := recorder.Query(context.Background(), &securityPolicyQuery{}, variables); err == nil || err.Error() != "partial answer" {
+		t.Fatalf("recording returned %v, want the error of the query", err)
+	}
+
+	player := &cassetteClient{dir: dir, mode: cassetteReplay}
+	var q securityPolicyQuery
+	err := player.Query(context.Background(), &q, variables)
+	if err == nil || err.Error() != "partial answer" {
+		t.Errorf("replaying returned %v, want the recorded error", err)
+	}
+	if !q.Repository.IsSecurityPolicyEnabled || q.RateLimit.Remaining != 4999 {
+		t.Errorf("replayed %+v, want the recorded answer", q)
+	}
+
+	variables["name"] = githubv4.String("secp256k1")
+	if err := player.Query(context.Background(), &securityPolicyQuery{}, variables); err == nil {
+		t.Error("replaying a query that was not recorded did not fail")
+	}
+}
+
+func TestCassetteReplaysGitHubClient(t *testing.T) {
+	client := &githubClient{
+		graphQLClient: &cassetteClient{dir: "testdata/cassettes", mode: cassetteReplay},
+		budget:        newRateBudget(GitHubConfig{}),
+	}
+	enabled, err := client.hasSecurityPolicy(context.Background(), "bitcoin", "bitcoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Error("hasSecurityPolicy = false, want the recorded true")
+	}
+	if remaining, _, _, _ := client.budget.summary(); remaining != 4999 {
+		t.Errorf("remaining rate limit = %d, want the recorded 4999", remaining)
+	}
+}
+
+func TestCassettePathFollowsQueryShape(t *testing.T) {
+	c := &cassetteClient{dir: "cassettes"}
+	path := func(q interface{}, variables map[string]interface{}) string {
+		t.Helper()
+		p, err := c.path(q, variables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	variables := map[string]interface{}{"owner": githubv4.String("bitcoin")}
+
+	// Both types are named main.query, only their fields differ.
+	older := func() interface{} {
+		type query struct {
+			Repository struct{ ForkCount int } `graphql:"repository(owner: $owner)"`
+		}
+		return &query{}
+	}()
+	newer := func() interface{} {
+		type query struct {
+			Repository struct{ ForkCount, StargazerCount int } `graphql:"repository(owner: $owner)"`
+		}
+		return &query{}
+	}()
+	if path(older, variables) == path(newer, variables) {
+		t.Error("a query with another field has the same path")
+	}
+
+	since := map[string]interface{}{"owner": githubv4.String("bitcoin"), "since": githubv4.GitTimestamp{Time: time.Now()}}
+	if path(older, variables) != path(older, since) {
+		t.Error("the timestamp of the run changed the path")
+	}
+}
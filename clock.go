@@ -0,0 +1,16 @@
+package main
+
+import "time"
+
+// Clock tells the time. The commands take the time of a run, which decides
+// the windows its commits are counted over, from clock rather than calling
+// time.Now themselves, so it can be replaced by a fixed one.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+var clock Clock = systemClock{}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// codeFrequencyWeeks bounds how many of the most recent weeks are kept, since
+// the stats API returns the whole history of the repository.
+const codeFrequencyWeeks = 52
+
+// codeFrequency reads the weekly additions and deletions from the REST stats
+// API. GitHub answers 202 while it computes the statistics in the background,
+// in which case no weeks are returned and the next run picks them up.
+func (c *githubClient) codeFrequency(ctx context.Context, owner, name string, now time.Time) ([]RepositoryCodeFrequency, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/stats/code_frequency", c.apiURL, owner, name)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusAccepted, http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+
+	// Each week is [unix timestamp, additions, deletions], deletions negative.
+	var weeks [][3]int64
+	if err := json.NewDecoder(res.Body).Decode(&weeks); err != nil {
+		return nil, err
+	}
+	from := now.AddDate(0, 0, -7*codeFrequencyWeeks)
+	var frequency []RepositoryCodeFrequency
+	for _, w := range weeks {
+		week := time.Unix(w[0], 0).UTC()
+		if week.Before(from) {
+			continue
+		}
+		frequency = append(frequency, RepositoryCodeFrequency{
+			Week:      week,
+			Additions: int(w[1]),
+			Deletions: int(-w[2]),
+		})
+	}
+	return frequency, nil
+}
+
+// saveCodeFrequency upserts the weekly additions and deletions of a
+// repository.
+func saveCodeFrequency(db *gorm.DB, repositoryId int, frequency []RepositoryCodeFrequency, now time.Time) error {
+	for _, f := range frequency {
+		row := RepositoryCodeFrequency{RepositoryId: repositoryId, Week: f.Week}
+		err := db.Where(row).
+			Assign(map[string]interface{}{"additions": f.Additions, "deletions": f.Deletions, "updated_at": now}).
+			FirstOrCreate(&row).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const coingeckoAPIBaseURL = "https://api.coingecko.com/api/v3"
+
+type (
+	// coingeckoClient reads coin metadata from the CoinGecko API, which
+	// answers anonymously at a low rate limit.
+	coingeckoClient struct {
+		http   *http.Client
+		apiURL string
+		key    string
+	}
+
+	coingeckoCoin struct {
+		Id            string `json:"id"`
+		Symbol        string `json:"symbol"`
+		Name          string `json:"name"`
+		MarketCapRank int    `json:"market_cap_rank"`
+		Links         struct {
+			Homepage []string `json:"homepage"`
+			ReposURL struct {
+				GitHub []string `json:"github"`
+			} `json:"repos_url"`
+		} `json:"links"`
+	}
+
+	// enrichment is what enrich found for a coin.
+	enrichment struct {
+		Id       string
+		Rank     int
+		Added    int
+		Unlisted int
+	}
+)
+
+func newCoinGeckoClient(config CoinGeckoConfig, h *httpClients) *coingeckoClient {
+	apiURL := strings.TrimSuffix(config.APIURL, "/")
+	if apiURL == "" {
+		apiURL = coingeckoAPIBaseURL
+	}
+	return &coingeckoClient{
+		http:   h.clientWith("coingecko", &retryTransport{base: h.transport, maxAttempts: defaultMaxAttempts}),
+		apiURL: apiURL,
+		key:    config.APIKey,
+	}
+}
+
+func (c *coingeckoClient) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	endpoint := c.apiURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if c.key != "" {
+		header := "x-cg-demo-api-key"
+		if strings.Contains(c.apiURL, "pro-api.coingecko.com") {
+			header = "x-cg-pro-api-key"
+		}
+		req.Header.Set(header, c.key)
+	}
+
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("GET %s: %w", endpoint, errNotFound)
+	default:
+		return fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// coin returns the metadata of the coin with the CoinGecko id.
+func (c *coingeckoClient) coin(ctx context.Context, id string) (coingeckoCoin, error) {
+	query := url.Values{}
+	for _, section := range []string{"localization", "tickers", "market_data", "community_data", "developer_data"} {
+		query.Set(section, "false")
+	}
+	var coin coingeckoCoin
+	err := c.get(ctx, "/coins/"+url.PathEscape(id), query, &coin)
+	return coin, err
+}
+
+// search returns the id of the coin with the symbol. Symbols are not unique
+// on CoinGecko, so the one with the best market cap rank wins.
+func (c *coingeckoClient) search(ctx context.Context, symbol string) (string, error) {
+	var body struct {
+		Coins []coingeckoCoin `json:"coins"`
+	}
+	if err := c.get(ctx, "/search", url.Values{"query": {symbol}}, &body); err != nil {
+		return "", err
+	}
+	var best *coingeckoCoin
+	for i, coin := range body.Coins {
+		if !strings.EqualFold(coin.Symbol, symbol) {
+			continue
+		}
+		if best == nil || coin.MarketCapRank > 0 && (best.MarketCapRank == 0 || coin.MarketCapRank < best.MarketCapRank) {
+			best = &body.Coins[i]
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("symbol %s on CoinGecko %w", symbol, errNotFound)
+	}
+	return best.Id, nil
+}
+
+// enrich stores the CoinGecko id, market cap rank and homepage of the coin
+// and adds the GitHub repositories CoinGecko links to under the coin's
+// owner. The ones under another owner, and the coin's repositories CoinGecko
+// does not link to, are only reported. A dry run writes nothing.
+func enrich(ctx context.Context, db *gorm.DB, client *coingeckoClient, coin Coin, dryRun bool) (enrichment, error) {
+	id := coin.CoingeckoId
+	if id == "" {
+		var err error
+		if id, err = client.search(ctx, coin.Symbol); err != nil {
+			return enrichment{}, err
+		}
+	}
+	found, err := client.coin(ctx, id)
+	if err != nil {
+		return enrichment{}, err
+	}
+	logger := slog.With("coin_id", coin.Id, "coingecko_id", found.Id)
+	if !strings.EqualFold(found.Symbol, coin.Symbol) {
+		logger.Warn("CoinGecko has another symbol for the coin.", "symbol", coin.Symbol, "coingecko_symbol", found.Symbol)
+	}
+	e := enrichment{Id: found.Id, Rank: found.MarketCapRank}
+
+	var homepage string
+	for _, h := range found.Links.Homepage {
+		if h = strings.TrimSpace(h); h != "" {
+			homepage = h
+			break
+		}
+	}
+	if !dryRun {
+		err := db.Model(&coin).Updates(map[string]interface{}{
+			"coingecko_id":    found.Id,
+			"market_cap_rank": found.MarketCapRank,
+			"homepage":        homepage,
+		}).Error
+		if err != nil {
+			return e, err
+		}
+	}
+
+	listed := map[string]bool{}
+	for _, raw := range found.Links.ReposURL.GitHub {
+		loc, err := parseRepositoryURL(raw)
+		// The link is often to the organization rather than a repository.
+		if err != nil || loc.Provider != providerGitHub {
+			logger.Debug("Skipped a CoinGecko link that is not a GitHub repository.", "url", raw)
+			continue
+		}
+		if !strings.EqualFold(loc.Owner, coin.Owner) {
+			logger.Warn("CoinGecko links to a repository of another owner.", "repo", loc.Owner+"/"+loc.Name, "owner", coin.Owner)
+			continue
+		}
+		listed[strings.ToLower(loc.Name)] = true
+		if dryRun {
+			if _, err := findRepository(db.Unscoped(), coin, loc.Name); errors.Is(err, errNotFound) {
+				e.Added++
+			}
+			continue
+		}
+		_, err = addRepository(db, coin, loc)
+		switch {
+		case err == nil:
+			e.Added++
+			logger.Info("Added a repository listed on CoinGecko.", "repo", loc.Owner+"/"+loc.Name)
+		case !errors.Is(err, errDuplicate):
+			return e, err
+		}
+	}
+
+	var names []string
+	if err := db.Model(&Repository{}).Where("coin_id = ?", coin.Id).Pluck("name", &names).Error; err != nil {
+		return e, err
+	}
+	for _, name := range names {
+		if !listed[strings.ToLower(name)] {
+			e.Unlisted++
+		}
+	}
+	return e, nil
+}
+
+func runEnrich(args []string) {
+	fs := flag.NewFlagSet("enrich", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("coin", "", "only enrich this coin")
+	id := fs.String("coingecko-id", "", "CoinGecko id of the coin given with -coin, when its symbol is ambiguous")
+	dryRun := fs.Bool("dry-run", false, "report what CoinGecko has without writing it")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+	if *id != "" && *symbol == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	config := loadConfig()
+	db := dbConnect(config)
+	defer closeDB(db)
+
+	var coins []Coin
+	if *symbol != "" {
+		coin, err := findCoin(db, *symbol)
+		if err != nil {
+			fatal("Failed to read the DB.", "error", err)
+		}
+		if *id != "" {
+			coin.CoingeckoId = *id
+		}
+		coins = append(coins, coin)
+	} else if err := db.Where("active = ?", true).Order("id").Find(&coins).Error; err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	h, err := newHTTPClients(config.HTTP)
+	if err != nil {
+		fatal("Invalid [HTTP] config.", "error", err)
+	}
+	client := newCoinGeckoClient(config.CoinGecko, h)
+
+	failed := false
+	for _, coin := range coins {
+		e, err := enrich(ctx, db, client, coin, *dryRun)
+		if err != nil {
+			slog.Error("Enrichment ERROR.", "coin_id", coin.Id, "symbol", coin.Symbol, "error", err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: %s, rank %d, %d repositories added, %d not listed on CoinGecko.\n", coin.Symbol, e.Id, e.Rank, e.Added, e.Unlisted)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	providerGitHub    = "github"
+	providerGitLab    = "gitlab"
+	providerBitbucket = "bitbucket"
+	providerGitea     = "gitea"
+
+	statusActive   = "active"
+	statusArchived = "archived"
+	statusMissing  = "missing"
+)
+
+// errRepositoryMissing means the provider answered 404 for the repository.
+var errRepositoryMissing = errors.New("repository not found")
+
+const (
+	errorKindAPI     = "api"
+	errorKindScrape  = "scrape"
+	errorKindClone   = "clone"
+	errorKindMissing = "missing"
+	errorKindPanic   = "panic"
+	errorKindOther   = "other"
+)
+
+// collectError tells API failures apart from scraping failures.
+type collectError struct {
+	Kind string
+	Err  error
+}
+
+func (e *collectError) Error() string {
+	return e.Kind + " error: " + e.Err.Error()
+}
+
+func (e *collectError) Unwrap() error {
+	return e.Err
+}
+
+func apiError(err error) error {
+	return &collectError{Kind: errorKindAPI, Err: err}
+}
+
+func scrapeError(err error) error {
+	return &collectError{Kind: errorKindScrape, Err: err}
+}
+
+func cloneError(err error) error {
+	return &collectError{Kind: errorKindClone, Err: err}
+}
+
+// panicError is a panic recovered while collecting a repository.
+type panicError struct {
+	Value interface{}
+	pcs   []uintptr
+}
+
+// newPanicError must be called from the deferred function that recovered
+// value, while the stack still holds the frames that panicked.
+func newPanicError(value interface{}) *panicError {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(4, pcs)
+	return &panicError{Value: value, pcs: pcs[:n]}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// StackTrace is where the panic happened. Sentry reads it by this name.
+func (e *panicError) StackTrace() []uintptr {
+	return e.pcs
+}
+
+// errorKind returns the kind of a collection error, or errorKindOther.
+func errorKind(err error) string {
+	if errors.Is(err, errRepositoryMissing) {
+		return errorKindMissing
+	}
+	var pe *panicError
+	if errors.As(err, &pe) {
+		return errorKindPanic
+	}
+	var ce *collectError
+	if errors.As(err, &ce) {
+		return ce.Kind
+	}
+	return errorKindOther
+}
+
+// clients holds one API client per supported provider, the price provider
+// if one is configured and the collectors enabled for the run started at
+// now.
+type clients struct {
+	github      *githubClient
+	gitlab      *gitlabClient
+	bitbucket   *bitbucketClient
+	gitea       *giteaClient
+	scrape      scraper
+	prices      priceProvider
+	commits     commitSettings
+	conditional conditionalSettings
+	cache       *repositoryCache
+	collectors  []Collector
+	now         time.Time
+}
+
+func newClients(config Config, now time.Time) *clients {
+	commits, err := newCommitSettings(config.Commits)
+	if err != nil {
+		fatal("Invalid [Commits] config.", "error", err)
+	}
+	h, err := newHTTPClients(config.HTTP)
+	if err != nil {
+		fatal("Invalid [HTTP] config.", "error", err)
+	}
+	prices, err := newPriceProvider(config, h)
+	if err != nil {
+		fatal("Invalid [Prices] config.", "error", err)
+	}
+	c := &clients{
+		github:      newGitHubClient(config.GitHub, h),
+		gitlab:      newGitLabClient(config.GitLab, h),
+		bitbucket:   newBitbucketClient(config.Bitbucket, h),
+		gitea:       newGiteaClient(config.Gitea, h),
+		scrape:      newScraper(config.Scrape, h, config.HTTP),
+		prices:      prices,
+		conditional: config.GitHub.conditional(),
+		cache:       newRepositoryCache(config.Redis),
+		commits:     commits,
+		now:         now,
+	}
+	if c.collectors, err = newCollectors(c, config.Collectors.Enabled); err != nil {
+		fatal("Invalid [Collectors] config.", "error", err)
+	}
+	return c
+}
+
+// locationOf is where the DB believes a repository lives.
+func locationOf(repo Repository) repositoryLocation {
+	provider := repo.Provider
+	if provider == "" {
+		provider = providerGitHub
+	}
+	return repositoryLocation{Provider: provider, Owner: repo.Coin.Owner, Name: repo.Name, BaseURL: repo.BaseURL}
+}
+
+// moved reports whether the repository was found somewhere else than the DB
+// says. GitHub owners and names are case-insensitive.
+func (l repositoryLocation) moved(from repositoryLocation) bool {
+	return !strings.EqualFold(l.Owner, from.Owner) || !strings.EqualFold(l.Name, from.Name)
+}
+
+// collect runs the first enabled collector of the repository's provider,
+// then the enabled supplementers of the provider. A failing supplementer
+// only leaves its metrics out.
+func collect(ctx context.Context, c *clients, repo Repository) (Metrics, error) {
+	loc := locationOf(repo)
+	for _, col := range c.collectors {
+		if col.Provider() != loc.Provider || isSupplementer(col) {
+			continue
+		}
+		m, err := col.Collect(ctx, repo.Coin, repo)
+		if err != nil {
+			return m, err
+		}
+		m.Values.TopContributors = topContributors(m.Values.Contributors, c.commits.TopContributors)
+		for _, s := range c.collectors {
+			if s, ok := s.(supplementer); ok && s.Provider() == loc.Provider {
+				if err := s.supplement(ctx, &m); err != nil {
+					slog.Warn("Supplementary metrics unavailable.", "coin_id", repo.Coin.Id, "repo", repoName(repo), "collector", s.Name(), "error", err)
+				}
+			}
+		}
+		return m, nil
+	}
+	return Metrics{Location: loc}, fmt.Errorf("no collector enabled for provider %q", loc.Provider)
+}
+
+// githubColumns are the columns collectGitHub reads. The commits and
+// contributors counts are left out when neither the API nor a fallback
+// could count them.
+var githubColumns = []string{
+	"status", "language", "license", "default_branch", "last_commit_at", "updated_at",
+	"pull_requests_count", "open_pull_requests_count", "closed_pull_requests_count", "merged_pull_requests_count",
+	"watchers_count", "stargazers_count", "issues_count", "open_issues_count", "closed_issues_count",
+	"commits_count_for_the_last_week", "commits_count_for_the_last_month", "commits_count", "contributors_count",
+	"forks_count", "releases_count", "tags_count",
+	"latest_release_tag", "latest_release_at", "releases_count_for_the_last90_days",
+	"median_issue_close_seconds", "median_pull_request_merge_seconds", "signed_commits_percentage",
+}
+
+func collectGitHub(ctx context.Context, c *clients, coin Coin, repo Repository) (Metrics, error) {
+	client, commits, now := c.github, c.commits, c.now
+	loc := locationOf(repo)
+	since := historySince(now, commits.Windows)
+
+	r, loc, err := client.fetchRepoStats(ctx, loc, since)
+	if err != nil {
+		return Metrics{Location: loc}, err
+	}
+
+	commit := r.DefaultBranchRef.Target.Commit
+	var nodes []commitNode
+	if commits.allBranches(repo) {
+		nodes, err = client.allBranchesHistory(ctx, loc.Owner, loc.Name, since)
+	} else {
+		nodes, err = client.followHistory(ctx, loc.Owner, loc.Name, since, commit.History)
+	}
+	if err != nil {
+		return Metrics{Location: loc}, apiError(err)
+	}
+	nodes = commits.filter(nodes)
+
+	columns := githubColumns
+	commitsCount := commit.TotalHistory.TotalCount
+	contributorsCount, err := client.contributorsCount(ctx, loc.Owner, loc.Name)
+	if err != nil || commitsCount == 0 {
+		// Fall back to web scraping or a clone (commits and contributors count)
+		fallback := c.collector(collectorScrape)
+		if fallback == nil {
+			fallback = c.collector(collectorGit)
+		}
+		if fallback == nil {
+			slog.Warn("API counts unavailable and no fallback is enabled.", "coin_id", coin.Id, "repo", repoName(repo), "error", err)
+			columns = without(columns, "commits_count", "contributors_count")
+		} else {
+			slog.Warn("API counts unavailable, falling back.", "coin_id", coin.Id, "repo", repoName(repo), "fallback", fallback.Name(), "error", err)
+			scraped, err := fallback.Collect(ctx, Coin{Owner: loc.Owner}, Repository{Name: loc.Name, AllBranches: repo.AllBranches})
+			if err != nil {
+				return Metrics{Location: loc}, err
+			}
+			commitsCount, contributorsCount = scraped.Values.CommitsCount, scraped.Values.ContributorsCount
+		}
+	}
+
+	codeFrequency, err := client.codeFrequency(ctx, loc.Owner, loc.Name, now)
+	if err != nil {
+		slog.Warn("Code frequency unavailable.", "coin_id", coin.Id, "repo", repoName(repo), "error", err)
+	}
+
+	status := statusActive
+	if r.IsArchived {
+		status = statusArchived
+	}
+
+	values := Repository{
+		Status:                      status,
+		Language:                    r.PrimaryLanguage.Name,
+		PullRequestsCount:           r.OpenPullRequests.TotalCount + r.ClosedPullRequests.TotalCount + r.MergedPullRequests.TotalCount,
+		OpenPullRequestsCount:       r.OpenPullRequests.TotalCount,
+		ClosedPullRequestsCount:     r.ClosedPullRequests.TotalCount,
+		MergedPullRequestsCount:     r.MergedPullRequests.TotalCount,
+		WatchersCount:               r.Watchers.TotalCount,
+		StargazersCount:             r.Stargazers.TotalCount,
+		IssuesCount:                 r.OpenIssues.TotalCount + r.ClosedIssues.TotalCount,
+		OpenIssuesCount:             r.OpenIssues.TotalCount,
+		ClosedIssuesCount:           r.ClosedIssues.TotalCount,
+		CommitsCountForTheLastWeek:  commitsCountForTheLastWeek(nodes, now),
+		CommitsCountForTheLastMonth: commitsCountForTheLastMonth(nodes, now),
+		CommitWindows:               commitWindowCounts(nodes, now, commits.Windows),
+		Contributors:                activeContributors(nodes, now),
+		AuthorDomains:               commits.authorDomains(nodes, now),
+		CodeFrequency:               codeFrequency,
+		CommitsCount:                commitsCount,
+		ContributorsCount:           contributorsCount,
+		ForksCount:                  r.ForkCount,
+		ReleasesCount:               r.Releases.TotalCount,
+		TagsCount:                   r.Tags.TotalCount,
+		Languages:                   r.languages(),
+		Topics:                      r.topics(),
+		License:                     r.LicenseInfo.SpdxId,
+		DefaultBranch:               r.DefaultBranchRef.Name,
+		LastCommitAt:                optionalTime(commit.CommittedDate),
+		UpdatedAt:                   now,
+
+		LatestReleaseTag:              r.latestRelease().tag(),
+		LatestReleaseAt:               r.latestRelease().publishedAt(),
+		ReleasesCountForTheLast90Days: recentReleasesCount(r.recentReleases(), now),
+		MedianIssueCloseSeconds:       medianTurnaround(r.issueTurnarounds()),
+		MedianPullRequestMergeSeconds: medianTurnaround(r.pullRequestTurnarounds()),
+		SignedCommitsPercentage:       signedCommitsPercentage(nodes, now),
+	}
+	return Metrics{Values: values, Location: loc, Columns: columns}, nil
+}
+
+// without returns columns less the given ones, in a new slice.
+func without(columns []string, drop ...string) []string {
+	kept := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !contains(drop, c) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	collectorGitHub    = "github"
+	collectorGitLab    = "gitlab"
+	collectorBitbucket = "bitbucket"
+	collectorGitea     = "gitea"
+	collectorScrape    = "scrape"
+	collectorGit       = "git"
+	collectorSecurity  = "security"
+)
+
+// Collector is a source of repository metrics. Provider is the kind of
+// repository it can collect; the first enabled collector of a repository's
+// provider collects it.
+type Collector interface {
+	Name() string
+	Provider() string
+	Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error)
+}
+
+// supplementer is a Collector that adds to the metrics another collector
+// found rather than collecting repositories on its own. It runs after the
+// collector of the repository succeeded, and only when enabled by name.
+type supplementer interface {
+	Collector
+	supplement(ctx context.Context, m *Metrics) error
+}
+
+// Metrics is what a Collector found for a repository. Location is where the
+// repository was actually collected from, which differs from the stored one
+// when it was renamed or moved. Columns are the repositories columns the
+// collector read, which are written even when they are zero; the others
+// only when they are not.
+type Metrics struct {
+	Values   Repository
+	Location repositoryLocation
+	Columns  []string
+}
+
+// collectorFactories is the registry of every known collector, in the order
+// they are tried.
+var collectorFactories = []struct {
+	Name string
+	New  func(c *clients) Collector
+}{
+	{collectorGitHub, func(c *clients) Collector { return githubCollector{c} }},
+	{collectorGitLab, func(c *clients) Collector { return gitlabCollector{c} }},
+	{collectorBitbucket, func(c *clients) Collector { return bitbucketCollector{c} }},
+	{collectorGitea, func(c *clients) Collector { return giteaCollector{c} }},
+	{collectorScrape, func(c *clients) Collector { return scrapeCollector{c} }},
+	{collectorGit, func(c *clients) Collector { return gitCollector{c} }},
+	{collectorSecurity, func(c *clients) Collector { return securityCollector{c} }},
+}
+
+func isCollector(name string) bool {
+	for _, f := range collectorFactories {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newCollectors builds the enabled collectors. An empty list enables all but
+// the supplementers.
+func newCollectors(c *clients, enabled []string) ([]Collector, error) {
+	for _, name := range enabled {
+		if !isCollector(name) {
+			return nil, fmt.Errorf("unknown collector %q", name)
+		}
+	}
+
+	var collectors []Collector
+	for _, f := range collectorFactories {
+		col := f.New(c)
+		if contains(enabled, f.Name) || len(enabled) == 0 && !isSupplementer(col) {
+			collectors = append(collectors, col)
+		}
+	}
+	return collectors, nil
+}
+
+func isSupplementer(col Collector) bool {
+	_, ok := col.(supplementer)
+	return ok
+}
+
+// collector returns the enabled collector called name, or nil.
+func (c *clients) collector(name string) Collector {
+	for _, col := range c.collectors {
+		if col.Name() == name {
+			return col
+		}
+	}
+	return nil
+}
+
+type githubCollector struct{ c *clients }
+
+func (githubCollector) Name() string     { return collectorGitHub }
+func (githubCollector) Provider() string { return providerGitHub }
+
+func (g githubCollector) Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error) {
+	return collectGitHub(ctx, g.c, coin, repo)
+}
+
+type gitlabCollector struct{ c *clients }
+
+func (gitlabCollector) Name() string     { return collectorGitLab }
+func (gitlabCollector) Provider() string { return providerGitLab }
+
+func (g gitlabCollector) Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error) {
+	values, err := collectGitLab(ctx, g.c.gitlab, coin, repo, g.c.now, g.c.commits)
+	return Metrics{Values: values, Location: locationOf(repo), Columns: gitlabColumns}, err
+}
+
+type bitbucketCollector struct{ c *clients }
+
+func (bitbucketCollector) Name() string     { return collectorBitbucket }
+func (bitbucketCollector) Provider() string { return providerBitbucket }
+
+func (b bitbucketCollector) Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error) {
+	values, err := collectBitbucket(ctx, b.c.bitbucket, coin, repo, b.c.now, b.c.commits)
+	return Metrics{Values: values, Location: locationOf(repo), Columns: bitbucketColumns}, err
+}
+
+type giteaCollector struct{ c *clients }
+
+func (giteaCollector) Name() string     { return collectorGitea }
+func (giteaCollector) Provider() string { return providerGitea }
+
+func (g giteaCollector) Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error) {
+	values, err := collectGitea(ctx, g.c.gitea, coin, repo, g.c.now, g.c.commits)
+	return Metrics{Values: values, Location: locationOf(repo), Columns: giteaColumns}, err
+}
+
+// scrapeCollector reads the commits and contributors counts from the GitHub
+// web page, rendered in a headless browser when enabled and needed. On its
+// own it covers GitHub repositories when the API is disabled; next to the
+// github collector it is the fallback for the counts the API could not
+// provide.
+type scrapeCollector struct{ c *clients }
+
+var scrapeColumns = []string{"commits_count", "contributors_count", "updated_at"}
+
+func (scrapeCollector) Name() string     { return collectorScrape }
+func (scrapeCollector) Provider() string { return providerGitHub }
+
+func (s scrapeCollector) Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error) {
+	loc := repositoryLocation{Provider: providerGitHub, Owner: coin.Owner, Name: repo.Name}
+	commits, contributors, err := s.c.scrape.counts(ctx, s.c.github.webURL, loc.Owner, loc.Name)
+	if err != nil {
+		return Metrics{Location: loc}, scrapeError(err)
+	}
+	return Metrics{
+		Values:   Repository{CommitsCount: commits, ContributorsCount: contributors, UpdatedAt: s.c.now},
+		Location: loc,
+		Columns:  scrapeColumns,
+	}, nil
+}
+
+// gitCollector clones the GitHub repository and counts its commits and
+// contributors exactly, where the web page rounds and the API may be rate
+// limited. Like scrapeCollector it covers GitHub repositories when the API
+// is disabled and is otherwise a fallback, tried after scraping.
+type gitCollector struct{ c *clients }
+
+var gitColumns = []string{
+	"commits_count_for_the_last_week", "commits_count_for_the_last_month", "commits_count", "contributors_count",
+	"default_branch", "last_commit_at", "updated_at",
+}
+
+func (gitCollector) Name() string     { return collectorGit }
+func (gitCollector) Provider() string { return providerGitHub }
+
+func (g gitCollector) Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error) {
+	loc := repositoryLocation{Provider: providerGitHub, Owner: coin.Owner, Name: repo.Name}
+	since := historySince(g.c.now, g.c.commits.Windows)
+	stats, err := cloneRepository(ctx, g.c.github.webURL+"/"+loc.Owner+"/"+loc.Name+".git", since, g.c.commits.allBranches(repo))
+	if err != nil {
+		return Metrics{Location: loc}, cloneError(err)
+	}
+	nodes := g.c.commits.filter(stats.Nodes)
+	return Metrics{
+		Values: Repository{
+			CommitsCountForTheLastWeek:  commitsCountForTheLastWeek(nodes, g.c.now),
+			CommitsCountForTheLastMonth: commitsCountForTheLastMonth(nodes, g.c.now),
+			CommitWindows:               commitWindowCounts(nodes, g.c.now, g.c.commits.Windows),
+			Contributors:                activeContributors(nodes, g.c.now),
+			AuthorDomains:               g.c.commits.authorDomains(nodes, g.c.now),
+			CommitsCount:                stats.CommitsCount,
+			ContributorsCount:           stats.ContributorsCount,
+			DefaultBranch:               stats.DefaultBranch,
+			LastCommitAt:                optionalTime(stats.LastCommitAt),
+			UpdatedAt:                   g.c.now,
+		},
+		Location: loc,
+		Columns:  gitColumns,
+	}, nil
+}
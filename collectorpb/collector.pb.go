@@ -0,0 +1,1324 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v4.25.3
+// source: collectorpb/collector.proto
+
+package collectorpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListCoinsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// At most 100 coins are returned per page, 30 by default.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// The next_page_token of the previous page, empty for the first one.
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+}
+
+func (x *ListCoinsRequest) Reset() {
+	*x = ListCoinsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListCoinsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoinsRequest) ProtoMessage() {}
+
+func (x *ListCoinsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoinsRequest.ProtoReflect.Descriptor instead.
+func (*ListCoinsRequest) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListCoinsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListCoinsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListCoinsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Coins []*Coin `protobuf:"bytes,1,rep,name=coins,proto3" json:"coins,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+}
+
+func (x *ListCoinsResponse) Reset() {
+	*x = ListCoinsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListCoinsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoinsResponse) ProtoMessage() {}
+
+func (x *ListCoinsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoinsResponse.ProtoReflect.Descriptor instead.
+func (*ListCoinsResponse) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListCoinsResponse) GetCoins() []*Coin {
+	if x != nil {
+		return x.Coins
+	}
+	return nil
+}
+
+func (x *ListCoinsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type Coin struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            int32   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string  `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Symbol        string  `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Owner         string  `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	RepositoryIds []int32 `protobuf:"varint,5,rep,packed,name=repository_ids,json=repositoryIds,proto3" json:"repository_ids,omitempty"`
+	// The rollup refreshed at the end of each run, unset before the first.
+	Stats *CoinStats `protobuf:"bytes,6,opt,name=stats,proto3" json:"stats,omitempty"`
+}
+
+func (x *Coin) Reset() {
+	*x = Coin{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Coin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coin) ProtoMessage() {}
+
+func (x *Coin) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coin.ProtoReflect.Descriptor instead.
+func (*Coin) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Coin) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Coin) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Coin) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Coin) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Coin) GetRepositoryIds() []int32 {
+	if x != nil {
+		return x.RepositoryIds
+	}
+	return nil
+}
+
+func (x *Coin) GetStats() *CoinStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type CoinStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RepositoriesCount           int32                  `protobuf:"varint,1,opt,name=repositories_count,json=repositoriesCount,proto3" json:"repositories_count,omitempty"`
+	PullRequestsCount           int32                  `protobuf:"varint,2,opt,name=pull_requests_count,json=pullRequestsCount,proto3" json:"pull_requests_count,omitempty"`
+	WatchersCount               int32                  `protobuf:"varint,3,opt,name=watchers_count,json=watchersCount,proto3" json:"watchers_count,omitempty"`
+	StargazersCount             int32                  `protobuf:"varint,4,opt,name=stargazers_count,json=stargazersCount,proto3" json:"stargazers_count,omitempty"`
+	IssuesCount                 int32                  `protobuf:"varint,5,opt,name=issues_count,json=issuesCount,proto3" json:"issues_count,omitempty"`
+	CommitsCountForTheLastWeek  int32                  `protobuf:"varint,6,opt,name=commits_count_for_the_last_week,json=commitsCountForTheLastWeek,proto3" json:"commits_count_for_the_last_week,omitempty"`
+	CommitsCountForTheLastMonth int32                  `protobuf:"varint,7,opt,name=commits_count_for_the_last_month,json=commitsCountForTheLastMonth,proto3" json:"commits_count_for_the_last_month,omitempty"`
+	CommitsCount                int32                  `protobuf:"varint,8,opt,name=commits_count,json=commitsCount,proto3" json:"commits_count,omitempty"`
+	ContributorsCount           int32                  `protobuf:"varint,9,opt,name=contributors_count,json=contributorsCount,proto3" json:"contributors_count,omitempty"`
+	UpdatedAt                   *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *CoinStats) Reset() {
+	*x = CoinStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CoinStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoinStats) ProtoMessage() {}
+
+func (x *CoinStats) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoinStats.ProtoReflect.Descriptor instead.
+func (*CoinStats) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CoinStats) GetRepositoriesCount() int32 {
+	if x != nil {
+		return x.RepositoriesCount
+	}
+	return 0
+}
+
+func (x *CoinStats) GetPullRequestsCount() int32 {
+	if x != nil {
+		return x.PullRequestsCount
+	}
+	return 0
+}
+
+func (x *CoinStats) GetWatchersCount() int32 {
+	if x != nil {
+		return x.WatchersCount
+	}
+	return 0
+}
+
+func (x *CoinStats) GetStargazersCount() int32 {
+	if x != nil {
+		return x.StargazersCount
+	}
+	return 0
+}
+
+func (x *CoinStats) GetIssuesCount() int32 {
+	if x != nil {
+		return x.IssuesCount
+	}
+	return 0
+}
+
+func (x *CoinStats) GetCommitsCountForTheLastWeek() int32 {
+	if x != nil {
+		return x.CommitsCountForTheLastWeek
+	}
+	return 0
+}
+
+func (x *CoinStats) GetCommitsCountForTheLastMonth() int32 {
+	if x != nil {
+		return x.CommitsCountForTheLastMonth
+	}
+	return 0
+}
+
+func (x *CoinStats) GetCommitsCount() int32 {
+	if x != nil {
+		return x.CommitsCount
+	}
+	return 0
+}
+
+func (x *CoinStats) GetContributorsCount() int32 {
+	if x != nil {
+		return x.ContributorsCount
+	}
+	return 0
+}
+
+func (x *CoinStats) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetRepositoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Used when id is zero.
+	Owner string `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Name  string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *GetRepositoryRequest) Reset() {
+	*x = GetRepositoryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRepositoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRepositoryRequest) ProtoMessage() {}
+
+func (x *GetRepositoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRepositoryRequest.ProtoReflect.Descriptor instead.
+func (*GetRepositoryRequest) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetRepositoryRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GetRepositoryRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *GetRepositoryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Repository struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id                          int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CoinId                      int32  `protobuf:"varint,2,opt,name=coin_id,json=coinId,proto3" json:"coin_id,omitempty"`
+	Owner                       string `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	Name                        string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Provider                    string `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	Status                      string `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Language                    string `protobuf:"bytes,7,opt,name=language,proto3" json:"language,omitempty"`
+	License                     string `protobuf:"bytes,8,opt,name=license,proto3" json:"license,omitempty"`
+	DefaultBranch               string `protobuf:"bytes,9,opt,name=default_branch,json=defaultBranch,proto3" json:"default_branch,omitempty"`
+	PullRequestsCount           int32  `protobuf:"varint,10,opt,name=pull_requests_count,json=pullRequestsCount,proto3" json:"pull_requests_count,omitempty"`
+	OpenPullRequestsCount       int32  `protobuf:"varint,11,opt,name=open_pull_requests_count,json=openPullRequestsCount,proto3" json:"open_pull_requests_count,omitempty"`
+	ClosedPullRequestsCount     int32  `protobuf:"varint,12,opt,name=closed_pull_requests_count,json=closedPullRequestsCount,proto3" json:"closed_pull_requests_count,omitempty"`
+	MergedPullRequestsCount     int32  `protobuf:"varint,13,opt,name=merged_pull_requests_count,json=mergedPullRequestsCount,proto3" json:"merged_pull_requests_count,omitempty"`
+	WatchersCount               int32  `protobuf:"varint,14,opt,name=watchers_count,json=watchersCount,proto3" json:"watchers_count,omitempty"`
+	StargazersCount             int32  `protobuf:"varint,15,opt,name=stargazers_count,json=stargazersCount,proto3" json:"stargazers_count,omitempty"`
+	IssuesCount                 int32  `protobuf:"varint,16,opt,name=issues_count,json=issuesCount,proto3" json:"issues_count,omitempty"`
+	OpenIssuesCount             int32  `protobuf:"varint,17,opt,name=open_issues_count,json=openIssuesCount,proto3" json:"open_issues_count,omitempty"`
+	ClosedIssuesCount           int32  `protobuf:"varint,18,opt,name=closed_issues_count,json=closedIssuesCount,proto3" json:"closed_issues_count,omitempty"`
+	CommitsCountForTheLastWeek  int32  `protobuf:"varint,19,opt,name=commits_count_for_the_last_week,json=commitsCountForTheLastWeek,proto3" json:"commits_count_for_the_last_week,omitempty"`
+	CommitsCountForTheLastMonth int32  `protobuf:"varint,20,opt,name=commits_count_for_the_last_month,json=commitsCountForTheLastMonth,proto3" json:"commits_count_for_the_last_month,omitempty"`
+	CommitsCount                int32  `protobuf:"varint,21,opt,name=commits_count,json=commitsCount,proto3" json:"commits_count,omitempty"`
+	ContributorsCount           int32  `protobuf:"varint,22,opt,name=contributors_count,json=contributorsCount,proto3" json:"contributors_count,omitempty"`
+	ForksCount                  int32  `protobuf:"varint,23,opt,name=forks_count,json=forksCount,proto3" json:"forks_count,omitempty"`
+	ReleasesCount               int32  `protobuf:"varint,24,opt,name=releases_count,json=releasesCount,proto3" json:"releases_count,omitempty"`
+	TagsCount                   int32  `protobuf:"varint,25,opt,name=tags_count,json=tagsCount,proto3" json:"tags_count,omitempty"`
+	// Unset until a collector has read it.
+	LastCommitAt *timestamppb.Timestamp `protobuf:"bytes,26,opt,name=last_commit_at,json=lastCommitAt,proto3" json:"last_commit_at,omitempty"`
+	UpdatedAt    *timestamppb.Timestamp `protobuf:"bytes,27,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *Repository) Reset() {
+	*x = Repository{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Repository) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Repository) ProtoMessage() {}
+
+func (x *Repository) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Repository.ProtoReflect.Descriptor instead.
+func (*Repository) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Repository) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Repository) GetCoinId() int32 {
+	if x != nil {
+		return x.CoinId
+	}
+	return 0
+}
+
+func (x *Repository) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Repository) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Repository) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Repository) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Repository) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Repository) GetLicense() string {
+	if x != nil {
+		return x.License
+	}
+	return ""
+}
+
+func (x *Repository) GetDefaultBranch() string {
+	if x != nil {
+		return x.DefaultBranch
+	}
+	return ""
+}
+
+func (x *Repository) GetPullRequestsCount() int32 {
+	if x != nil {
+		return x.PullRequestsCount
+	}
+	return 0
+}
+
+func (x *Repository) GetOpenPullRequestsCount() int32 {
+	if x != nil {
+		return x.OpenPullRequestsCount
+	}
+	return 0
+}
+
+func (x *Repository) GetClosedPullRequestsCount() int32 {
+	if x != nil {
+		return x.ClosedPullRequestsCount
+	}
+	return 0
+}
+
+func (x *Repository) GetMergedPullRequestsCount() int32 {
+	if x != nil {
+		return x.MergedPullRequestsCount
+	}
+	return 0
+}
+
+func (x *Repository) GetWatchersCount() int32 {
+	if x != nil {
+		return x.WatchersCount
+	}
+	return 0
+}
+
+func (x *Repository) GetStargazersCount() int32 {
+	if x != nil {
+		return x.StargazersCount
+	}
+	return 0
+}
+
+func (x *Repository) GetIssuesCount() int32 {
+	if x != nil {
+		return x.IssuesCount
+	}
+	return 0
+}
+
+func (x *Repository) GetOpenIssuesCount() int32 {
+	if x != nil {
+		return x.OpenIssuesCount
+	}
+	return 0
+}
+
+func (x *Repository) GetClosedIssuesCount() int32 {
+	if x != nil {
+		return x.ClosedIssuesCount
+	}
+	return 0
+}
+
+func (x *Repository) GetCommitsCountForTheLastWeek() int32 {
+	if x != nil {
+		return x.CommitsCountForTheLastWeek
+	}
+	return 0
+}
+
+func (x *Repository) GetCommitsCountForTheLastMonth() int32 {
+	if x != nil {
+		return x.CommitsCountForTheLastMonth
+	}
+	return 0
+}
+
+func (x *Repository) GetCommitsCount() int32 {
+	if x != nil {
+		return x.CommitsCount
+	}
+	return 0
+}
+
+func (x *Repository) GetContributorsCount() int32 {
+	if x != nil {
+		return x.ContributorsCount
+	}
+	return 0
+}
+
+func (x *Repository) GetForksCount() int32 {
+	if x != nil {
+		return x.ForksCount
+	}
+	return 0
+}
+
+func (x *Repository) GetReleasesCount() int32 {
+	if x != nil {
+		return x.ReleasesCount
+	}
+	return 0
+}
+
+func (x *Repository) GetTagsCount() int32 {
+	if x != nil {
+		return x.TagsCount
+	}
+	return 0
+}
+
+func (x *Repository) GetLastCommitAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCommitAt
+	}
+	return nil
+}
+
+func (x *Repository) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type StreamSnapshotsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RepositoryId int32 `protobuf:"varint,1,opt,name=repository_id,json=repositoryId,proto3" json:"repository_id,omitempty"`
+	// Both bounds are optional.
+	Since *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`
+	Until *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=until,proto3" json:"until,omitempty"`
+}
+
+func (x *StreamSnapshotsRequest) Reset() {
+	*x = StreamSnapshotsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamSnapshotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSnapshotsRequest) ProtoMessage() {}
+
+func (x *StreamSnapshotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSnapshotsRequest.ProtoReflect.Descriptor instead.
+func (*StreamSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamSnapshotsRequest) GetRepositoryId() int32 {
+	if x != nil {
+		return x.RepositoryId
+	}
+	return 0
+}
+
+func (x *StreamSnapshotsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *StreamSnapshotsRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+type Snapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RepositoryId int32                  `protobuf:"varint,1,opt,name=repository_id,json=repositoryId,proto3" json:"repository_id,omitempty"`
+	RunId        int32                  `protobuf:"varint,2,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	CapturedAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=captured_at,json=capturedAt,proto3" json:"captured_at,omitempty"`
+	// Backfilled snapshots only hold the metrics their backfill reconstructed.
+	Backfilled                  bool  `protobuf:"varint,4,opt,name=backfilled,proto3" json:"backfilled,omitempty"`
+	PullRequestsCount           int32 `protobuf:"varint,5,opt,name=pull_requests_count,json=pullRequestsCount,proto3" json:"pull_requests_count,omitempty"`
+	OpenPullRequestsCount       int32 `protobuf:"varint,6,opt,name=open_pull_requests_count,json=openPullRequestsCount,proto3" json:"open_pull_requests_count,omitempty"`
+	ClosedPullRequestsCount     int32 `protobuf:"varint,7,opt,name=closed_pull_requests_count,json=closedPullRequestsCount,proto3" json:"closed_pull_requests_count,omitempty"`
+	MergedPullRequestsCount     int32 `protobuf:"varint,8,opt,name=merged_pull_requests_count,json=mergedPullRequestsCount,proto3" json:"merged_pull_requests_count,omitempty"`
+	WatchersCount               int32 `protobuf:"varint,9,opt,name=watchers_count,json=watchersCount,proto3" json:"watchers_count,omitempty"`
+	StargazersCount             int32 `protobuf:"varint,10,opt,name=stargazers_count,json=stargazersCount,proto3" json:"stargazers_count,omitempty"`
+	IssuesCount                 int32 `protobuf:"varint,11,opt,name=issues_count,json=issuesCount,proto3" json:"issues_count,omitempty"`
+	OpenIssuesCount             int32 `protobuf:"varint,12,opt,name=open_issues_count,json=openIssuesCount,proto3" json:"open_issues_count,omitempty"`
+	ClosedIssuesCount           int32 `protobuf:"varint,13,opt,name=closed_issues_count,json=closedIssuesCount,proto3" json:"closed_issues_count,omitempty"`
+	CommitsCountForTheLastWeek  int32 `protobuf:"varint,14,opt,name=commits_count_for_the_last_week,json=commitsCountForTheLastWeek,proto3" json:"commits_count_for_the_last_week,omitempty"`
+	CommitsCountForTheLastMonth int32 `protobuf:"varint,15,opt,name=commits_count_for_the_last_month,json=commitsCountForTheLastMonth,proto3" json:"commits_count_for_the_last_month,omitempty"`
+	CommitsCount                int32 `protobuf:"varint,16,opt,name=commits_count,json=commitsCount,proto3" json:"commits_count,omitempty"`
+	ContributorsCount           int32 `protobuf:"varint,17,opt,name=contributors_count,json=contributorsCount,proto3" json:"contributors_count,omitempty"`
+	ForksCount                  int32 `protobuf:"varint,18,opt,name=forks_count,json=forksCount,proto3" json:"forks_count,omitempty"`
+	ReleasesCount               int32 `protobuf:"varint,19,opt,name=releases_count,json=releasesCount,proto3" json:"releases_count,omitempty"`
+	TagsCount                   int32 `protobuf:"varint,20,opt,name=tags_count,json=tagsCount,proto3" json:"tags_count,omitempty"`
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collectorpb_collector_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_collectorpb_collector_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_collectorpb_collector_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Snapshot) GetRepositoryId() int32 {
+	if x != nil {
+		return x.RepositoryId
+	}
+	return 0
+}
+
+func (x *Snapshot) GetRunId() int32 {
+	if x != nil {
+		return x.RunId
+	}
+	return 0
+}
+
+func (x *Snapshot) GetCapturedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CapturedAt
+	}
+	return nil
+}
+
+func (x *Snapshot) GetBackfilled() bool {
+	if x != nil {
+		return x.Backfilled
+	}
+	return false
+}
+
+func (x *Snapshot) GetPullRequestsCount() int32 {
+	if x != nil {
+		return x.PullRequestsCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetOpenPullRequestsCount() int32 {
+	if x != nil {
+		return x.OpenPullRequestsCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetClosedPullRequestsCount() int32 {
+	if x != nil {
+		return x.ClosedPullRequestsCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetMergedPullRequestsCount() int32 {
+	if x != nil {
+		return x.MergedPullRequestsCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetWatchersCount() int32 {
+	if x != nil {
+		return x.WatchersCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetStargazersCount() int32 {
+	if x != nil {
+		return x.StargazersCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetIssuesCount() int32 {
+	if x != nil {
+		return x.IssuesCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetOpenIssuesCount() int32 {
+	if x != nil {
+		return x.OpenIssuesCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetClosedIssuesCount() int32 {
+	if x != nil {
+		return x.ClosedIssuesCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetCommitsCountForTheLastWeek() int32 {
+	if x != nil {
+		return x.CommitsCountForTheLastWeek
+	}
+	return 0
+}
+
+func (x *Snapshot) GetCommitsCountForTheLastMonth() int32 {
+	if x != nil {
+		return x.CommitsCountForTheLastMonth
+	}
+	return 0
+}
+
+func (x *Snapshot) GetCommitsCount() int32 {
+	if x != nil {
+		return x.CommitsCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetContributorsCount() int32 {
+	if x != nil {
+		return x.ContributorsCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetForksCount() int32 {
+	if x != nil {
+		return x.ForksCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetReleasesCount() int32 {
+	if x != nil {
+		return x.ReleasesCount
+	}
+	return 0
+}
+
+func (x *Snapshot) GetTagsCount() int32 {
+	if x != nil {
+		return x.TagsCount
+	}
+	return 0
+}
+
+var File_collectorpb_collector_proto protoreflect.FileDescriptor
+
+var file_collectorpb_collector_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x70, 0x62, 0x2f, 0x63, 0x6f,
+	0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x63,
+	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x4e, 0x0a, 0x10,
+	0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a,
+	0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x65, 0x0a, 0x11,
+	0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x28, 0x0a, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x6f, 0x69, 0x6e, 0x52, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e,
+	0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f,
+	0x6b, 0x65, 0x6e, 0x22, 0xae, 0x01, 0x0a, 0x04, 0x43, 0x6f, 0x69, 0x6e, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65,
+	0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x25,
+	0x0a, 0x0e, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x73,
+	0x18, 0x05, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f,
+	0x72, 0x79, 0x49, 0x64, 0x73, 0x12, 0x2d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x69, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x73, 0x22, 0xfa, 0x03, 0x0a, 0x09, 0x43, 0x6f, 0x69, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11,
+	0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x2e, 0x0a, 0x13, 0x70, 0x75, 0x6c, 0x6c, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11,
+	0x70, 0x75, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x25, 0x0a, 0x0e, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x73, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x77, 0x61, 0x74, 0x63, 0x68,
+	0x65, 0x72, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x74, 0x61, 0x72,
+	0x67, 0x61, 0x7a, 0x65, 0x72, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0f, 0x73, 0x74, 0x61, 0x72, 0x67, 0x61, 0x7a, 0x65, 0x72, 0x73, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65,
+	0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x43, 0x0a, 0x1f, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x5f, 0x74, 0x68, 0x65, 0x5f,
+	0x6c, 0x61, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x65, 0x6b, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x1a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x6f, 0x72,
+	0x54, 0x68, 0x65, 0x4c, 0x61, 0x73, 0x74, 0x57, 0x65, 0x65, 0x6b, 0x12, 0x45, 0x0a, 0x20, 0x63,
+	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x66, 0x6f, 0x72,
+	0x5f, 0x74, 0x68, 0x65, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1b, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x68, 0x65, 0x4c, 0x61, 0x73, 0x74, 0x4d, 0x6f, 0x6e,
+	0x74, 0x68, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2d, 0x0a, 0x12, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x6f, 0x72, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x11, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x6f, 0x72,
+	0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41,
+	0x74, 0x22, 0x50, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f,
+	0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x22, 0xe8, 0x08, 0x0a, 0x0a, 0x52, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f,
+	0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x6f, 0x69, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x06, 0x63, 0x6f, 0x69, 0x6e, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6f,
+	0x77, 0x6e, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65,
+	0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
+	0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
+	0x72, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x6e,
+	0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x6e,
+	0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x12,
+	0x25, 0x0a, 0x0e, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x62, 0x72, 0x61, 0x6e, 0x63,
+	0x68, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74,
+	0x42, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x12, 0x2e, 0x0a, 0x13, 0x70, 0x75, 0x6c, 0x6c, 0x5f, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x11, 0x70, 0x75, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x37, 0x0a, 0x18, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x70,
+	0x75, 0x6c, 0x6c, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52, 0x15, 0x6f, 0x70, 0x65, 0x6e, 0x50, 0x75,
+	0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x3b, 0x0a, 0x1a, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x5f, 0x70, 0x75, 0x6c, 0x6c, 0x5f, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x17, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x50, 0x75, 0x6c, 0x6c, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x3b, 0x0a, 0x1a,
+	0x6d, 0x65, 0x72, 0x67, 0x65, 0x64, 0x5f, 0x70, 0x75, 0x6c, 0x6c, 0x5f, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x17, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x64, 0x50, 0x75, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x77, 0x61, 0x74,
+	0x63, 0x68, 0x65, 0x72, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0d, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x29, 0x0a, 0x10, 0x73, 0x74, 0x61, 0x72, 0x67, 0x61, 0x7a, 0x65, 0x72, 0x73, 0x5f, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x73, 0x74, 0x61, 0x72,
+	0x67, 0x61, 0x7a, 0x65, 0x72, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x69,
+	0x73, 0x73, 0x75, 0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2a,
+	0x0a, 0x11, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x6f, 0x70, 0x65, 0x6e, 0x49,
+	0x73, 0x73, 0x75, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x63, 0x6c,
+	0x6f, 0x73, 0x65, 0x64, 0x5f, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x12, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x49,
+	0x73, 0x73, 0x75, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x43, 0x0a, 0x1f, 0x63, 0x6f,
+	0x6d, 0x6d, 0x69, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x5f,
+	0x74, 0x68, 0x65, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x65, 0x6b, 0x18, 0x13, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x1a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x46, 0x6f, 0x72, 0x54, 0x68, 0x65, 0x4c, 0x61, 0x73, 0x74, 0x57, 0x65, 0x65, 0x6b, 0x12,
+	0x45, 0x0a, 0x20, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x5f, 0x66, 0x6f, 0x72, 0x5f, 0x74, 0x68, 0x65, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x6d, 0x6f,
+	0x6e, 0x74, 0x68, 0x18, 0x14, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1b, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x68, 0x65, 0x4c, 0x61, 0x73,
+	0x74, 0x4d, 0x6f, 0x6e, 0x74, 0x68, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x15, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x63,
+	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2d, 0x0a, 0x12, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x6f, 0x72, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x16, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x6f, 0x72, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x6f,
+	0x72, 0x6b, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x17, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0a, 0x66, 0x6f, 0x72, 0x6b, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x72,
+	0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x18, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x67, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x19, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x74, 0x61, 0x67, 0x73, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x40, 0x0a, 0x0e, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x5f, 0x61, 0x74, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0c, 0x6c, 0x61, 0x73, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61,
+	0x74, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0xa1,
+	0x01, 0x0a, 0x16, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x70,
+	0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0c, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x49, 0x64, 0x12, 0x30,
+	0x0a, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65,
+	0x12, 0x30, 0x0a, 0x05, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x75, 0x6e, 0x74,
+	0x69, 0x6c, 0x22, 0x9e, 0x07, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12,
+	0x23, 0x0a, 0x0d, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f,
+	0x72, 0x79, 0x49, 0x64, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x75, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64, 0x12, 0x3b, 0x0a, 0x0b, 0x63,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x63, 0x61,
+	0x70, 0x74, 0x75, 0x72, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x61, 0x63, 0x6b,
+	0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x62, 0x61,
+	0x63, 0x6b, 0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x12, 0x2e, 0x0a, 0x13, 0x70, 0x75, 0x6c, 0x6c,
+	0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x70, 0x75, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x37, 0x0a, 0x18, 0x6f, 0x70, 0x65, 0x6e,
+	0x5f, 0x70, 0x75, 0x6c, 0x6c, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x15, 0x6f, 0x70, 0x65, 0x6e,
+	0x50, 0x75, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x3b, 0x0a, 0x1a, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x5f, 0x70, 0x75, 0x6c, 0x6c,
+	0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x17, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x50, 0x75, 0x6c,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x3b,
+	0x0a, 0x1a, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x64, 0x5f, 0x70, 0x75, 0x6c, 0x6c, 0x5f, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x17, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x64, 0x50, 0x75, 0x6c, 0x6c, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x77,
+	0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0d, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x73, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x74, 0x61, 0x72, 0x67, 0x61, 0x7a, 0x65, 0x72, 0x73,
+	0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x73, 0x74,
+	0x61, 0x72, 0x67, 0x61, 0x7a, 0x65, 0x72, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x21, 0x0a,
+	0x0c, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x2a, 0x0a, 0x11, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x6f, 0x70, 0x65,
+	0x6e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13,
+	0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x5f, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x63, 0x6c, 0x6f, 0x73, 0x65,
+	0x64, 0x49, 0x73, 0x73, 0x75, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x43, 0x0a, 0x1f,
+	0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x66, 0x6f,
+	0x72, 0x5f, 0x74, 0x68, 0x65, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x65, 0x6b, 0x18,
+	0x0e, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x68, 0x65, 0x4c, 0x61, 0x73, 0x74, 0x57, 0x65, 0x65,
+	0x6b, 0x12, 0x45, 0x0a, 0x20, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x5f, 0x74, 0x68, 0x65, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f,
+	0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1b, 0x63, 0x6f, 0x6d,
+	0x6d, 0x69, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x68, 0x65, 0x4c,
+	0x61, 0x73, 0x74, 0x4d, 0x6f, 0x6e, 0x74, 0x68, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x6d,
+	0x69, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0c, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2d, 0x0a,
+	0x12, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x6f, 0x72, 0x73, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x6f, 0x72, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b,
+	0x66, 0x6f, 0x72, 0x6b, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x12, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0a, 0x66, 0x6f, 0x72, 0x6b, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a,
+	0x0e, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x13, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x67, 0x73, 0x5f, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x74, 0x61, 0x67, 0x73, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x32, 0xfb, 0x01, 0x0a, 0x09, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x12, 0x4c, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x12, 0x1e,
+	0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f,
+	0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x4d, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79,
+	0x12, 0x22, 0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x51,
+	0x0a, 0x0f, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x73, 0x12, 0x24, 0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x30,
+	0x01, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x6f, 0x6e, 0x36, 0x37, 0x2f, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x2d, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x2d, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x2f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_collectorpb_collector_proto_rawDescOnce sync.Once
+	file_collectorpb_collector_proto_rawDescData = file_collectorpb_collector_proto_rawDesc
+)
+
+func file_collectorpb_collector_proto_rawDescGZIP() []byte {
+	file_collectorpb_collector_proto_rawDescOnce.Do(func() {
+		file_collectorpb_collector_proto_rawDescData = protoimpl.X.CompressGZIP(file_collectorpb_collector_proto_rawDescData)
+	})
+	return file_collectorpb_collector_proto_rawDescData
+}
+
+var file_collectorpb_collector_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_collectorpb_collector_proto_goTypes = []any{
+	(*ListCoinsRequest)(nil),       // 0: collector.v1.ListCoinsRequest
+	(*ListCoinsResponse)(nil),      // 1: collector.v1.ListCoinsResponse
+	(*Coin)(nil),                   // 2: collector.v1.Coin
+	(*CoinStats)(nil),              // 3: collector.v1.CoinStats
+	(*GetRepositoryRequest)(nil),   // 4: collector.v1.GetRepositoryRequest
+	(*Repository)(nil),             // 5: collector.v1.Repository
+	(*StreamSnapshotsRequest)(nil), // 6: collector.v1.StreamSnapshotsRequest
+	(*Snapshot)(nil),               // 7: collector.v1.Snapshot
+	(*timestamppb.Timestamp)(nil),  // 8: google.protobuf.Timestamp
+}
+var file_collectorpb_collector_proto_depIdxs = []int32{
+	2,  // 0: collector.v1.ListCoinsResponse.coins:type_name -> collector.v1.Coin
+	3,  // 1: collector.v1.Coin.stats:type_name -> collector.v1.CoinStats
+	8,  // 2: collector.v1.CoinStats.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 3: collector.v1.Repository.last_commit_at:type_name -> google.protobuf.Timestamp
+	8,  // 4: collector.v1.Repository.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 5: collector.v1.StreamSnapshotsRequest.since:type_name -> google.protobuf.Timestamp
+	8,  // 6: collector.v1.StreamSnapshotsRequest.until:type_name -> google.protobuf.Timestamp
+	8,  // 7: collector.v1.Snapshot.captured_at:type_name -> google.protobuf.Timestamp
+	0,  // 8: collector.v1.Collector.ListCoins:input_type -> collector.v1.ListCoinsRequest
+	4,  // 9: collector.v1.Collector.GetRepository:input_type -> collector.v1.GetRepositoryRequest
+	6,  // 10: collector.v1.Collector.StreamSnapshots:input_type -> collector.v1.StreamSnapshotsRequest
+	1,  // 11: collector.v1.Collector.ListCoins:output_type -> collector.v1.ListCoinsResponse
+	5,  // 12: collector.v1.Collector.GetRepository:output_type -> collector.v1.Repository
+	7,  // 13: collector.v1.Collector.StreamSnapshots:output_type -> collector.v1.Snapshot
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_collectorpb_collector_proto_init() }
+func file_collectorpb_collector_proto_init() {
+	if File_collectorpb_collector_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_collectorpb_collector_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*ListCoinsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collectorpb_collector_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ListCoinsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collectorpb_collector_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Coin); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collectorpb_collector_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*CoinStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collectorpb_collector_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*GetRepositoryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collectorpb_collector_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Repository); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collectorpb_collector_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamSnapshotsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collectorpb_collector_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Snapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_collectorpb_collector_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_collectorpb_collector_proto_goTypes,
+		DependencyIndexes: file_collectorpb_collector_proto_depIdxs,
+		MessageInfos:      file_collectorpb_collector_proto_msgTypes,
+	}.Build()
+	File_collectorpb_collector_proto = out.File
+	file_collectorpb_collector_proto_rawDesc = nil
+	file_collectorpb_collector_proto_goTypes = nil
+	file_collectorpb_collector_proto_depIdxs = nil
+}
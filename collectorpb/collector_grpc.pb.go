@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.3
+// source: collectorpb/collector.proto
+
+package collectorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Collector_ListCoins_FullMethodName       = "/collector.v1.Collector/ListCoins"
+	Collector_GetRepository_FullMethodName   = "/collector.v1.Collector/GetRepository"
+	Collector_StreamSnapshots_FullMethodName = "/collector.v1.Collector/StreamSnapshots"
+)
+
+// CollectorClient is the client API for Collector service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CollectorClient interface {
+	// ListCoins lists the coins by id, with the rollup of their repositories.
+	ListCoins(ctx context.Context, in *ListCoinsRequest, opts ...grpc.CallOption) (*ListCoinsResponse, error)
+	// GetRepository returns the latest metrics of a repository, found by id
+	// or by owner and name.
+	GetRepository(ctx context.Context, in *GetRepositoryRequest, opts ...grpc.CallOption) (*Repository, error)
+	// StreamSnapshots streams the snapshots of a repository, oldest first.
+	StreamSnapshots(ctx context.Context, in *StreamSnapshotsRequest, opts ...grpc.CallOption) (Collector_StreamSnapshotsClient, error)
+}
+
+type collectorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCollectorClient(cc grpc.ClientConnInterface) CollectorClient {
+	return &collectorClient{cc}
+}
+
+func (c *collectorClient) ListCoins(ctx context.Context, in *ListCoinsRequest, opts ...grpc.CallOption) (*ListCoinsResponse, error) {
+	out := new(ListCoinsResponse)
+	err := c.cc.Invoke(ctx, Collector_ListCoins_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collectorClient) GetRepository(ctx context.Context, in *GetRepositoryRequest, opts ...grpc.CallOption) (*Repository, error) {
+	out := new(Repository)
+	err := c.cc.Invoke(ctx, Collector_GetRepository_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collectorClient) StreamSnapshots(ctx context.Context, in *StreamSnapshotsRequest, opts ...grpc.CallOption) (Collector_StreamSnapshotsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Collector_ServiceDesc.Streams[0], Collector_StreamSnapshots_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &collectorStreamSnapshotsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Collector_StreamSnapshotsClient interface {
+	Recv() (*Snapshot, error)
+	grpc.ClientStream
+}
+
+type collectorStreamSnapshotsClient struct {
+	grpc.ClientStream
+}
+
+func (x *collectorStreamSnapshotsClient) Recv() (*Snapshot, error) {
+	m := new(Snapshot)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CollectorServer is the server API for Collector service.
+// All implementations must embed UnimplementedCollectorServer
+// for forward compatibility
+type CollectorServer interface {
+	// ListCoins lists the coins by id, with the rollup of their repositories.
+	ListCoins(context.Context, *ListCoinsRequest) (*ListCoinsResponse, error)
+	// GetRepository returns the latest metrics of a repository, found by id
+	// or by owner and name.
+	GetRepository(context.Context, *GetRepositoryRequest) (*Repository, error)
+	// StreamSnapshots streams the snapshots of a repository, oldest first.
+	StreamSnapshots(*StreamSnapshotsRequest, Collector_StreamSnapshotsServer) error
+	mustEmbedUnimplementedCollectorServer()
+}
+
+// UnimplementedCollectorServer must be embedded to have forward compatible implementations.
+type UnimplementedCollectorServer struct {
+}
+
+func (UnimplementedCollectorServer) ListCoins(context.Context, *ListCoinsRequest) (*ListCoinsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCoins not implemented")
+}
+func (UnimplementedCollectorServer) GetRepository(context.Context, *GetRepositoryRequest) (*Repository, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRepository not implemented")
+}
+func (UnimplementedCollectorServer) StreamSnapshots(*StreamSnapshotsRequest, Collector_StreamSnapshotsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSnapshots not implemented")
+}
+func (UnimplementedCollectorServer) mustEmbedUnimplementedCollectorServer() {}
+
+// UnsafeCollectorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CollectorServer will
+// result in compilation errors.
+type UnsafeCollectorServer interface {
+	mustEmbedUnimplementedCollectorServer()
+}
+
+func RegisterCollectorServer(s grpc.ServiceRegistrar, srv CollectorServer) {
+	s.RegisterService(&Collector_ServiceDesc, srv)
+}
+
+func _Collector_ListCoins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCoinsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollectorServer).ListCoins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collector_ListCoins_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollectorServer).ListCoins(ctx, req.(*ListCoinsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Collector_GetRepository_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRepositoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollectorServer).GetRepository(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collector_GetRepository_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollectorServer).GetRepository(ctx, req.(*GetRepositoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Collector_StreamSnapshots_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSnapshotsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CollectorServer).StreamSnapshots(m, &collectorStreamSnapshotsServer{stream})
+}
+
+type Collector_StreamSnapshotsServer interface {
+	Send(*Snapshot) error
+	grpc.ServerStream
+}
+
+type collectorStreamSnapshotsServer struct {
+	grpc.ServerStream
+}
+
+func (x *collectorStreamSnapshotsServer) Send(m *Snapshot) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Collector_ServiceDesc is the grpc.ServiceDesc for Collector service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Collector_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "collector.v1.Collector",
+	HandlerType: (*CollectorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCoins",
+			Handler:    _Collector_ListCoins_Handler,
+		},
+		{
+			MethodName: "GetRepository",
+			Handler:    _Collector_GetRepository_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSnapshots",
+			Handler:       _Collector_StreamSnapshots_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "collectorpb/collector.proto",
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// command is a subcommand of the binary. Run receives the arguments that
+// follow the subcommand name.
+type command struct {
+	Name  string
+	Usage string
+	Run   func(args []string)
+}
+
+var commands = []command{
+	{"collect", "collect metrics for every repository (default)", runCollect},
+	{"retry-failed", "collect only the repositories whose last attempt failed", runRetryFailed},
+	{"migrate", "create or update the database schema", runMigrate},
+	{"serve", "serve the collected metrics over HTTP", runServe},
+	{"serve-webhooks", "apply GitHub push, star and issues webhooks between runs", runServeWebhooks},
+	{"coin", "add, remove or list coins", runCoin},
+	{"repo", "add, remove or list repositories", runRepo},
+	{"discover", "add every public repository of each coin's owner", runDiscover},
+	{"enrich", "fill in coin metadata and repositories from CoinGecko", runEnrich},
+	{"backfill", "write monthly commit counts from before the first run into the snapshots", runBackfillCommits},
+	{"backfill-stars", "write weekly star counts from before the first run into the snapshots", runBackfillStars},
+	{"report", "render a weekly or monthly digest of the coins, optionally emailed", runReport},
+	{"export", "write the repository metrics or their snapshots as CSV or JSON", runExport},
+	{"export-parquet", "write the snapshot history as Parquet files partitioned by date", runExportParquet},
+	{"seed", "upsert coins and repositories from a CSV or JSON file", runSeed},
+	{"list", "list coins and their repositories", runList},
+	{"validate", "report impossible values in the collected metrics", runValidate},
+}
+
+func main() {
+	name := "collect"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	dispatch(os.Args[0], commands, name, args)
+}
+
+// dispatch runs the command called name, or prints the available commands
+// and exits when there is none.
+func dispatch(prog string, cmds []command, name string, args []string) {
+	for _, c := range cmds {
+		if c.Name == name {
+			c.Run(args)
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\nUsage: %s <command> [flags]\n\nCommands:\n", name, prog)
+	for _, c := range cmds {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.Name, c.Usage)
+	}
+	os.Exit(2)
+}
+
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	addConfigFlag(fs)
+	rollback := fs.Bool("rollback", false, "revert the most recently applied migration")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	if *rollback {
+		if err := rollbackSchema(db); err != nil {
+			fatal("Failed to roll back the DB.", "error", err)
+		}
+		return
+	}
+	if err := migrateSchema(db); err != nil {
+		fatal("Failed to migrate the DB.", "error", err)
+	}
+	slog.Info("Migrated.")
+}
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addConfigFlag(fs)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	grpcAddr := fs.String("grpc-addr", "", "address to also serve the gRPC API on, such as :9090")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	if *grpcAddr != "" {
+		go func() {
+			if err := serveGRPC(db, *grpcAddr); err != nil {
+				fatal("The gRPC server stopped.", "error", err)
+			}
+		}()
+	}
+	if err := serve(db, *addr); err != nil {
+		fatal("The server stopped.", "error", err)
+	}
+}
+
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	addConfigFlag(fs)
+	fs.Parse(args)
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	var coins []Coin
+	if err := db.Preload("Repositories").Order("id").Find(&coins).Error; err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSYMBOL\tNAME\tREPOSITORY\tCOMMITS\tUPDATED")
+	for _, coin := range coins {
+		fmt.Fprintf(w, "%d\t%s\t%s\t\t\t\n", coin.Id, coin.Symbol, coin.Name)
+		for _, repo := range coin.Repositories {
+			fmt.Fprintf(w, "\t\t\t%s/%s\t%d\t%s\n", coin.Owner, repo.Name, repo.CommitsCount, repo.UpdatedAt.Format("2006-01-02 15:04"))
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultBots are the commit authors dropped by excludeBots in addition to
+// any author whose name ends in "[bot]".
+var defaultBots = []string{"dependabot", "renovate", "github-actions"}
+
+// commitNode is one commit of the default branch history. Every provider
+// parses CommittedDate with its offset, so dates compare as instants
+// whatever the time zone of the committer. Only GitHub reads Signature,
+// which it leaves nil for unsigned commits.
+type commitNode struct {
+	Oid           string
+	CommittedDate time.Time
+	Author        struct {
+		Name  string
+		Email string
+		User  struct {
+			Login string
+		}
+	}
+	Parents struct {
+		TotalCount int
+	}
+	Signature *struct {
+		IsValid bool
+	}
+}
+
+// commitSettings decides which commits are counted and over which windows.
+type commitSettings struct {
+	Windows         []commitWindow
+	ExcludeBots     bool
+	ExcludeMerges   bool
+	AllBranches     bool
+	TopContributors int
+	bots            map[string]bool
+	personal        map[string]bool
+}
+
+func newCommitSettings(config CommitsConfig) (commitSettings, error) {
+	windows, err := parseCommitWindows(config.Windows)
+	if err != nil {
+		return commitSettings{}, err
+	}
+	bots := map[string]bool{}
+	for _, name := range append(defaultBots, config.Bots...) {
+		bots[strings.ToLower(name)] = true
+	}
+	personal := map[string]bool{}
+	for _, domain := range append(defaultPersonalDomains, config.PersonalDomains...) {
+		personal[strings.ToLower(domain)] = true
+	}
+	return commitSettings{
+		Windows:         windows,
+		ExcludeBots:     config.ExcludeBots,
+		ExcludeMerges:   config.ExcludeMerges,
+		AllBranches:     config.AllBranches,
+		TopContributors: config.TopContributors,
+		bots:            bots,
+		personal:        personal,
+	}, nil
+}
+
+// optionalTime is t as the value of a nullable column, nil when t is
+// unknown.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// signedCommitsPercentage is the percentage of the commits of the last month
+// with a valid signature, nil when there were none.
+func signedCommitsPercentage(nodes []commitNode, now time.Time) *float64 {
+	since := now.AddDate(0, -1, 0)
+	var total, signed int
+	for _, n := range nodes {
+		if n.CommittedDate.Before(since) {
+			continue
+		}
+		total++
+		if n.Signature != nil && n.Signature.IsValid {
+			signed++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	p := 100 * float64(signed) / float64(total)
+	return &p
+}
+
+// allBranches reports whether the recent commits of repo are read from all
+// of its branches rather than only the default one, either because the
+// config says so for every repository or because repo asks for it.
+func (s commitSettings) allBranches(repo Repository) bool {
+	return s.AllBranches || repo.AllBranches
+}
+
+func (s commitSettings) isBot(n commitNode) bool {
+	for _, name := range []string{n.Author.User.Login, n.Author.Name} {
+		name = strings.ToLower(name)
+		if strings.HasSuffix(name, "[bot]") || s.bots[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// filter drops the bot and merge commits the settings exclude.
+func (s commitSettings) filter(nodes []commitNode) []commitNode {
+	if !s.ExcludeBots && !s.ExcludeMerges {
+		return nodes
+	}
+	kept := nodes[:0:0]
+	for _, n := range nodes {
+		if s.ExcludeBots && s.isBot(n) {
+			continue
+		}
+		if s.ExcludeMerges && n.Parents.TotalCount > 1 {
+			continue
+		}
+		kept = append(kept, n)
+	}
+	return kept
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// conditionalSettings are the [GitHub] settings of conditional requests.
+type conditionalSettings struct {
+	Enabled bool
+	MaxAge  time.Duration
+}
+
+// validators are the ETag and Last-Modified GitHub answered for the REST
+// resource of a repository, sent back to ask whether it changed since.
+type validators struct {
+	ETag         string
+	LastModified string
+}
+
+func (v validators) empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// repositoryChanged asks the REST API whether the repository changed since
+// it answered v. The resource covers the stars, forks, open issues and last
+// push, and a 304 Not Modified answer does not count against the rate limit.
+// It also returns the validators of the current state.
+func (c *githubClient) repositoryChanged(ctx context.Context, owner, name string, v validators) (bool, validators, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s", c.apiURL, owner, name)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, validators{}, err
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, validators{}, err
+	}
+	res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		return false, v, nil
+	case http.StatusOK:
+		return true, validators{ETag: res.Header.Get("ETag"), LastModified: res.Header.Get("Last-Modified")}, nil
+	}
+	return false, validators{}, fmt.Errorf("GET %s: %s", endpoint, res.Status)
+}
+
+// checkUnchanged asks GitHub whether the repository of j changed since its
+// last collection, when conditional requests are enabled. It returns the
+// result of an unchanged repository, which needs no collection, or false
+// with j carrying the validators to store once it is collected. A failed
+// check only costs the saving.
+func checkUnchanged(ctx context.Context, client *clients, j *job, timeout time.Duration) (result, bool) {
+	if !client.conditional.Enabled {
+		return result{}, false
+	}
+	if p := j.Repository.Provider; p != "" && p != providerGitHub {
+		return result{}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	loc := locationOf(j.Repository)
+	stored := validators{ETag: j.Repository.ETag, LastModified: j.Repository.LastModified}
+	changed, current, err := client.github.repositoryChanged(ctx, loc.Owner, loc.Name, stored)
+	if err != nil {
+		slog.Debug("Conditional request failed, collecting.", "repo", repoName(j.Repository), "error", err)
+		return result{}, false
+	}
+	j.Validators = current
+	// The commit windows age even when nothing changed, so a repository
+	// is collected in full at least once every MaxAge.
+	stale := client.now.Sub(j.Repository.UpdatedAt) >= client.conditional.MaxAge
+	if changed || stored.empty() || stale {
+		return result{}, false
+	}
+	return result{job: *j, Unchanged: true, Duration: time.Since(start)}, true
+}
@@ -0,0 +1,757 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	confDir            = "./config/env/"
+	defaultMaxAttempts = 5
+	defaultBatchSize   = 10
+
+	defaultConditionalMaxAge = 24 * time.Hour
+
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite3"
+)
+
+type (
+	Config struct {
+		Database      DbConfig
+		Snapshot      SnapshotConfig
+		GitHub        GitHubConfig
+		GitLab        GitLabConfig
+		Bitbucket     BitbucketConfig
+		Gitea         GiteaConfig
+		Commits       CommitsConfig
+		Score         ScoreConfig
+		Collectors    CollectorsConfig
+		Secrets       SecretsConfig
+		Notifications NotificationsConfig
+		SMTP          SMTPConfig
+		TimeSeries    TimeSeriesConfig
+		Events        EventsConfig
+		Tracing       TracingConfig
+		Sentry        SentryConfig
+		HTTP          HTTPConfig
+		Redis         RedisConfig
+		Scrape        ScrapeConfig
+		Repositories  RepositoriesConfig
+		CoinGecko     CoinGeckoConfig
+		Prices        PricesConfig
+	}
+
+	// DbConfig is the [Database] section. The pool settings are left to
+	// database/sql when zero. Params are appended to the DSN as is, e.g.
+	// params = { timeout = "5s", readTimeout = "30s" } for MySQL. For MySQL,
+	// Collation must belong to Charset and Loc is the time zone DATETIME
+	// values are read in, such as "UTC" or "Asia/Tokyo". TLS encrypts MySQL
+	// connections: "verify" checks the server certificate against the TLSCA
+	// bundle (or the system roots), "skip-verify" does not check it and
+	// "preferred" falls back to plain text. For Postgres, TLSCA is passed as
+	// sslrootcert. IAMAuth signs a short-lived AWS RDS token in Region for
+	// every MySQL connection instead of using DB_PASSWORD.
+	DbConfig struct {
+		Driver          string
+		Host            string
+		Port            string
+		User            string
+		Password        string
+		Database        string
+		Charset         string
+		Collation       string
+		Loc             string
+		ParseTime       string
+		SSLMode         string
+		TLS             string
+		TLSCA           string
+		IAMAuth         bool
+		Region          string
+		MaxOpenConns    int
+		MaxIdleConns    int
+		ConnMaxLifetime duration
+		ConnMaxIdleTime duration
+		Params          map[string]string
+	}
+
+	// SnapshotConfig controls the repository_snapshots history table.
+	// RetentionDays of zero keeps snapshots forever.
+	SnapshotConfig struct {
+		RetentionDays int
+	}
+
+	// CommitsConfig lists the trailing windows, such as "90d", for which
+	// commit counts are stored in repository_commit_windows. ExcludeBots
+	// and ExcludeMerges leave bot authors (the built-in list plus Bots) and
+	// merge commits out of every windowed count.
+	CommitsConfig struct {
+		Windows       []string
+		ExcludeBots   bool
+		ExcludeMerges bool
+		Bots          []string
+		// AllBranches counts the recent commits of every branch, once
+		// each, instead of those of the default branch only.
+		AllBranches bool
+		// TopContributors is how many of the most active authors of the
+		// last 90 days are kept per repository, 10 when zero.
+		TopContributors int
+		// PersonalDomains are email domains of personal accounts, whose
+		// commits count as the community's rather than an organization's.
+		PersonalDomains []string
+	}
+
+	// CollectorsConfig lists the enabled collectors: "github", "gitlab",
+	// "bitbucket", "gitea", "scrape" and "git". Leaving it empty enables all
+	// of them. "security" adds the security posture of GitHub repositories
+	// to what the github collector found, and only runs when listed.
+	CollectorsConfig struct {
+		Enabled []string
+	}
+
+	// ScoreConfig weights the 30-day components of the per-coin activity
+	// score. Leaving every weight at zero disables scoring.
+	ScoreConfig struct {
+		Commits      float64
+		Contributors float64
+		Stars        float64
+		PullRequests float64
+	}
+
+	// GitHubConfig tunes how the GitHub APIs are called. MaxAttempts
+	// includes the first try, so 1 disables retries. Once the GraphQL rate
+	// limit drops to MinRemaining points the run either waits for the reset
+	// or, with OnExhausted = "abort", stops collecting. GraphQLURL, APIURL
+	// and WebURL point the collector at a GitHub Enterprise Server and
+	// default to github.com. Setting AppID authenticates as that GitHub App
+	// installation instead of with GITHUB_TOKEN. BatchSize repositories are
+	// fetched per GraphQL query; 1 queries them one by one. Conditional
+	// first asks the REST API whether a repository changed since its last
+	// collection and skips it when it did not, collecting it in full at
+	// least once every ConditionalMaxAge, "24h" by default, so the commit
+	// counts of the last week and month keep up. After BreakerThreshold
+	// repositories in a row, 10 by default, failed with API errors, the
+	// GitHub calls pause for BreakerCoolDown, "5m" by default. Setting
+	// Cassette records the answers to the GraphQL queries in that directory,
+	// or with CassetteMode = "replay" answers the queries from it, offline.
+	// Token, PrivateKey and WebhookSecret are secrets and never read from the
+	// file.
+	GitHubConfig struct {
+		BatchSize         int
+		MaxAttempts       int
+		MinRemaining      int
+		OnExhausted       string
+		GraphQLURL        string
+		APIURL            string
+		WebURL            string
+		AppID             int64
+		InstallationID    int64
+		PrivateKeyPath    string
+		Conditional       bool
+		ConditionalMaxAge duration
+		BreakerThreshold  int
+		BreakerCoolDown   duration
+		Cassette          string
+		CassetteMode      string
+		Token             string `toml:"-" json:"-"`
+		PrivateKey        string `toml:"-" json:"-"`
+		WebhookSecret     string `toml:"-" json:"-"`
+	}
+
+	// GitLabConfig holds the optional GitLab token, which is a secret and
+	// never read from the file.
+	GitLabConfig struct {
+		Token string `toml:"-" json:"-"`
+	}
+
+	// BitbucketConfig holds the optional Bitbucket access token, which is a
+	// secret and never read from the file.
+	BitbucketConfig struct {
+		Token string `toml:"-" json:"-"`
+	}
+
+	// GiteaConfig lists the hosts, such as "git.example.org", of the Gitea
+	// and Forgejo instances repositories can live on, in addition to
+	// codeberg.org and gitea.com. The optional token is a secret and never
+	// read from the file.
+	GiteaConfig struct {
+		Hosts []string
+		Token string `toml:"-" json:"-"`
+	}
+
+	// SecretsConfig selects where DB_PASSWORD, GITHUB_TOKEN,
+	// GITHUB_APP_PRIVATE_KEY, GITHUB_WEBHOOK_SECRET, GITLAB_TOKEN,
+	// BITBUCKET_TOKEN, GITEA_TOKEN, SMTP_PASSWORD, INFLUXDB_TOKEN,
+	// TIMESCALE_DSN, REDIS_PASSWORD and COINGECKO_API_KEY come from.
+	// Provider "env", the default, reads the environment variables. "vault"
+	// reads the KV secret at Path from VaultAddr with VAULT_TOKEN, and "ssm"
+	// reads the parameters under the Path prefix in Region. Secrets missing
+	// from the provider fall back to the environment.
+	SecretsConfig struct {
+		Provider  string
+		Path      string
+		VaultAddr string
+		Region    string
+	}
+
+	// NotificationsConfig holds the Slack and Discord incoming webhook URLs
+	// a summary is posted to after each run. Once AlertMinRepos
+	// repositories (10 by default) have been processed, a share of failed
+	// ones above ErrorRate, such as 0.2, is alerted on right away. Zero
+	// disables the alert.
+	NotificationsConfig struct {
+		Slack         string
+		Discord       string
+		ErrorRate     float64
+		AlertMinRepos int
+	}
+
+	// SMTPConfig is the mail server report --email sends the digest through,
+	// on Port 587 by default. Username and the SMTP_PASSWORD secret, which
+	// is never read from the file, are optional.
+	SMTPConfig struct {
+		Host     string
+		Port     int
+		Username string
+		Password string `toml:"-" json:"-"`
+		From     string
+		To       []string
+	}
+
+	// TimeSeriesConfig selects an optional second sink each run's metrics
+	// are written to as time series points. Driver "influxdb" writes to
+	// Bucket of Org on the InfluxDB 2 server at URL with the INFLUXDB_TOKEN
+	// secret, and "timescale" to the repository_metrics hypertable of the
+	// TimescaleDB the TIMESCALE_DSN secret points at. Token and DSN are
+	// never read from the file.
+	TimeSeriesConfig struct {
+		Driver string
+		URL    string
+		Org    string
+		Bucket string
+		Token  string `toml:"-" json:"-"`
+		DSN    string `toml:"-" json:"-"`
+	}
+
+	// EventsConfig selects an optional broker an event is published to
+	// after each repository a run updates. Driver "kafka" writes to Topic
+	// on Brokers, such as ["kafka-1:9092"], and "nats" publishes on the
+	// subject Topic of the NATS server at URL. Topic defaults to
+	// "commit-count-collector.repositories".
+	EventsConfig struct {
+		Driver  string
+		Brokers []string
+		URL     string
+		Topic   string
+	}
+
+	// TracingConfig exports a trace of each collection run to the OTLP/HTTP
+	// collector at Endpoint, such as "http://otel-collector:4318". The run
+	// has a span per repository, with the API requests, scraped pages and
+	// SQL statements of the repository as its children. SampleRatio is the
+	// share of runs traced, all of them when zero. Tracing is off without an
+	// Endpoint.
+	TracingConfig struct {
+		Endpoint    string
+		ServiceName string
+		SampleRatio float64
+	}
+
+	// SentryConfig reports the collection errors and panics of each run to
+	// the Sentry project of DSN, tagged by coin and repository. Environment
+	// defaults to ENVIRONMENT. Reporting is off without a DSN.
+	SentryConfig struct {
+		DSN         string
+		Environment string
+	}
+
+	// HTTPConfig sets up the client the provider APIs and scraping share.
+	// Timeout bounds a request, retries included, and defaults to "30s".
+	// Proxy is the URL of an HTTP(S) proxy, such as
+	// "http://proxy.internal:3128"; without it HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY apply. UserAgent defaults to "commit-count-collector".
+	// Requests to the same host, API calls and scrapes alike, start at
+	// least Delay plus a random part of Jitter apart; both zero send them
+	// at full speed.
+	HTTPConfig struct {
+		Timeout   duration
+		Proxy     string
+		UserAgent string
+		Delay     duration
+		Jitter    duration
+	}
+
+	// RedisConfig caches the metrics of each repository a run wrote in the
+	// Redis at Addr, such as "localhost:6379", for TTL, "1h" by default. A
+	// run within the TTL reuses them instead of collecting the repository
+	// again. The REDIS_PASSWORD secret is never read from the file. Caching
+	// is off without an Addr.
+	RedisConfig struct {
+		Addr     string
+		DB       int
+		TTL      duration
+		Password string `toml:"-" json:"-"`
+	}
+
+	// ScrapeConfig lists the strategies that find the commits and
+	// contributors counts on a GitHub repository page, tried in order until
+	// one finds a number, so a layout change can be fixed in the file. An
+	// empty list keeps the built-in strategies. The strategies can only be
+	// set in the file. Browser renders the pages where no strategy found the
+	// contributors count in a headless Chrome, at BrowserPath or found on
+	// the PATH, giving the scripts BrowserWait, "2s" by default, to fill
+	// them in.
+	ScrapeConfig struct {
+		Commits      []ScrapeSelector
+		Contributors []ScrapeSelector
+		Browser      bool
+		BrowserPath  string
+		BrowserWait  duration
+	}
+
+	// RepositoriesConfig is the [Repositories] section, lists of owner/name
+	// glob patterns such as "bitcoin/*-mirror" applied to the repositories a
+	// run selects. A pattern prefixed with a coin symbol and a colon, like
+	// "BTC:bitcoin/bitcoin", only applies to that coin's repositories.
+	// Repositories matching Skip are never collected. Once Allow has a
+	// pattern for a coin, or an unprefixed one, only the repositories
+	// matching one of them are. Matching ignores case.
+	RepositoriesConfig struct {
+		Skip  []string
+		Allow []string
+	}
+
+	// CoinGeckoConfig is the [CoinGecko] section of the enrich command.
+	// APIURL defaults to the public API. COINGECKO_API_KEY is optional and
+	// never read from the file; it is sent as a Pro key to
+	// pro-api.coingecko.com and as a Demo key to any other URL.
+	CoinGeckoConfig struct {
+		APIURL string
+		APIKey string `toml:"-" json:"-"`
+	}
+
+	// PricesConfig is the [Prices] section. Provider, "coingecko" or empty
+	// to disable it, is where a collect run reads the daily market snapshot
+	// of the coins from once their stats are refreshed.
+	PricesConfig struct {
+		Provider string
+	}
+
+	// ScrapeSelector takes the Index-th of the elements matching the CSS
+	// Selector whose text is a number; a negative Index counts from the
+	// last one, so -1 is the last.
+	ScrapeSelector struct {
+		Selector string
+		Index    int
+	}
+)
+
+func (c SMTPConfig) smtpPort() int {
+	if c.Port == 0 {
+		return 587
+	}
+	return c.Port
+}
+
+func (c HTTPConfig) timeout() time.Duration {
+	if c.Timeout.Duration == 0 {
+		return defaultHTTPTimeout
+	}
+	return c.Timeout.Duration
+}
+
+func (c HTTPConfig) userAgent() string {
+	if c.UserAgent == "" {
+		return defaultUserAgent
+	}
+	return c.UserAgent
+}
+
+// duration lets TOML strings such as "5m" fill a time.Duration.
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+func (g GitHubConfig) attempts() int {
+	if g.MaxAttempts < 1 {
+		return defaultMaxAttempts
+	}
+	return g.MaxAttempts
+}
+
+func (g GitHubConfig) conditional() conditionalSettings {
+	maxAge := g.ConditionalMaxAge.Duration
+	if maxAge == 0 {
+		maxAge = defaultConditionalMaxAge
+	}
+	return conditionalSettings{Enabled: g.Conditional, MaxAge: maxAge}
+}
+
+func (g GitHubConfig) cassetteMode() string {
+	if g.CassetteMode == "" {
+		return cassetteRecord
+	}
+	return g.CassetteMode
+}
+
+func (g GitHubConfig) batchSize() int {
+	if g.BatchSize < 1 {
+		return defaultBatchSize
+	}
+	return g.BatchSize
+}
+
+func (g GitHubConfig) graphQLURL() string {
+	if g.GraphQLURL == "" {
+		return githubGraphQLURL
+	}
+	return g.GraphQLURL
+}
+
+func (g GitHubConfig) apiURL() string {
+	if g.APIURL == "" {
+		return githubAPIBaseURL
+	}
+	return strings.TrimSuffix(g.APIURL, "/")
+}
+
+func (g GitHubConfig) webURL() string {
+	if g.WebURL == "" {
+		return repository_base_url
+	}
+	return strings.TrimSuffix(g.WebURL, "/")
+}
+
+// dialector opens the configured database with gorm.
+func (d DbConfig) dialector() (gorm.Dialector, error) {
+	switch d.Driver {
+	case driverPostgres:
+		return postgres.Open(d.DSN()), nil
+	case driverSQLite:
+		return sqlite.Open(d.DSN()), nil
+	default:
+		return d.mysqlDialector()
+	}
+}
+
+func (d DbConfig) DSN() string {
+	switch d.Driver {
+	case driverPostgres:
+		u := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(d.User, d.Password),
+			Host:   net.JoinHostPort(d.Host, d.Port),
+			Path:   "/" + d.Database,
+		}
+		query := url.Values{"sslmode": {d.SSLMode}}
+		if d.TLSCA != "" {
+			query.Set("sslrootcert", d.TLSCA)
+		}
+		for k, v := range d.Params {
+			query.Set(k, v)
+		}
+		u.RawQuery = query.Encode()
+		return u.String()
+	case driverSQLite:
+		return d.Database
+	default:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=%s",
+			d.User,
+			d.Password,
+			d.Host,
+			d.Port,
+			d.Database,
+			d.Charset,
+			d.ParseTime)
+		if d.Collation != "" {
+			dsn += "&collation=" + d.Collation
+		}
+		if d.Loc != "" {
+			dsn += "&loc=" + url.QueryEscape(d.Loc)
+		}
+		if tls := d.mysqlTLSParam(); tls != "" {
+			dsn += "&tls=" + tls
+		}
+		keys := make([]string, 0, len(d.Params))
+		for k := range d.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			dsn += "&" + k + "=" + url.QueryEscape(d.Params[k])
+		}
+		return dsn
+	}
+}
+
+// tableOptions is appended to the CREATE TABLE statements of the migrations
+// so new MySQL tables use the configured charset and collation regardless of
+// the database default.
+func (d DbConfig) tableOptions() string {
+	if d.Driver != driverMySQL || d.Charset == "" {
+		return ""
+	}
+	options := "DEFAULT CHARSET=" + d.Charset
+	if d.Collation != "" {
+		options += " COLLATE=" + d.Collation
+	}
+	return options
+}
+
+// configurePool applies the connection pool settings to db.
+func (d DbConfig) configurePool(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if d.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(d.MaxOpenConns)
+	}
+	if d.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(d.MaxIdleConns)
+	}
+	if d.ConnMaxLifetime.Duration > 0 {
+		sqlDB.SetConnMaxLifetime(d.ConnMaxLifetime.Duration)
+	}
+	if d.ConnMaxIdleTime.Duration > 0 {
+		sqlDB.SetConnMaxIdleTime(d.ConnMaxIdleTime.Duration)
+	}
+	return nil
+}
+
+// problems lists every missing or unsupported database setting.
+func (d DbConfig) problems() []string {
+	var problems []string
+	switch d.Driver {
+	case driverMySQL:
+		if d.Charset == "" {
+			problems = append(problems, "charset is required for mysql")
+		}
+		if d.Collation != "" && !strings.HasPrefix(d.Collation, d.Charset+"_") {
+			problems = append(problems, fmt.Sprintf("collation %q does not belong to charset %q", d.Collation, d.Charset))
+		}
+		if d.Loc != "" {
+			if _, err := time.LoadLocation(d.Loc); err != nil {
+				problems = append(problems, fmt.Sprintf("loc %q is not a known time zone", d.Loc))
+			}
+		}
+		switch d.TLS {
+		case "", tlsModeVerify, tlsModeSkipVerify, tlsModePreferred:
+		default:
+			problems = append(problems, fmt.Sprintf("tls %q is not supported, use %q, %q or %q", d.TLS, tlsModeVerify, tlsModeSkipVerify, tlsModePreferred))
+		}
+		if d.TLSCA != "" && d.TLS != tlsModeVerify {
+			problems = append(problems, fmt.Sprintf("tlsCA needs tls = %q", tlsModeVerify))
+		}
+		if d.IAMAuth {
+			if d.TLS != tlsModeVerify && d.TLS != tlsModeSkipVerify {
+				problems = append(problems, "iamAuth needs tls, the token is sent in plain text")
+			}
+			if d.Region == "" {
+				problems = append(problems, "region is required with iamAuth")
+			}
+		}
+	case driverPostgres:
+		switch d.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			problems = append(problems, fmt.Sprintf("sslMode %q is not a valid postgres sslmode", d.SSLMode))
+		}
+	case driverSQLite:
+	default:
+		problems = append(problems, fmt.Sprintf("driver %q is not supported, use %q, %q or %q", d.Driver, driverMySQL, driverPostgres, driverSQLite))
+	}
+	if d.Driver != driverSQLite {
+		// SQLite only needs the file path in database.
+		if d.Host == "" {
+			problems = append(problems, "host is required")
+		}
+		if _, err := strconv.Atoi(d.Port); err != nil {
+			problems = append(problems, fmt.Sprintf("port %q is not a number", d.Port))
+		}
+		if d.User == "" {
+			problems = append(problems, "user is required")
+		}
+	}
+	if d.Database == "" {
+		problems = append(problems, "database is required")
+	}
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 {
+		problems = append(problems, "maxOpenConns and maxIdleConns must not be negative")
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		problems = append(problems, "maxIdleConns must not exceed maxOpenConns")
+	}
+	return problems
+}
+
+// configPath is set by the --config flag every command accepts.
+var configPath string
+
+func addConfigFlag(fs *flag.FlagSet) {
+	fs.StringVar(&configPath, "config", "", "config file (.toml, .yaml, .yml or .json), default ./config/env/<ENVIRONMENT>.toml")
+}
+
+// loadConfig reads the --config file, or ./config/env/<ENVIRONMENT>.toml when
+// ENVIRONMENT is set, and the environment alone otherwise. Either way the
+// COLLECTOR_ variables, which may come from a .env file, take precedence over
+// the file.
+func loadConfig() Config {
+	if err := loadDotEnv(dotEnvPath); err != nil {
+		fatal("Failed to read the .env file.", "error", err)
+	}
+
+	return readValidConfig(false)
+}
+
+// loadGitHubConfig is loadConfig for the commands calling the GitHub API,
+// which also need its credentials.
+func loadGitHubConfig() Config {
+	if err := loadDotEnv(dotEnvPath); err != nil {
+		fatal("Failed to read the .env file.", "error", err)
+	}
+
+	return readValidConfig(true)
+}
+
+func readValidConfig(github bool) Config {
+	environment := os.Getenv("ENVIRONMENT")
+	if err := checkEnvironment(environment); err != nil {
+		fatal("Invalid config.", "error", err)
+	}
+	path := configPath
+	if path == "" && environment != "" {
+		path = confDir + environment + ".toml"
+	}
+	config := readConfig(path)
+	if err := config.validate(github); err != nil {
+		fatal("Invalid config.", "error", err)
+	}
+	return config
+}
+
+func dbConnect(config Config) *gorm.DB {
+	// Foreign keys are added by the migrations, not by AutoMigrate. Errors
+	// are returned to and logged by the callers, with the unique index
+	// violations of every driver translated to gorm.ErrDuplicatedKey.
+	dialector, err := config.Database.dialector()
+	if err != nil {
+		fatal("Failed to set up the DB connection.", "error", err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		TranslateError:                           true,
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		fatal("Failed to connect to the DB.", "error", err)
+	}
+	if err := db.Use(tracingPlugin{system: dbSystem(config.Database.Driver)}); err != nil {
+		fatal("Failed to set up the DB tracing.", "error", err)
+	}
+	if err := config.Database.configurePool(db); err != nil {
+		fatal("Failed to configure the DB connection pool.", "error", err)
+	}
+	if options := config.Database.tableOptions(); options != "" {
+		db = db.Set("gorm:table_options", options).Session(&gorm.Session{})
+	}
+
+	// A fresh SQLite file has no tables yet, so create them right away.
+	if config.Database.Driver == driverSQLite && !db.Migrator().HasTable(&SchemaMigration{}) {
+		if err := migrateSchema(db); err != nil {
+			fatal("Failed to create the SQLite schema.", "error", err)
+		}
+	}
+	return db
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+func readConfig(path string) Config {
+	var config Config
+	if path != "" {
+		if err := decodeConfigFile(path, &config); err != nil {
+			fatal("Failed to read the Config.", "path", path, "error", err)
+		}
+	}
+	if err := applyEnv(&config); err != nil {
+		fatal("Failed to read the Config.", "error", err)
+	}
+
+	secrets, err := loadSecrets(config.Secrets)
+	if err != nil {
+		fatal("Failed to load the secrets.", "provider", config.Secrets.Provider, "error", err)
+	}
+	config.Database.Password = secrets["DB_PASSWORD"]
+	config.GitHub.Token = secrets["GITHUB_TOKEN"]
+	config.GitHub.PrivateKey = secrets["GITHUB_APP_PRIVATE_KEY"]
+	config.GitHub.WebhookSecret = secrets["GITHUB_WEBHOOK_SECRET"]
+	config.GitLab.Token = secrets["GITLAB_TOKEN"]
+	config.Bitbucket.Token = secrets["BITBUCKET_TOKEN"]
+	config.Gitea.Token = secrets["GITEA_TOKEN"]
+	config.SMTP.Password = secrets["SMTP_PASSWORD"]
+	config.TimeSeries.Token = secrets["INFLUXDB_TOKEN"]
+	config.TimeSeries.DSN = secrets["TIMESCALE_DSN"]
+	config.Redis.Password = secrets["REDIS_PASSWORD"]
+	config.CoinGecko.APIKey = secrets["COINGECKO_API_KEY"]
+	giteaHosts = append(giteaHosts, config.Gitea.Hosts...)
+
+	return config
+}
+
+// decodeConfigFile picks the format by extension. YAML is converted to JSON
+// first so that, like in TOML, keys match the fields case-insensitively.
+func decodeConfigFile(path string, config *Config) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		_, err := toml.DecodeFile(path, config)
+		return err
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, config)
+	case ".yaml", ".yml":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+		return json.Unmarshal(data, config)
+	default:
+		return fmt.Errorf("unknown config format %q, use .toml, .yaml, .yml or .json", filepath.Ext(path))
+	}
+}
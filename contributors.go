@@ -0,0 +1,127 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// contributorsHistoryDays is how far back the history is read so the
+	// 90-day active contributor counts are complete.
+	contributorsHistoryDays = 90
+
+	defaultTopContributors = 10
+)
+
+// authorKey identifies a commit author by login, falling back to the email
+// and then the name for commits not linked to an account.
+func authorKey(n commitNode) string {
+	switch {
+	case n.Author.User.Login != "":
+		return n.Author.User.Login
+	case n.Author.Email != "":
+		return strings.ToLower(n.Author.Email)
+	default:
+		return n.Author.Name
+	}
+}
+
+// activeContributors returns one row per distinct author of the commits
+// made in the last 90 days.
+func activeContributors(nodes []commitNode, now time.Time) []RepositoryContributor {
+	last30 := now.AddDate(0, 0, -30)
+	last90 := now.AddDate(0, 0, -contributorsHistoryDays)
+
+	byAuthor := map[string]*RepositoryContributor{}
+	for _, n := range nodes {
+		key := authorKey(n)
+		if key == "" || n.CommittedDate.Before(last90) {
+			continue
+		}
+		c, ok := byAuthor[key]
+		if !ok {
+			c = &RepositoryContributor{Author: key}
+			byAuthor[key] = c
+		}
+		c.CommitsCountForTheLast90Days++
+		if !n.CommittedDate.Before(last30) {
+			c.CommitsCountForTheLast30Days++
+		}
+		if n.CommittedDate.After(c.LastCommittedAt) {
+			c.LastCommittedAt = n.CommittedDate.UTC()
+		}
+	}
+
+	contributors := make([]RepositoryContributor, 0, len(byAuthor))
+	for _, c := range byAuthor {
+		contributors = append(contributors, *c)
+	}
+	sort.Slice(contributors, func(i, j int) bool { return contributors[i].Author < contributors[j].Author })
+	return contributors
+}
+
+// topContributors returns the n authors with the most commits in the last
+// 90 days, ranked from 1, nil when contributors is.
+func topContributors(contributors []RepositoryContributor, n int) []RepositoryTopContributor {
+	if contributors == nil {
+		return nil
+	}
+	if n <= 0 {
+		n = defaultTopContributors
+	}
+	sorted := append([]RepositoryContributor{}, contributors...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CommitsCountForTheLast90Days > sorted[j].CommitsCountForTheLast90Days
+	})
+	top := make([]RepositoryTopContributor, 0, min(n, len(sorted)))
+	for i, c := range sorted[:min(n, len(sorted))] {
+		top = append(top, RepositoryTopContributor{
+			Rank:                         i + 1,
+			Login:                        c.Author,
+			CommitsCountForTheLast90Days: c.CommitsCountForTheLast90Days,
+		})
+	}
+	return top
+}
+
+// saveTopContributors replaces the top contributor rows of a repository.
+// Nil contributors leave them as they are, as the collector did not read
+// commits.
+func saveTopContributors(db *gorm.DB, repositoryId int, top []RepositoryTopContributor, now time.Time) error {
+	if top == nil {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repositoryId).Delete(&RepositoryTopContributor{}).Error; err != nil {
+			return err
+		}
+		for _, c := range top {
+			c.RepositoryId = repositoryId
+			c.UpdatedAt = now
+			if err := tx.Create(&c).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// saveContributors replaces the contributor rows of a repository. Within a
+// transaction it runs in a savepoint.
+func saveContributors(db *gorm.DB, repositoryId int, contributors []RepositoryContributor, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repositoryId).Delete(&RepositoryContributor{}).Error; err != nil {
+			return err
+		}
+		for _, c := range contributors {
+			c.RepositoryId = repositoryId
+			c.UpdatedAt = now
+			if err := tx.Create(&c).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	tlsModeVerify     = "verify"
+	tlsModeSkipVerify = "skip-verify"
+	tlsModePreferred  = "preferred"
+
+	// mysqlTLSConfigName is the name the verifying TLS config is registered
+	// under with the MySQL driver.
+	mysqlTLSConfigName = "collector"
+
+	// rdsTokenExpiry is the longest lifetime RDS accepts for an IAM token.
+	rdsTokenExpiry = 15 * time.Minute
+	// emptyPayloadHash is the SHA-256 of an empty body.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// mysqlTLSParam is the tls value of the MySQL DSN for the configured mode.
+func (d DbConfig) mysqlTLSParam() string {
+	switch d.TLS {
+	case tlsModeVerify:
+		return mysqlTLSConfigName
+	case tlsModeSkipVerify, tlsModePreferred:
+		return d.TLS
+	}
+	return ""
+}
+
+// registerMySQLTLS registers the verifying TLS config with the MySQL driver.
+// Without TLSCA the system roots are trusted.
+func (d DbConfig) registerMySQLTLS() error {
+	config := &tls.Config{ServerName: d.Host, MinVersion: tls.VersionTLS12}
+	if d.TLSCA != "" {
+		pem, err := os.ReadFile(d.TLSCA)
+		if err != nil {
+			return err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in %s", d.TLSCA)
+		}
+	}
+	return mysqldriver.RegisterTLSConfig(mysqlTLSConfigName, config)
+}
+
+// mysqlDialector opens MySQL through a connector so that, with IAMAuth, every
+// new connection signs a fresh token instead of using a password.
+func (d DbConfig) mysqlDialector() (gorm.Dialector, error) {
+	if d.TLS == tlsModeVerify {
+		if err := d.registerMySQLTLS(); err != nil {
+			return nil, fmt.Errorf("failed to load the TLS CA bundle: %w", err)
+		}
+	}
+	// varchar(255) like gorm v1, so indexed strings fit MySQL's key size.
+	if !d.IAMAuth {
+		return mysql.New(mysql.Config{DSN: d.DSN(), DefaultStringSize: 255}), nil
+	}
+
+	cfg, err := mysqldriver.ParseDSN(d.DSN())
+	if err != nil {
+		return nil, err
+	}
+	creds, err := d.awsCredentials()
+	if err != nil {
+		return nil, err
+	}
+	// The token is sent as a cleartext password, which TLS protects.
+	cfg.AllowCleartextPasswords = true
+	endpoint := net.JoinHostPort(d.Host, d.Port)
+	err = cfg.Apply(mysqldriver.BeforeConnect(func(ctx context.Context, c *mysqldriver.Config) error {
+		token, err := rdsAuthToken(ctx, endpoint, d.Region, d.User, creds, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to build the RDS IAM token: %w", err)
+		}
+		c.Passwd = token
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+	connector, err := mysqldriver.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return mysql.New(mysql.Config{Conn: sql.OpenDB(connector), DefaultStringSize: 255}), nil
+}
+
+// awsCredentials loads the credentials the default AWS chain finds: the
+// environment, the shared config files or the instance role.
+func (d DbConfig) awsCredentials() (aws.CredentialsProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(d.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS config: %w", err)
+	}
+	if cfg.Credentials == nil {
+		return nil, fmt.Errorf("no AWS credentials found")
+	}
+	return cfg.Credentials, nil
+}
+
+// rdsAuthToken presigns an RDS connect request for user, which RDS accepts
+// as the password for rdsTokenExpiry.
+func rdsAuthToken(ctx context.Context, endpoint, region, user string, creds aws.CredentialsProvider, now time.Time) (string, error) {
+	query := url.Values{
+		"Action":        {"connect"},
+		"DBUser":        {user},
+		"X-Amz-Expires": {fmt.Sprint(int(rdsTokenExpiry.Seconds()))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+endpoint+"/?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	c, err := creds.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	signed, _, err := v4.NewSigner().PresignHTTP(ctx, c, req, emptyPayloadHash, "rds-db", region, now)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(signed, "https://"), nil
+}
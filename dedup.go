@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// sharedRepositories maps the id of the repository collected for a location
+// to the other repositories at the same location, attached to other coins.
+type sharedRepositories map[int][]Repository
+
+// dedupeRepositories keeps one repository per location, so a repository
+// several coins share, such as a common library, is collected once per run.
+// The one kept is the least recently updated, whose metrics are the most
+// out of date.
+func dedupeRepositories(repos []Repository) ([]Repository, sharedRepositories) {
+	byLocation := map[repositoryLocation][]Repository{}
+	var order []repositoryLocation
+	for _, repo := range repos {
+		key := locationKey(locationOf(repo))
+		if _, ok := byLocation[key]; !ok {
+			order = append(order, key)
+		}
+		byLocation[key] = append(byLocation[key], repo)
+	}
+	if len(order) == len(repos) {
+		return repos, nil
+	}
+
+	unique := make([]Repository, 0, len(order))
+	shared := sharedRepositories{}
+	for _, key := range order {
+		group := byLocation[key]
+		sort.SliceStable(group, func(i, j int) bool { return group[i].UpdatedAt.Before(group[j].UpdatedAt) })
+		unique = append(unique, group[0])
+		if len(group) > 1 {
+			shared[group[0].Id] = group[1:]
+		}
+	}
+	slog.Info("Repositories shared by several coins are collected once.", "shared", len(repos)-len(unique))
+	return unique, shared
+}
+
+// fanOut returns the result of a collected repository followed by a copy for
+// each repository sharing its location.
+func (s sharedRepositories) fanOut(r result) []result {
+	results := []result{r}
+	for _, repo := range s[r.Repository.Id] {
+		copied := r
+		copied.Repository = repo
+		results = append(results, copied)
+	}
+	return results
+}
+
+// locationKey ignores the case GitHub ignores too.
+func locationKey(loc repositoryLocation) repositoryLocation {
+	loc.Owner = strings.ToLower(loc.Owner)
+	loc.Name = strings.ToLower(loc.Name)
+	loc.BaseURL = strings.ToLower(loc.BaseURL)
+	return loc
+}
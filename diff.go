@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// metricField is one collected value of a repository.
+type metricField struct {
+	Name  string
+	Value interface{}
+}
+
+// metricFields lists the collected values of r, named after their columns.
+func metricFields(r Repository) []metricField {
+	return []metricField{
+		{"status", r.Status},
+		{"language", r.Language},
+		{"pull_requests_count", r.PullRequestsCount},
+		{"open_pull_requests_count", r.OpenPullRequestsCount},
+		{"closed_pull_requests_count", r.ClosedPullRequestsCount},
+		{"merged_pull_requests_count", r.MergedPullRequestsCount},
+		{"watchers_count", r.WatchersCount},
+		{"stargazers_count", r.StargazersCount},
+		{"issues_count", r.IssuesCount},
+		{"open_issues_count", r.OpenIssuesCount},
+		{"closed_issues_count", r.ClosedIssuesCount},
+		{"commits_count_for_the_last_week", r.CommitsCountForTheLastWeek},
+		{"commits_count_for_the_last_month", r.CommitsCountForTheLastMonth},
+		{"commits_count", r.CommitsCount},
+		{"contributors_count", r.ContributorsCount},
+		{"forks_count", r.ForksCount},
+		{"releases_count", r.ReleasesCount},
+		{"tags_count", r.TagsCount},
+		{"default_branch", r.DefaultBranch},
+		{"license", r.License},
+		{"latest_release_tag", r.LatestReleaseTag},
+		{"latest_release_at", timeValue(r.LatestReleaseAt)},
+		{"releases_count_for_the_last90_days", r.ReleasesCountForTheLast90Days},
+		{"median_issue_close_seconds", r.MedianIssueCloseSeconds},
+		{"median_pull_request_merge_seconds", r.MedianPullRequestMergeSeconds},
+		{"last_commit_at", timeValue(r.LastCommitAt)},
+		{"signed_commits_percentage", percentageValue(r.SignedCommitsPercentage)},
+		{"has_security_policy", boolValue(r.HasSecurityPolicy)},
+		{"default_branch_protected", boolValue(r.DefaultBranchProtected)},
+		{"dependabot_configured", boolValue(r.DependabotConfigured)},
+	}
+}
+
+// boolValue formats b for metricChanges, which skips the "" of a nil b.
+func boolValue(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+// percentageValue formats p for metricChanges, which skips the "" of a nil p.
+func percentageValue(p *float64) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", *p)
+}
+
+// timeValue formats t for metricChanges, which skips the "" of a nil t.
+func timeValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// metricChanges describes every value that differs between old and new as
+// "name: old → new". Zero values in new are skipped because Updates does not
+// write them either.
+func metricChanges(old, new Repository) []string {
+	var changes []string
+	before := metricFields(old)
+	for i, after := range metricFields(new) {
+		if after.Value == 0 || after.Value == "" {
+			continue
+		}
+		if before[i].Value != after.Value {
+			changes = append(changes, fmt.Sprintf("%s: %v → %v", after.Name, before[i].Value, after.Value))
+		}
+	}
+	return changes
+}
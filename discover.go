@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/shurcooL/githubv4"
+	"gorm.io/gorm"
+	"log/slog"
+	"os"
+)
+
+type (
+	// ownerRepositoriesQuery lists the public repositories of a user or an
+	// organization.
+	ownerRepositoriesQuery struct {
+		RepositoryOwner struct {
+			Repositories struct {
+				Nodes    []ownerRepository
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   githubv4.String
+				}
+			} `graphql:"repositories(first: 100, after: $cursor, privacy: PUBLIC)"`
+		} `graphql:"repositoryOwner(login: $owner)"`
+		RateLimit rateLimit
+	}
+
+	ownerRepository struct {
+		Name       string
+		IsFork     bool
+		IsArchived bool
+	}
+
+	discoverOptions struct {
+		IncludeForks    bool
+		IncludeArchived bool
+		Prune           bool
+	}
+)
+
+func (c *githubClient) ownerRepositories(ctx context.Context, owner string) ([]ownerRepository, error) {
+	var repos []ownerRepository
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	for {
+		var query ownerRepositoriesQuery
+		if err := c.budget.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+		c.budget.update(query.RateLimit)
+
+		page := query.RepositoryOwner.Repositories
+		repos = append(repos, page.Nodes...)
+		if !page.PageInfo.HasNextPage {
+			return repos, nil
+		}
+		variables["cursor"] = githubv4.NewString(page.PageInfo.EndCursor)
+	}
+}
+
+// discover creates a repository row for every public repository of the
+// coin's owner that passes the filters. With Prune it also deletes GitHub
+// repositories that no longer exist or no longer pass them.
+func discover(ctx context.Context, db *gorm.DB, client *githubClient, coin Coin, opts discoverOptions) (created, pruned int, err error) {
+	found, err := client.ownerRepositories(ctx, coin.Owner)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	keep := map[string]bool{}
+	for _, r := range found {
+		if (r.IsFork && !opts.IncludeForks) || (r.IsArchived && !opts.IncludeArchived) {
+			continue
+		}
+		keep[r.Name] = true
+
+		_, err := addRepository(db, coin, repositoryLocation{Provider: providerGitHub, Owner: coin.Owner, Name: r.Name})
+		switch {
+		case err == nil:
+			created++
+			slog.Info("Discovered repository.", "coin_id", coin.Id, "repo", coin.Owner+"/"+r.Name)
+		case !errors.Is(err, errDuplicate):
+			return created, pruned, err
+		}
+	}
+
+	if !opts.Prune {
+		return created, 0, nil
+	}
+	var existing []Repository
+	if err := db.Where("coin_id = ? AND provider = ?", coin.Id, providerGitHub).Find(&existing).Error; err != nil {
+		return created, 0, err
+	}
+	for _, repo := range existing {
+		if keep[repo.Name] {
+			continue
+		}
+		if err := deleteRepositories(db, db.Where("id = ?", repo.Id)); err != nil {
+			return created, pruned, err
+		}
+		pruned++
+		slog.Info("Pruned repository.", "coin_id", coin.Id, "repo", coin.Owner+"/"+repo.Name)
+	}
+	return created, pruned, nil
+}
+
+func runDiscover(args []string) {
+	var opts discoverOptions
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	addConfigFlag(fs)
+	symbol := fs.String("coin", "", "only discover repositories of this coin")
+	fs.BoolVar(&opts.IncludeForks, "include-forks", false, "also add forked repositories")
+	fs.BoolVar(&opts.IncludeArchived, "include-archived", false, "also add archived repositories")
+	fs.BoolVar(&opts.Prune, "prune", false, "delete GitHub repositories that are gone from the owner or filtered out")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	config := loadGitHubConfig()
+	db := dbConnect(config)
+	defer closeDB(db)
+
+	var coins []Coin
+	if *symbol != "" {
+		coin, err := findCoin(db, *symbol)
+		if err != nil {
+			fatal("Failed to read the DB.", "error", err)
+		}
+		coins = append(coins, coin)
+	} else if err := db.Where("active = ?", true).Order("id").Find(&coins).Error; err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	h, err := newHTTPClients(config.HTTP)
+	if err != nil {
+		fatal("Invalid [HTTP] config.", "error", err)
+	}
+	client := newGitHubClient(config.GitHub, h)
+
+	failed := false
+	for _, coin := range coins {
+		created, pruned, err := discover(ctx, db, client, coin, opts)
+		if err != nil {
+			slog.Error("Discovery ERROR.", "coin_id", coin.Id, "owner", coin.Owner, "error", err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: %d added, %d pruned.\n", coin.Symbol, created, pruned)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
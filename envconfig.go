@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// envPrefix starts the variables that set config fields, such as
+	// COLLECTOR_DATABASE_HOST or COLLECTOR_GITHUB_BATCHSIZE.
+	envPrefix  = "COLLECTOR_"
+	dotEnvPath = ".env"
+)
+
+// loadDotEnv sets the KEY=VALUE pairs of path as environment variables. A
+// missing file is fine, and variables already set are never overridden.
+func loadDotEnv(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				if value, err = strconv.Unquote(value); err != nil {
+					return fmt.Errorf("%s:%d: %w", path, n, err)
+				}
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+		}
+	}
+	return scanner.Err()
+}
+
+// applyEnv overrides the fields of config with the COLLECTOR_<SECTION>_<FIELD>
+// variables that are set. Lists are comma separated and maps are written as
+// key=value,key=value.
+func applyEnv(config *Config) error {
+	sections := reflect.ValueOf(config).Elem()
+	var problems []string
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		prefix := envPrefix + strings.ToUpper(sections.Type().Field(i).Name) + "_"
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			if field.Tag.Get("toml") == "-" {
+				continue
+			}
+			name := prefix + strings.ToUpper(field.Name)
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setField(section.Field(j), value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid environment config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func setField(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		m := map[string]string{}
+		for _, pair := range strings.Split(value, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
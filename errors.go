@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"gorm.io/gorm"
+	"time"
+)
+
+func recordCollectionError(db *gorm.DB, runId, repositoryId int, err error, now time.Time) error {
+	return db.Create(&CollectionError{
+		RunId:        runId,
+		RepositoryId: repositoryId,
+		Kind:         errorKind(err),
+		Message:      err.Error(),
+		CreatedAt:    now,
+	}).Error
+}
+
+// resolveCollectionErrors marks the open errors of a repository as resolved
+// after it was collected successfully.
+func resolveCollectionErrors(db *gorm.DB, repositoryId int, now time.Time) error {
+	return db.Model(&CollectionError{}).
+		Where("repository_id = ? AND resolved_at IS NULL", repositoryId).
+		Update("resolved_at", now).Error
+}
+
+// failedRepositories returns the repositories with unresolved errors.
+func failedRepositories(db *gorm.DB) ([]Repository, error) {
+	var repos []Repository
+	err := db.Preload("Coin").
+		Where("id IN (?)", db.Model(&CollectionError{}).Select("repository_id").Where("resolved_at IS NULL")).
+		Find(&repos).Error
+	return repos, err
+}
+
+func runRetryFailed(args []string) {
+	fs := flag.NewFlagSet("retry-failed", flag.ExitOnError)
+	addConfigFlag(fs)
+	opts := addCollectFlags(fs)
+	fs.Parse(args)
+	opts.validate()
+
+	config := loadGitHubConfig()
+	defer startTracing(config.Tracing)()
+	defer startSentry(config.Sentry)()
+	defer reportPanic()
+	db := dbConnect(config)
+	defer closeDB(db)
+
+	repos, err := failedRepositories(db)
+	if err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+	collectAll(db, config, repos, opts, opts.newMetrics(), clock.Now())
+}
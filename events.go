@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+const (
+	eventsKafka = "kafka"
+	eventsNATS  = "nats"
+
+	// defaultEventsTopic is the Kafka topic or NATS subject events are
+	// published to when [Events] does not name one.
+	defaultEventsTopic = "commit-count-collector.repositories"
+
+	eventsTimeout = 30 * time.Second
+)
+
+type (
+	// repositoryEvent is published after a run updated a repository. Old
+	// and New hold the metrics before and after the update, and Changed the
+	// names of those that differ.
+	repositoryEvent struct {
+		RunId        int                    `json:"run_id"`
+		CoinId       int                    `json:"coin_id"`
+		Coin         string                 `json:"coin"`
+		RepositoryId int                    `json:"repository_id"`
+		Repository   string                 `json:"repository"`
+		Provider     string                 `json:"provider"`
+		Old          map[string]interface{} `json:"old"`
+		New          map[string]interface{} `json:"new"`
+		Changed      []string               `json:"changed"`
+		CollectedAt  time.Time              `json:"collected_at"`
+	}
+
+	eventPublisher interface {
+		publish(ctx context.Context, key string, payload []byte) error
+		close() error
+	}
+
+	// eventBus publishes a repositoryEvent per updated repository to the
+	// [Events] broker. A failed publish is logged; events never fail the
+	// run.
+	eventBus struct {
+		publisher eventPublisher
+	}
+
+	kafkaPublisher struct {
+		writer *kafka.Writer
+	}
+
+	natsPublisher struct {
+		conn    *nats.Conn
+		subject string
+	}
+)
+
+// newEventBus connects to the configured broker. Without a driver it
+// returns an eventBus that publishes nothing.
+func newEventBus(config EventsConfig) (*eventBus, error) {
+	topic := config.Topic
+	if topic == "" {
+		topic = defaultEventsTopic
+	}
+	switch config.Driver {
+	case "":
+		return &eventBus{}, nil
+	case eventsKafka:
+		return &eventBus{publisher: newKafkaPublisher(config.Brokers, topic)}, nil
+	case eventsNATS:
+		conn, err := nats.Connect(config.URL, nats.Name("commit-count-collector"))
+		if err != nil {
+			return nil, err
+		}
+		return &eventBus{publisher: &natsPublisher{conn: conn, subject: topic}}, nil
+	}
+	return nil, fmt.Errorf("unknown events driver %q", config.Driver)
+}
+
+// newRepositoryEvent describes the update of old to new by run.
+func newRepositoryEvent(runId int, old, new Repository, collectedAt time.Time) repositoryEvent {
+	loc := locationOf(new)
+	e := repositoryEvent{
+		RunId:        runId,
+		CoinId:       new.CoinId,
+		Coin:         new.Coin.Symbol,
+		RepositoryId: new.Id,
+		Repository:   loc.Owner + "/" + loc.Name,
+		Provider:     loc.Provider,
+		Old:          map[string]interface{}{},
+		New:          map[string]interface{}{},
+		Changed:      []string{},
+		CollectedAt:  collectedAt,
+	}
+	before := metricFields(old)
+	for i, after := range metricFields(new) {
+		e.Old[after.Name] = before[i].Value
+		e.New[after.Name] = after.Value
+		if before[i].Value != after.Value {
+			e.Changed = append(e.Changed, after.Name)
+		}
+	}
+	return e
+}
+
+// publish sends the event of the update of old to new, keyed by the
+// repository id so the events of a repository stay in order.
+func (e *eventBus) publish(runId int, old, new Repository, collectedAt time.Time) {
+	if e.publisher == nil {
+		return
+	}
+	payload, err := json.Marshal(newRepositoryEvent(runId, old, new, collectedAt))
+	if err != nil {
+		slog.Error("Failed to encode the event.", "repository_id", new.Id, "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventsTimeout)
+	defer cancel()
+	if err := e.publisher.publish(ctx, strconv.Itoa(new.Id), payload); err != nil {
+		slog.Error("Failed to publish the event.", "repository_id", new.Id, "error", err)
+	}
+}
+
+// close delivers the events still buffered and disconnects.
+func (e *eventBus) close() {
+	if e.publisher == nil {
+		return
+	}
+	if err := e.publisher.close(); err != nil {
+		slog.Error("Failed to close the event publisher.", "error", err)
+	}
+}
+
+// newKafkaPublisher writes asynchronously, batching the events of a run;
+// delivery failures are logged as they are reported.
+func newKafkaPublisher(brokers []string, topic string) *kafkaPublisher {
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				slog.Error("Failed to deliver the events.", "count", len(messages), "error", err)
+			}
+		},
+	}}
+}
+
+func (p *kafkaPublisher) publish(ctx context.Context, key string, payload []byte) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{Key: []byte(key), Value: payload})
+}
+
+func (p *kafkaPublisher) close() error {
+	return p.writer.Close()
+}
+
+func (p *natsPublisher) publish(ctx context.Context, key string, payload []byte) error {
+	msg := nats.NewMsg(p.subject)
+	msg.Header.Set("Repository-Id", key)
+	msg.Data = payload
+	return p.conn.PublishMsg(msg)
+}
+
+func (p *natsPublisher) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), eventsTimeout)
+	defer cancel()
+	err := p.conn.FlushWithContext(ctx)
+	p.conn.Close()
+	return err
+}
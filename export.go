@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+
+	exportBatchSize = 1000
+)
+
+// exportCoinColumns identify the coin and repository of every exported row,
+// ahead of the columns of the row itself.
+var exportCoinColumns = []string{"coin_symbol", "coin_name", "owner", "repository"}
+
+type (
+	// exporter writes rows of values in the order of the columns it was
+	// created with.
+	exporter interface {
+		write(values []interface{}) error
+		close() error
+	}
+
+	csvExporter struct {
+		w *csv.Writer
+	}
+
+	// jsonExporter writes a JSON array of objects whose keys keep the
+	// column order.
+	jsonExporter struct {
+		w       *bufio.Writer
+		columns []string
+		rows    int
+	}
+)
+
+func newExporter(w io.Writer, format string, columns []string) (exporter, error) {
+	switch format {
+	case exportFormatCSV:
+		e := &csvExporter{w: csv.NewWriter(w)}
+		return e, e.w.Write(columns)
+	case exportFormatJSON:
+		e := &jsonExporter{w: bufio.NewWriter(w), columns: columns}
+		_, err := e.w.WriteString("[")
+		return e, err
+	}
+	return nil, fmt.Errorf("format must be %q or %q, got %q", exportFormatCSV, exportFormatJSON, format)
+}
+
+func (e *csvExporter) write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = csvValue(v)
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExporter) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvValue formats times as RFC 3339 in UTC and leaves NULLs empty.
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func (e *jsonExporter) write(values []interface{}) error {
+	if e.rows > 0 {
+		e.w.WriteString(",")
+	}
+	e.rows++
+	e.w.WriteString("\n  {")
+	for i, v := range values {
+		if i > 0 {
+			e.w.WriteString(", ")
+		}
+		key, _ := json.Marshal(e.columns[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.w.Write(key)
+		e.w.WriteString(": ")
+		e.w.Write(value)
+	}
+	_, err := e.w.WriteString("}")
+	return err
+}
+
+func (e *jsonExporter) close() error {
+	if e.rows > 0 {
+		e.w.WriteString("\n")
+	}
+	e.w.WriteString("]\n")
+	return e.w.Flush()
+}
+
+// rowColumns lists the columns of a model by their JSON names, in field
+// order, leaving out the fields that are not stored and the relations.
+func rowColumns(model interface{}) []string {
+	t := reflect.TypeOf(model)
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" || f.Tag.Get("gorm") == "-" {
+			continue
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+// rowValue returns the value of the field of row named column in JSON, nil
+// for NULL.
+func rowValue(row interface{}, column string) interface{} {
+	v := reflect.ValueOf(row)
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name != column {
+			continue
+		}
+		f := v.Field(i)
+		if f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				return nil
+			}
+			f = f.Elem()
+		}
+		return f.Interface()
+	}
+	return nil
+}
+
+// selectColumns checks the comma separated columns against available and
+// returns them, or every available column when list is empty.
+func selectColumns(list string, available []string) ([]string, error) {
+	if list == "" {
+		return available, nil
+	}
+	var columns []string
+	for _, c := range strings.Split(list, ",") {
+		c = strings.TrimSpace(c)
+		if !contains(available, c) {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", c, strings.Join(available, ", "))
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// exportValues picks the columns of row, a Repository or RepositorySnapshot
+// of repo or a CoinMarketSnapshot or CoinRank of its coin.
+func exportValues(repo Repository, row interface{}, columns []string) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		switch c {
+		case "coin_symbol":
+			values[i] = repo.Coin.Symbol
+		case "coin_name":
+			values[i] = repo.Coin.Name
+		case "owner":
+			values[i] = repo.Coin.Owner
+		case "repository":
+			values[i] = repo.Name
+		default:
+			values[i] = rowValue(row, c)
+		}
+	}
+	return values
+}
+
+// exportRepositories writes the current metrics of repos, or with history
+// every snapshot of them, oldest first.
+func exportRepositories(db *gorm.DB, e exporter, repos []Repository, columns []string, history bool) error {
+	if !history {
+		for _, repo := range repos {
+			if err := e.write(exportValues(repo, repo, columns)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Snapshots are read in batches so long histories are not loaded at once.
+	for _, repo := range repos {
+		var snapshots []RepositorySnapshot
+		err := db.Where("repository_id = ?", repo.Id).Order("captured_at").FindInBatches(&snapshots, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, s := range snapshots {
+				if err := e.write(exportValues(repo, s, columns)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportMarket writes the market snapshots of the coins of repos, oldest
+// first.
+func exportMarket(db *gorm.DB, e exporter, repos []Repository, columns []string) error {
+	seen := map[int]bool{}
+	for _, repo := range repos {
+		if seen[repo.CoinId] {
+			continue
+		}
+		seen[repo.CoinId] = true
+		var snapshots []CoinMarketSnapshot
+		if err := db.Where("coin_id = ?", repo.CoinId).Order("date").Find(&snapshots).Error; err != nil {
+			return err
+		}
+		for _, s := range snapshots {
+			if err := e.write(exportValues(repo, s, columns)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportRanks writes the ranks of the coins of repos in the last run that
+// ranked them, best first.
+func exportRanks(db *gorm.DB, e exporter, repos []Repository, columns []string) error {
+	var runId int
+	if err := db.Model(&CoinRank{}).Select("COALESCE(MAX(run_id), 0)").Scan(&runId).Error; err != nil {
+		return err
+	}
+	var ranks []CoinRank
+	if err := db.Where("run_id = ?", runId).Order("composite_rank, coin_id").Find(&ranks).Error; err != nil {
+		return err
+	}
+	repoOf := map[int]Repository{}
+	for _, repo := range repos {
+		if _, ok := repoOf[repo.CoinId]; !ok {
+			repoOf[repo.CoinId] = repo
+		}
+	}
+	for _, rank := range ranks {
+		repo, ok := repoOf[rank.CoinId]
+		if !ok {
+			continue
+		}
+		if err := e.write(exportValues(repo, rank, columns)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	addConfigFlag(fs)
+	format := fs.String("format", exportFormatCSV, "output format: csv or json")
+	out := fs.String("out", "", "file to write to, default stdout")
+	list := fs.String("columns", "", "comma separated columns to export, default all")
+	history := fs.Bool("history", false, "export every snapshot instead of the current metrics")
+	market := fs.Bool("market", false, "export the daily market snapshots of the coins instead")
+	ranks := fs.Bool("ranks", false, "export the ranks of the coins in the last run instead")
+	filter := repositoryFilter{IncludeMissing: true, IncludeInactive: true}
+	fs.StringVar(&filter.Coin, "coin", "", "only export the repositories of the coin with this symbol")
+	fs.StringVar(&filter.Repo, "repo", "", "only export this owner/name repository")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	if *format != exportFormatCSV && *format != exportFormatJSON {
+		fatal("Invalid format.", "format", *format)
+	}
+	if *history && *market || *history && *ranks || *market && *ranks {
+		fatal("Invalid flags, -history, -market and -ranks exclude each other.")
+	}
+	var model interface{} = Repository{}
+	coinColumns := exportCoinColumns
+	switch {
+	case *history:
+		model = RepositorySnapshot{}
+	case *market:
+		model, coinColumns = CoinMarketSnapshot{}, exportCoinColumns[:2]
+	case *ranks:
+		model, coinColumns = CoinRank{}, exportCoinColumns[:2]
+	}
+	available := append(append([]string{}, coinColumns...), rowColumns(model)...)
+	columns, err := selectColumns(*list, available)
+	if err != nil {
+		fatal("Invalid columns.", "error", err)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	repos, err := selectRepositories(db, filter, clock.Now())
+	if err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].Coin.Symbol != repos[j].Coin.Symbol {
+			return repos[i].Coin.Symbol < repos[j].Coin.Symbol
+		}
+		return repoName(repos[i]) < repoName(repos[j])
+	})
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fatal("Failed to create the file.", "path", *out, "error", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	e, err := newExporter(w, *format, columns)
+	if err != nil {
+		fatal("Failed to export.", "error", err)
+	}
+	switch {
+	case *market:
+		err = exportMarket(db, e, repos, columns)
+	case *ranks:
+		err = exportRanks(db, e, repos, columns)
+	default:
+		err = exportRepositories(db, e, repos, columns, *history)
+	}
+	if err != nil {
+		fatal("Failed to export.", "error", err)
+	}
+	if err := e.close(); err != nil {
+		fatal("Failed to export.", "path", *out, "error", err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+)
+
+// repositoryFilter narrows a collect run down to part of the repositories.
+// Zero values select everything.
+type repositoryFilter struct {
+	Coin       string
+	Repo       string
+	SinceStale time.Duration
+	// Missing repositories are skipped unless IncludeMissing is set, and
+	// inactive ones unless IncludeInactive is.
+	IncludeMissing  bool
+	IncludeInactive bool
+}
+
+func selectRepositories(db *gorm.DB, f repositoryFilter, now time.Time) ([]Repository, error) {
+	scope := db.Preload("Coin").Select("repositories.*").
+		Joins("JOIN coins ON coins.id = repositories.coin_id").
+		Where("coins.deleted_at IS NULL")
+
+	if f.Coin != "" {
+		coin, err := findCoin(db, f.Coin)
+		if err != nil {
+			return nil, err
+		}
+		scope = scope.Where("repositories.coin_id = ?", coin.Id)
+	}
+	if f.Repo != "" {
+		loc, err := parseRepositoryURL(f.Repo)
+		if err != nil {
+			return nil, err
+		}
+		scope = scope.Where("coins.owner = ? AND repositories.name = ?", loc.Owner, loc.Name)
+	}
+	if !f.IncludeMissing {
+		scope = scope.Where("repositories.status IS NULL OR repositories.status <> ?", statusMissing)
+	}
+	if !f.IncludeInactive {
+		scope = scope.Where("repositories.active = ? AND coins.active = ?", true, true)
+	}
+	if f.SinceStale > 0 {
+		scope = scope.Where("repositories.updated_at < ?", now.Add(-f.SinceStale))
+	}
+
+	var repos []Repository
+	err := scope.Find(&repos).Error
+	return repos, err
+}
+
+// apply drops the repositories the lists exclude. The repositories must
+// have their Coin loaded.
+func (c RepositoriesConfig) apply(repos []Repository) []Repository {
+	if len(c.Skip) == 0 && len(c.Allow) == 0 {
+		return repos
+	}
+	kept := repos[:0:0]
+	for _, repo := range repos {
+		if c.excludes(repo) {
+			slog.Debug("Skipped by the [Repositories] lists.", "coin_id", repo.CoinId, "repo", repoName(repo))
+			continue
+		}
+		kept = append(kept, repo)
+	}
+	if n := len(repos) - len(kept); n > 0 {
+		slog.Info("Skipped repositories by the [Repositories] lists.", "count", n)
+	}
+	return kept
+}
+
+func (c RepositoriesConfig) excludes(repo Repository) bool {
+	if matchesList(c.Skip, repo) {
+		return true
+	}
+	for _, p := range c.Allow {
+		if symbol, _ := splitListPattern(p); symbol == "" || strings.EqualFold(symbol, repo.Coin.Symbol) {
+			return !matchesList(c.Allow, repo)
+		}
+	}
+	return false
+}
+
+// matchesList reports whether a pattern of the list applying to the
+// repository's coin matches its owner/name.
+func matchesList(patterns []string, repo Repository) bool {
+	name := strings.ToLower(repoName(repo))
+	for _, p := range patterns {
+		symbol, glob := splitListPattern(p)
+		if symbol != "" && !strings.EqualFold(symbol, repo.Coin.Symbol) {
+			continue
+		}
+		if ok, _ := path.Match(strings.ToLower(glob), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitListPattern splits the coin symbol off a pattern, if it has one.
+func splitListPattern(p string) (symbol, glob string) {
+	symbol, glob, ok := strings.Cut(strings.TrimSpace(p), ":")
+	if !ok {
+		return "", symbol
+	}
+	return strings.TrimSpace(symbol), strings.TrimSpace(glob)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// cloneStats are the exact counts of a repository's default branch, or of
+// all its branches.
+type cloneStats struct {
+	CommitsCount      int
+	ContributorsCount int
+	DefaultBranch     string
+	// LastCommitAt is the commit date of the newest commit of the default
+	// branch.
+	LastCommitAt time.Time
+	// Nodes are the commits since the requested time, newest first.
+	Nodes []commitNode
+}
+
+// cloneRepository counts the history of the repository at url from a bare,
+// blobless clone, which fetches the commits and trees but no file contents.
+// With allBranches every branch is cloned and counted, otherwise only the
+// default one. The clone lives in a temporary directory removed before
+// returning.
+func cloneRepository(ctx context.Context, url string, since time.Time, allBranches bool) (cloneStats, error) {
+	dir, err := os.MkdirTemp("", "commit-count-collector-")
+	if err != nil {
+		return cloneStats{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	clone, revs := []string{"clone", "--quiet", "--bare", "--filter=blob:none"}, "HEAD"
+	if allBranches {
+		revs = "--all"
+	} else {
+		clone = append(clone, "--single-branch")
+	}
+	if err := runGit(ctx, "", append(clone, url, dir)...); err != nil {
+		return cloneStats{}, err
+	}
+
+	var stats cloneStats
+	// An empty repository has no commit to show.
+	if out, err := gitCommand(ctx, dir, "log", "-1", "--format=%cI", "HEAD").Output(); err == nil {
+		stats.LastCommitAt, _ = time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+	}
+	if out, err := gitCommand(ctx, dir, "symbolic-ref", "--short", "HEAD").Output(); err == nil {
+		stats.DefaultBranch = strings.TrimSpace(string(out))
+	}
+
+	cmd := gitCommand(ctx, dir, "log", "--format=%H%x00%cI%x00%aN%x00%aE%x00%P", revs)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return cloneStats{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return cloneStats{}, err
+	}
+
+	authors := map[string]bool{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+		stats.CommitsCount++
+		authors[strings.ToLower(fields[3])] = true
+
+		committed, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil || committed.Before(since) {
+			continue
+		}
+		var node commitNode
+		node.Oid = fields[0]
+		node.CommittedDate = committed
+		node.Author.Name = fields[2]
+		node.Author.Email = fields[3]
+		node.Parents.TotalCount = len(strings.Fields(fields[4]))
+		stats.Nodes = append(stats.Nodes, node)
+	}
+	if err := scanner.Err(); err != nil {
+		cmd.Wait()
+		return cloneStats{}, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return cloneStats{}, fmt.Errorf("git log: %w", err)
+	}
+	stats.ContributorsCount = len(authors)
+	return stats, nil
+}
+
+// gitCommand never prompts for credentials, so a private or missing
+// repository fails instead of hanging the run.
+func gitCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	return cmd
+}
+
+func runGit(ctx context.Context, dir string, args ...string) error {
+	if out, err := gitCommand(ctx, dir, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// giteaHosts are the hosts whose repository URLs are treated as Gitea or
+// Forgejo instances. The [Gitea] config adds to the built-in ones.
+var giteaHosts = []string{"codeberg.org", "gitea.com"}
+
+// giteaClient talks to the REST API of any Gitea or Forgejo instance, found
+// at the base URL stored with each repository. GITEA_TOKEN is optional and
+// sent to every instance.
+type giteaClient struct {
+	http  *http.Client
+	token string
+}
+
+type giteaRepository struct {
+	StarsCount      int      `json:"stars_count"`
+	ForksCount      int      `json:"forks_count"`
+	WatchersCount   int      `json:"watchers_count"`
+	OpenIssuesCount int      `json:"open_issues_count"`
+	OpenPRCount     int      `json:"open_pr_counter"`
+	ReleaseCount    int      `json:"release_counter"`
+	Archived        bool     `json:"archived"`
+	DefaultBranch   string   `json:"default_branch"`
+	Topics          []string `json:"topics"`
+	// Licenses are SPDX identifiers, detected by Gitea 1.23 and later.
+	Licenses []string `json:"licenses"`
+}
+
+func newGiteaClient(config GiteaConfig, h *httpClients) *giteaClient {
+	return &giteaClient{
+		http:  h.client("gitea"),
+		token: config.Token,
+	}
+}
+
+func isGiteaHost(host string) bool {
+	for _, h := range giteaHosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *giteaClient) get(ctx context.Context, baseURL, path string, query url.Values, v interface{}) (http.Header, error) {
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/api/v1" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("GET %s: %w", endpoint, errRepositoryMissing)
+	default:
+		return nil, fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+	if v != nil {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			return nil, err
+		}
+	}
+	return res.Header, nil
+}
+
+// count returns the X-Total-Count header of a listing endpoint fetched with
+// a single item per page.
+func (c *giteaClient) count(ctx context.Context, baseURL, path string, query url.Values) (int, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("limit", "1")
+
+	header, err := c.get(ctx, baseURL, path, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	total := header.Get("X-Total-Count")
+	if total == "" {
+		return 0, fmt.Errorf("GET %s: no X-Total-Count header", path)
+	}
+	return strconv.Atoi(total)
+}
+
+// releases reads the newest published releases.
+func (c *giteaClient) releases(ctx context.Context, baseURL, repo string) ([]release, error) {
+	var page []struct {
+		TagName     string    `json:"tag_name"`
+		PublishedAt time.Time `json:"published_at"`
+		Draft       bool      `json:"draft"`
+	}
+	query := url.Values{"limit": {strconv.Itoa(releasesPageSize)}, "draft": {"false"}}
+	if _, err := c.get(ctx, baseURL, repo+"/releases", query, &page); err != nil {
+		return nil, err
+	}
+
+	var releases []release
+	for _, r := range page {
+		if !r.Draft {
+			releases = append(releases, release{Tag: r.TagName, PublishedAt: r.PublishedAt})
+		}
+	}
+	return releases, nil
+}
+
+// turnarounds samples the most recently updated closed issues and merged pull
+// requests. Gitea lists merged pull requests among the closed ones.
+func (c *giteaClient) turnarounds(ctx context.Context, baseURL, repo string) (issues, pullRequests []turnaround, err error) {
+	var closedIssues []struct {
+		CreatedAt time.Time `json:"created_at"`
+		ClosedAt  time.Time `json:"closed_at"`
+	}
+	query := url.Values{"state": {"closed"}, "type": {"issues"}, "limit": {strconv.Itoa(turnaroundSampleSize)}}
+	if _, err := c.get(ctx, baseURL, repo+"/issues", query, &closedIssues); err != nil {
+		return nil, nil, err
+	}
+	for _, i := range closedIssues {
+		issues = append(issues, turnaround{Opened: i.CreatedAt, Closed: i.ClosedAt})
+	}
+
+	var closedPullRequests []struct {
+		CreatedAt time.Time `json:"created_at"`
+		Merged    bool      `json:"merged"`
+		MergedAt  time.Time `json:"merged_at"`
+	}
+	query = url.Values{"state": {"closed"}, "sort": {"recentupdate"}, "limit": {strconv.Itoa(turnaroundSampleSize)}}
+	if _, err := c.get(ctx, baseURL, repo+"/pulls", query, &closedPullRequests); err != nil {
+		return nil, nil, err
+	}
+	for _, p := range closedPullRequests {
+		if p.Merged {
+			pullRequests = append(pullRequests, turnaround{Opened: p.CreatedAt, Closed: p.MergedAt})
+		}
+	}
+	return issues, pullRequests, nil
+}
+
+// lastCommitDate is the commit date of the newest commit of the default
+// branch, zero for an empty repository.
+func (c *giteaClient) lastCommitDate(ctx context.Context, baseURL, repo string) (time.Time, error) {
+	var commits []struct {
+		Commit struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	query := url.Values{"limit": {"1"}, "stat": {"false"}}
+	if _, err := c.get(ctx, baseURL, repo+"/commits", query, &commits); err != nil {
+		return time.Time{}, err
+	}
+	if len(commits) == 0 {
+		return time.Time{}, nil
+	}
+	return commits[0].Commit.Committer.Date, nil
+}
+
+// commitsSince pages through the commits of the default branch. Gitea cannot
+// list the commits of all branches at once, so the all-branches option does
+// not apply to it.
+func (c *giteaClient) commitsSince(ctx context.Context, baseURL, repo string, since time.Time) ([]commitNode, error) {
+	var nodes []commitNode
+	query := url.Values{
+		"since": {since.UTC().Format(time.RFC3339)},
+		"limit": {"50"},
+		"stat":  {"false"},
+	}
+
+	for page := 1; ; page++ {
+		var commits []struct {
+			Commit struct {
+				Author struct {
+					Name  string    `json:"name"`
+					Email string    `json:"email"`
+					Date  time.Time `json:"date"`
+				} `json:"author"`
+				Committer struct {
+					Date time.Time `json:"date"`
+				} `json:"committer"`
+			} `json:"commit"`
+			Author *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			Parents []struct{} `json:"parents"`
+		}
+		query.Set("page", strconv.Itoa(page))
+		header, err := c.get(ctx, baseURL, repo+"/commits", query, &commits)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range commits {
+			// Instances older than Gitea 1.22 ignore since.
+			if commit.Commit.Committer.Date.Before(since) {
+				return nodes, nil
+			}
+			var node commitNode
+			node.CommittedDate = commit.Commit.Committer.Date
+			node.Author.Name = commit.Commit.Author.Name
+			node.Author.Email = commit.Commit.Author.Email
+			if commit.Author != nil {
+				node.Author.User.Login = commit.Author.Login
+			}
+			node.Parents.TotalCount = len(commit.Parents)
+			nodes = append(nodes, node)
+		}
+		if header.Get("X-HasMore") != "true" || len(commits) == 0 {
+			return nodes, nil
+		}
+	}
+}
+
+func (c *giteaClient) languages(ctx context.Context, baseURL, repo string) ([]RepositoryLanguage, error) {
+	var bytes map[string]int64
+	if _, err := c.get(ctx, baseURL, repo+"/languages", nil, &bytes); err != nil {
+		return nil, err
+	}
+	return languageBreakdown(bytes, 0), nil
+}
+
+// giteaLicense is the license of a repository, or NOASSERTION like GitHub
+// reports when there are several.
+func giteaLicense(licenses []string) string {
+	switch len(licenses) {
+	case 0:
+		return ""
+	case 1:
+		return licenses[0]
+	default:
+		return "NOASSERTION"
+	}
+}
+
+// giteaColumns are the columns collectGitea reads.
+var giteaColumns = []string{
+	"status", "language", "license", "default_branch", "last_commit_at", "updated_at",
+	"pull_requests_count", "open_pull_requests_count", "closed_pull_requests_count",
+	"watchers_count", "stargazers_count", "issues_count", "open_issues_count", "closed_issues_count",
+	"commits_count_for_the_last_week", "commits_count_for_the_last_month", "commits_count",
+	"forks_count", "releases_count", "tags_count",
+	"latest_release_tag", "latest_release_at", "releases_count_for_the_last90_days",
+	"median_issue_close_seconds", "median_pull_request_merge_seconds",
+}
+
+func collectGitea(ctx context.Context, client *giteaClient, coin Coin, repo Repository, now time.Time, commits commitSettings) (Repository, error) {
+	if repo.BaseURL == "" {
+		return Repository{}, fmt.Errorf("gitea repository %s has no base URL", repoName(repo))
+	}
+	base := repo.BaseURL
+	path := "/repos/" + url.PathEscape(coin.Owner) + "/" + url.PathEscape(repo.Name)
+
+	var r giteaRepository
+	if _, err := client.get(ctx, base, path, nil, &r); err != nil {
+		return Repository{}, apiError(err)
+	}
+	languages, err := client.languages(ctx, base, path)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	closedPullRequests, err := client.count(ctx, base, path+"/pulls", url.Values{"state": {"closed"}})
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	closedIssues, err := client.count(ctx, base, path+"/issues", url.Values{"state": {"closed"}, "type": {"issues"}})
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	commitsCount, err := client.count(ctx, base, path+"/commits", url.Values{"stat": {"false"}})
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	tags, err := client.count(ctx, base, path+"/tags", nil)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	releases, err := client.releases(ctx, base, path)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	issueTurnarounds, pullRequestTurnarounds, err := client.turnarounds(ctx, base, path)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	lastCommit, err := client.lastCommitDate(ctx, base, path)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	nodes, err := client.commitsSince(ctx, base, path, historySince(now, commits.Windows))
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	nodes = commits.filter(nodes)
+
+	status := statusActive
+	if r.Archived {
+		status = statusArchived
+	}
+
+	// Gitea does not tell merged pull requests apart from closed ones in
+	// its counts and has no contributors count, so MergedPullRequestsCount
+	// and ContributorsCount are left untouched.
+	return Repository{
+		Status:                      status,
+		Language:                    primaryLanguage(languages),
+		Languages:                   languages,
+		Topics:                      topicsOf(r.Topics),
+		License:                     giteaLicense(r.Licenses),
+		PullRequestsCount:           r.OpenPRCount + closedPullRequests,
+		OpenPullRequestsCount:       r.OpenPRCount,
+		ClosedPullRequestsCount:     closedPullRequests,
+		WatchersCount:               r.WatchersCount,
+		StargazersCount:             r.StarsCount,
+		IssuesCount:                 r.OpenIssuesCount + closedIssues,
+		OpenIssuesCount:             r.OpenIssuesCount,
+		ClosedIssuesCount:           closedIssues,
+		CommitsCountForTheLastWeek:  commitsCountForTheLastWeek(nodes, now),
+		CommitsCountForTheLastMonth: commitsCountForTheLastMonth(nodes, now),
+		CommitWindows:               commitWindowCounts(nodes, now, commits.Windows),
+		Contributors:                activeContributors(nodes, now),
+		AuthorDomains:               commits.authorDomains(nodes, now),
+		CommitsCount:                commitsCount,
+		ForksCount:                  r.ForksCount,
+		ReleasesCount:               r.ReleaseCount,
+		TagsCount:                   tags,
+		DefaultBranch:               r.DefaultBranch,
+		LastCommitAt:                optionalTime(lastCommit),
+		UpdatedAt:                   now,
+
+		LatestReleaseTag:              latestRelease(releases).tag(),
+		LatestReleaseAt:               latestRelease(releases).publishedAt(),
+		ReleasesCountForTheLast90Days: recentReleasesCount(releases, now),
+		MedianIssueCloseSeconds:       medianTurnaround(issueTurnarounds),
+		MedianPullRequestMergeSeconds: medianTurnaround(pullRequestTurnarounds),
+	}, nil
+}
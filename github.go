@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	githubAPIBaseURL = "https://api.github.com"
+	githubGraphQLURL = "https://api.github.com/graphql"
+)
+
+var lastPagePattern = regexp.MustCompile(`<([^>]+)>;\s*rel="last"`)
+
+// githubClient bundles the GraphQL client with the authenticated HTTP client
+// used for the REST endpoints that have no GraphQL equivalent.
+type githubClient struct {
+	graphQLClient
+	http    *http.Client
+	budget  *rateBudget
+	breaker *circuitBreaker
+	apiURL  string
+	webURL  string
+
+	// prefetched holds the repositories fetched by a batched query until
+	// their worker collects them.
+	mu         sync.Mutex
+	prefetched map[string]repositoryFields
+}
+
+// repositoryFields are the metrics read for a repository in one query,
+// either alone or aliased into a batch.
+type repositoryFields struct {
+	NameWithOwner string
+	IsArchived    bool
+	ForkCount     int
+	Releases      struct {
+		TotalCount int
+	}
+	LatestRelease struct {
+		TagName     string
+		PublishedAt time.Time
+	}
+	RecentReleases struct {
+		Nodes []struct {
+			TagName     string
+			PublishedAt time.Time
+		}
+	} `graphql:"recentReleases: releases(first: 50, orderBy: {field: CREATED_AT, direction: DESC})"`
+	Tags struct {
+		TotalCount int
+	} `graphql:"tags: refs(refPrefix: \"refs/tags/\")"`
+	OpenPullRequests struct {
+		TotalCount int
+	} `graphql:"openPullRequests: pullRequests(states: OPEN)"`
+	ClosedPullRequests struct {
+		TotalCount int
+	} `graphql:"closedPullRequests: pullRequests(states: CLOSED)"`
+	MergedPullRequests struct {
+		TotalCount int
+	} `graphql:"mergedPullRequests: pullRequests(states: MERGED)"`
+	Stargazers struct {
+		TotalCount int
+	}
+	Watchers struct {
+		TotalCount int
+	}
+	OpenIssues struct {
+		TotalCount int
+	} `graphql:"openIssues: issues(states: OPEN)"`
+	ClosedIssues struct {
+		TotalCount int
+	} `graphql:"closedIssues: issues(states: CLOSED)"`
+	RecentlyClosedIssues struct {
+		Nodes []struct {
+			CreatedAt time.Time
+			ClosedAt  time.Time
+		}
+	} `graphql:"recentlyClosedIssues: issues(states: CLOSED, first: 50, orderBy: {field: UPDATED_AT, direction: DESC})"`
+	RecentlyMergedPullRequests struct {
+		Nodes []struct {
+			CreatedAt time.Time
+			MergedAt  time.Time
+		}
+	} `graphql:"recentlyMergedPullRequests: pullRequests(states: MERGED, first: 50, orderBy: {field: UPDATED_AT, direction: DESC})"`
+	PrimaryLanguage struct {
+		Name string
+	}
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string
+			}
+		}
+	} `graphql:"repositoryTopics(first: 20)"`
+	LicenseInfo struct {
+		SpdxId string
+	}
+	Languages struct {
+		TotalSize int64
+		Edges     []struct {
+			Size int64
+			Node struct {
+				Name string
+			}
+		}
+	} `graphql:"languages(first: 10, orderBy: {field: SIZE, direction: DESC})"`
+	DefaultBranchRef struct {
+		Name   string
+		Target struct {
+			Commit struct {
+				CommittedDate time.Time
+				TotalHistory  struct {
+					TotalCount int
+				} `graphql:"totalHistory: history"`
+				History historyConnection `graphql:"history(since: $since, first: 100)"`
+			} `graphql:"... on Commit"`
+		}
+	}
+}
+
+// languages is the language breakdown, with shares of the size of all the
+// languages and not only of the ten read.
+func (r repositoryFields) languages() []RepositoryLanguage {
+	bytes := make(map[string]int64, len(r.Languages.Edges))
+	for _, e := range r.Languages.Edges {
+		bytes[e.Node.Name] = e.Size
+	}
+	return languageBreakdown(bytes, r.Languages.TotalSize)
+}
+
+func (r repositoryFields) topics() []RepositoryTopic {
+	names := make([]string, len(r.RepositoryTopics.Nodes))
+	for i, n := range r.RepositoryTopics.Nodes {
+		names[i] = n.Topic.Name
+	}
+	return topicsOf(names)
+}
+
+// latestRelease is nil when the repository has no published release.
+func (r repositoryFields) latestRelease() *release {
+	if r.LatestRelease.TagName == "" {
+		return nil
+	}
+	return &release{Tag: r.LatestRelease.TagName, PublishedAt: r.LatestRelease.PublishedAt}
+}
+
+func (r repositoryFields) recentReleases() []release {
+	releases := make([]release, len(r.RecentReleases.Nodes))
+	for i, n := range r.RecentReleases.Nodes {
+		releases[i] = release{Tag: n.TagName, PublishedAt: n.PublishedAt}
+	}
+	return releases
+}
+
+// issueTurnarounds and pullRequestTurnarounds sample the time to close issues
+// and to merge pull requests.
+func (r repositoryFields) issueTurnarounds() []turnaround {
+	samples := make([]turnaround, len(r.RecentlyClosedIssues.Nodes))
+	for i, n := range r.RecentlyClosedIssues.Nodes {
+		samples[i] = turnaround{Opened: n.CreatedAt, Closed: n.ClosedAt}
+	}
+	return samples
+}
+
+func (r repositoryFields) pullRequestTurnarounds() []turnaround {
+	samples := make([]turnaround, len(r.RecentlyMergedPullRequests.Nodes))
+	for i, n := range r.RecentlyMergedPullRequests.Nodes {
+		samples[i] = turnaround{Opened: n.CreatedAt, Closed: n.MergedAt}
+	}
+	return samples
+}
+
+type repositoryQuery struct {
+	Repository repositoryFields `graphql:"repository(owner: $owner, name: $name)"`
+	RateLimit  rateLimit
+}
+
+// historyQuery fetches the pages of the commit history after the first one.
+type historyQuery struct {
+	Repository struct {
+		DefaultBranchRef struct {
+			Target struct {
+				Commit struct {
+					History historyConnection `graphql:"history(since: $since, first: 100, after: $cursor)"`
+				} `graphql:"... on Commit"`
+			}
+		}
+	} `graphql:"repository(owner: $owner, name: $name)"`
+	RateLimit rateLimit
+}
+
+type historyConnection struct {
+	TotalCount int
+	Nodes      []commitNode
+	PageInfo   struct {
+		HasNextPage bool
+		EndCursor   githubv4.String
+	}
+}
+
+func newGitHubClient(config GitHubConfig, h *httpClients) *githubClient {
+	base := h.clientWith("github", &retryTransport{base: h.transport, maxAttempts: config.attempts()})
+	src, err := githubTokenSource(config, base)
+	if err != nil {
+		fatal("Invalid GitHub authentication config.", "error", err)
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+	httpClient := oauth2.NewClient(ctx, src)
+	// oauth2 keeps the transport of base but not its timeout.
+	httpClient.Timeout = base.Timeout
+
+	var graphql graphQLClient = githubv4.NewEnterpriseClient(config.graphQLURL(), httpClient)
+	if config.Cassette != "" {
+		graphql = &cassetteClient{next: graphql, dir: config.Cassette, mode: config.cassetteMode()}
+	}
+
+	return &githubClient{
+		graphQLClient: graphql,
+		http:          httpClient,
+		budget:        newRateBudget(config),
+		breaker:       newCircuitBreaker(config),
+		apiURL:        config.apiURL(),
+		webURL:        config.webURL(),
+
+		prefetched: map[string]repositoryFields{},
+	}
+}
+
+// contributorsCount asks the REST API for one contributor per page, so the
+// page number of the "last" link equals the number of contributors.
+func (c *githubClient) contributorsCount(ctx context.Context, owner, name string) (int, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/contributors?per_page=1&anonymous=true", c.apiURL, owner, name)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+
+	if m := lastPagePattern.FindStringSubmatch(res.Header.Get("Link")); m != nil {
+		last, err := url.Parse(m[1])
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(last.Query().Get("page"))
+	}
+
+	// A single page means there is no Link header at all.
+	var contributors []json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&contributors); err != nil {
+		return 0, err
+	}
+	return len(contributors), nil
+}
+
+// followHistory pages through the rest of a commit history connection and
+// returns every node, including the ones already in first.
+func (c *githubClient) followHistory(ctx context.Context, owner, name string, since time.Time, first historyConnection) ([]commitNode, error) {
+	nodes := first.Nodes
+	pageInfo := first.PageInfo
+
+	for pageInfo.HasNextPage {
+		var query historyQuery
+		variables := map[string]interface{}{
+			"owner":  githubv4.String(owner),
+			"name":   githubv4.String(name),
+			"since":  githubv4.GitTimestamp{Time: since},
+			"cursor": pageInfo.EndCursor,
+		}
+
+		if err := c.budget.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := c.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+		c.budget.update(query.RateLimit)
+
+		history := query.Repository.DefaultBranchRef.Target.Commit.History
+		nodes = append(nodes, history.Nodes...)
+		pageInfo = history.PageInfo
+	}
+	return nodes, nil
+}
+
+// fetchRepoStats returns the metrics of the repository at loc, from the
+// prefetched batch when there is one, and where the repository actually
+// lives. Every call works on its own query value, so it is safe to use from
+// concurrent workers.
+func (c *githubClient) fetchRepoStats(ctx context.Context, loc repositoryLocation, since time.Time) (repositoryFields, repositoryLocation, error) {
+	fields, ok := c.takePrefetched(loc)
+	if !ok {
+		if err := c.budget.wait(ctx); err != nil {
+			return repositoryFields{}, loc, err
+		}
+		var query repositoryQuery
+		err := c.queryRepository(ctx, &query, loc, since)
+		if isNotResolved(err) {
+			// A renamed or transferred repository still redirects on the REST API.
+			moved, rerr := c.resolveLocation(ctx, loc)
+			switch {
+			case errors.Is(rerr, errRepositoryMissing):
+				return repositoryFields{}, loc, rerr
+			case rerr == nil && moved.moved(loc):
+				loc = moved
+				err = c.queryRepository(ctx, &query, loc, since)
+			}
+		}
+		if err != nil {
+			return repositoryFields{}, loc, apiError(err)
+		}
+		c.budget.update(query.RateLimit)
+		fields = query.Repository
+	}
+
+	if found, err := parseRepositoryURL(fields.NameWithOwner); err == nil && found.moved(loc) {
+		loc = found
+	}
+	return fields, loc, nil
+}
+
+func (c *githubClient) queryRepository(ctx context.Context, query *repositoryQuery, loc repositoryLocation, since time.Time) error {
+	variables := map[string]interface{}{
+		"owner": githubv4.String(loc.Owner),
+		"name":  githubv4.String(loc.Name),
+		"since": githubv4.GitTimestamp{Time: since},
+	}
+	return c.Query(ctx, query, variables)
+}
+
+// isNotResolved reports whether a GraphQL error means the repository does
+// not exist under the requested owner and name.
+func isNotResolved(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Could not resolve to a Repository")
+}
+
+// resolveLocation follows the REST API redirect of a renamed or transferred
+// repository and returns where it lives now.
+func (c *githubClient) resolveLocation(ctx context.Context, loc repositoryLocation) (repositoryLocation, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s", c.apiURL, loc.Owner, loc.Name)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return loc, err
+	}
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return loc, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return loc, fmt.Errorf("GET %s: %w", endpoint, errRepositoryMissing)
+	default:
+		return loc, fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+	var body struct {
+		FullName string `json:"full_name"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return loc, err
+	}
+	return parseRepositoryURL(body.FullName)
+}
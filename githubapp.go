@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// appTokenSource exchanges a JWT signed with the GitHub App private key for
+// an installation access token. Wrapped in oauth2.ReuseTokenSource, a new
+// token is requested only once the current one is about to expire.
+type appTokenSource struct {
+	appId          int64
+	installationId int64
+	key            *rsa.PrivateKey
+	apiURL         string
+	http           *http.Client
+}
+
+// githubTokenSource authenticates as the GitHub App when AppID is set and
+// with the GITHUB_TOKEN personal access token otherwise.
+func githubTokenSource(config GitHubConfig, base *http.Client) (oauth2.TokenSource, error) {
+	if config.AppID == 0 {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: config.Token}), nil
+	}
+	if config.InstallationID == 0 {
+		return nil, errors.New("installationId is required with appId")
+	}
+
+	// The key can come from the environment or the secrets provider so it
+	// never has to be written to disk next to the config.
+	pemBytes := []byte(config.PrivateKey)
+	if len(pemBytes) == 0 {
+		if config.PrivateKeyPath == "" {
+			return nil, errors.New("privateKeyPath or GITHUB_APP_PRIVATE_KEY is required with appId")
+		}
+		var err error
+		if pemBytes, err = os.ReadFile(config.PrivateKeyPath); err != nil {
+			return nil, err
+		}
+	}
+	key, err := parseRSAPrivateKey(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{
+		appId:          config.AppID,
+		installationId: config.InstallationID,
+		key:            key,
+		apiURL:         config.apiURL(),
+		http:           base,
+	}), nil
+}
+
+func parseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// jwt builds the RS256 token that authenticates as the app itself. GitHub
+// accepts at most ten minutes of validity; the issue time is backdated to
+// absorb clock drift.
+func (s *appTokenSource) jwt(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.appId, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.apiURL, s.installationId)
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	res, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("POST %s: %s", endpoint, res.Status)
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	// Refresh a minute early so a token never expires mid-request.
+	return &oauth2.Token{AccessToken: body.Token, TokenType: "token", Expiry: body.ExpiresAt.Add(-time.Minute)}, nil
+}
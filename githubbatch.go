@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/shurcooL/githubv4"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// prefetch queries several repositories at once by aliasing one repository
+// field per repository in a query type built at runtime. The repositories
+// that resolve are kept for takePrefetched; the error of a partially failed
+// query, such as one missing repository, is returned as is.
+func (c *githubClient) prefetch(ctx context.Context, repos []Repository, since time.Time) error {
+	fields := make([]reflect.StructField, 0, len(repos)+1)
+	variables := map[string]interface{}{
+		"since": githubv4.GitTimestamp{Time: since},
+	}
+	for i, repo := range repos {
+		loc := locationOf(repo)
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Repository%d", i),
+			Type: reflect.TypeOf(repositoryFields{}),
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"repository%d: repository(owner: $owner%d, name: $name%d)"`, i, i, i)),
+		})
+		variables[fmt.Sprintf("owner%d", i)] = githubv4.String(loc.Owner)
+		variables[fmt.Sprintf("name%d", i)] = githubv4.String(loc.Name)
+	}
+	fields = append(fields, reflect.StructField{Name: "RateLimit", Type: reflect.TypeOf(rateLimit{})})
+
+	query := reflect.New(reflect.StructOf(fields))
+	err := c.Query(ctx, query.Interface(), variables)
+
+	result := query.Elem()
+	if limit := result.FieldByName("RateLimit").Interface().(rateLimit); !limit.ResetAt.IsZero() {
+		c.budget.update(limit)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, repo := range repos {
+		f := result.Field(i).Interface().(repositoryFields)
+		if f.NameWithOwner != "" {
+			c.prefetched[prefetchKey(locationOf(repo))] = f
+		}
+	}
+	return err
+}
+
+// takePrefetched returns and forgets the prefetched fields of a repository.
+func (c *githubClient) takePrefetched(loc repositoryLocation) (repositoryFields, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := prefetchKey(loc)
+	f, ok := c.prefetched[key]
+	delete(c.prefetched, key)
+	return f, ok
+}
+
+func prefetchKey(loc repositoryLocation) string {
+	return strings.ToLower(loc.Owner + "/" + loc.Name)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	gitlabAPIBaseURL = "https://gitlab.com/api/v4"
+
+	// gitlabCountLimit is the size of the listings past which GitLab leaves
+	// out the X-Total header.
+	gitlabCountLimit = 10000
+)
+
+// gitlabClient talks to the GitLab REST API. GITLAB_TOKEN is optional since
+// public projects can be read anonymously, but it raises the rate limit.
+type gitlabClient struct {
+	http  *http.Client
+	token string
+}
+
+type gitlabProject struct {
+	StarCount     int      `json:"star_count"`
+	ForksCount    int      `json:"forks_count"`
+	Archived      bool     `json:"archived"`
+	DefaultBranch string   `json:"default_branch"`
+	Topics        []string `json:"topics"`
+}
+
+func newGitLabClient(config GitLabConfig, h *httpClients) *gitlabClient {
+	return &gitlabClient{
+		http:  h.client("gitlab"),
+		token: config.Token,
+	}
+}
+
+func (c *gitlabClient) get(ctx context.Context, path string, query url.Values, v interface{}) (http.Header, error) {
+	endpoint := gitlabAPIBaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("GET %s: %w", endpoint, errRepositoryMissing)
+	default:
+		return nil, fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+	if v != nil {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			return nil, err
+		}
+	}
+	return res.Header, nil
+}
+
+// count returns the X-Total header of a listing endpoint fetched with a
+// single item per page.
+func (c *gitlabClient) count(ctx context.Context, path string, query url.Values) (int, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", "1")
+
+	var page []json.RawMessage
+	header, err := c.get(ctx, path, query, &page)
+	if err != nil {
+		return 0, err
+	}
+	return gitlabTotal(header, path, len(page))
+}
+
+// gitlabTotal reads the X-Total header of a listing whose first page had
+// listed items. GitLab leaves it out of listings of more than 10,000 items,
+// which are then counted as 10,000: a lower bound is better than failing the
+// whole collection.
+func gitlabTotal(header http.Header, path string, listed int) (int, error) {
+	if total := header.Get("X-Total"); total != "" {
+		n, err := strconv.Atoi(total)
+		if err != nil {
+			return 0, fmt.Errorf("GET %s: invalid X-Total header %q", path, total)
+		}
+		return n, nil
+	}
+	if header.Get("X-Next-Page") == "" {
+		return listed, nil
+	}
+	slog.Warn("GitLab did not count a listing of more than 10,000 items, counting it as 10,000.", "path", path)
+	return gitlabCountLimit, nil
+}
+
+// releases reads the newest releases and the total number of releases.
+// Upcoming releases, dated in the future, are left out.
+func (c *gitlabClient) releases(ctx context.Context, project string) ([]release, int, error) {
+	var page []struct {
+		TagName         string    `json:"tag_name"`
+		ReleasedAt      time.Time `json:"released_at"`
+		UpcomingRelease bool      `json:"upcoming_release"`
+	}
+	query := url.Values{"per_page": {strconv.Itoa(releasesPageSize)}, "order_by": {"released_at"}}
+	header, err := c.get(ctx, project+"/releases", query, &page)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := gitlabTotal(header, project+"/releases", len(page))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var releases []release
+	for _, r := range page {
+		if !r.UpcomingRelease {
+			releases = append(releases, release{Tag: r.TagName, PublishedAt: r.ReleasedAt})
+		}
+	}
+	return releases, total, nil
+}
+
+// turnarounds samples the most recently updated issues or merge requests in
+// state, which is "closed" or "merged".
+func (c *gitlabClient) turnarounds(ctx context.Context, path, state string) ([]turnaround, error) {
+	var page []struct {
+		CreatedAt time.Time `json:"created_at"`
+		ClosedAt  time.Time `json:"closed_at"`
+		MergedAt  time.Time `json:"merged_at"`
+	}
+	query := url.Values{
+		"state":    {state},
+		"order_by": {"updated_at"},
+		"per_page": {strconv.Itoa(turnaroundSampleSize)},
+	}
+	if _, err := c.get(ctx, path, query, &page); err != nil {
+		return nil, err
+	}
+
+	samples := make([]turnaround, len(page))
+	for i, p := range page {
+		samples[i] = turnaround{Opened: p.CreatedAt, Closed: p.ClosedAt}
+		if state == "merged" {
+			samples[i].Closed = p.MergedAt
+		}
+	}
+	return samples, nil
+}
+
+// lastCommitDate is the commit date of the newest commit of the default
+// branch, zero for an empty repository.
+func (c *gitlabClient) lastCommitDate(ctx context.Context, project string) (time.Time, error) {
+	var commits []struct {
+		CommittedDate time.Time `json:"committed_date"`
+	}
+	if _, err := c.get(ctx, project+"/repository/commits", url.Values{"per_page": {"1"}}, &commits); err != nil {
+		return time.Time{}, err
+	}
+	if len(commits) == 0 {
+		return time.Time{}, nil
+	}
+	return commits[0].CommittedDate, nil
+}
+
+// commitsSince pages through the commits of the default branch, or of every
+// branch when all is set. The dates keep the offset GitLab gives them, which
+// does not matter as the windows compare them as instants.
+func (c *gitlabClient) commitsSince(ctx context.Context, project string, since time.Time, all bool) ([]commitNode, error) {
+	var nodes []commitNode
+	query := url.Values{
+		"since":    {since.UTC().Format(time.RFC3339)},
+		"per_page": {"100"},
+	}
+	if all {
+		query.Set("all", "true")
+	}
+
+	for page := "1"; page != ""; {
+		var commits []struct {
+			Id            string    `json:"id"`
+			CommittedDate time.Time `json:"committed_date"`
+			AuthorName    string    `json:"author_name"`
+			AuthorEmail   string    `json:"author_email"`
+			ParentIds     []string  `json:"parent_ids"`
+		}
+		query.Set("page", page)
+		header, err := c.get(ctx, project+"/repository/commits", query, &commits)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range commits {
+			var node commitNode
+			node.Oid = commit.Id
+			node.CommittedDate = commit.CommittedDate
+			node.Author.Name = commit.AuthorName
+			node.Author.Email = commit.AuthorEmail
+			node.Parents.TotalCount = len(commit.ParentIds)
+			nodes = append(nodes, node)
+		}
+		page = header.Get("X-Next-Page")
+	}
+	return nodes, nil
+}
+
+// languages reads the language breakdown, which GitLab gives in percent.
+func (c *gitlabClient) languages(ctx context.Context, project string) ([]RepositoryLanguage, error) {
+	var shares map[string]float64
+	if _, err := c.get(ctx, project+"/languages", nil, &shares); err != nil {
+		return nil, err
+	}
+
+	languages := make([]RepositoryLanguage, 0, len(shares))
+	for name, share := range shares {
+		languages = append(languages, RepositoryLanguage{Language: name, Share: share})
+	}
+	return topLanguages(languages), nil
+}
+
+// gitlabColumns are the columns collectGitLab reads.
+var gitlabColumns = []string{
+	"status", "language", "default_branch", "last_commit_at", "updated_at",
+	"pull_requests_count", "open_pull_requests_count", "closed_pull_requests_count", "merged_pull_requests_count",
+	"stargazers_count", "issues_count", "open_issues_count", "closed_issues_count",
+	"commits_count_for_the_last_week", "commits_count_for_the_last_month", "commits_count", "contributors_count",
+	"forks_count", "releases_count", "tags_count",
+	"latest_release_tag", "latest_release_at", "releases_count_for_the_last90_days",
+	"median_issue_close_seconds", "median_pull_request_merge_seconds",
+}
+
+func collectGitLab(ctx context.Context, client *gitlabClient, coin Coin, repo Repository, now time.Time, commits commitSettings) (Repository, error) {
+	project := "/projects/" + url.PathEscape(coin.Owner+"/"+repo.Name)
+
+	var p gitlabProject
+	if _, err := client.get(ctx, project, nil, &p); err != nil {
+		return Repository{}, apiError(err)
+	}
+	languages, err := client.languages(ctx, project)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	mergeRequests := map[string]int{}
+	for _, state := range []string{"opened", "closed", "merged"} {
+		n, err := client.count(ctx, project+"/merge_requests", url.Values{"state": {state}})
+		if err != nil {
+			return Repository{}, apiError(err)
+		}
+		mergeRequests[state] = n
+	}
+	issues := map[string]int{}
+	for _, state := range []string{"opened", "closed"} {
+		n, err := client.count(ctx, project+"/issues", url.Values{"state": {state}})
+		if err != nil {
+			return Repository{}, apiError(err)
+		}
+		issues[state] = n
+	}
+	commitsCount, err := client.count(ctx, project+"/repository/commits", nil)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	contributors, err := client.count(ctx, project+"/repository/contributors", nil)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	releases, releasesCount, err := client.releases(ctx, project)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	tags, err := client.count(ctx, project+"/repository/tags", nil)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	closedIssues, err := client.turnarounds(ctx, project+"/issues", "closed")
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	mergedRequests, err := client.turnarounds(ctx, project+"/merge_requests", "merged")
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	lastCommit, err := client.lastCommitDate(ctx, project)
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	nodes, err := client.commitsSince(ctx, project, historySince(now, commits.Windows), commits.allBranches(repo))
+	if err != nil {
+		return Repository{}, apiError(err)
+	}
+	nodes = commits.filter(nodes)
+
+	status := statusActive
+	if p.Archived {
+		status = statusArchived
+	}
+
+	// GitLab has no watchers, so WatchersCount is left untouched. Its license
+	// keys are not SPDX identifiers, so License is too.
+	return Repository{
+		Status:                      status,
+		Language:                    primaryLanguage(languages),
+		Languages:                   languages,
+		Topics:                      topicsOf(p.Topics),
+		PullRequestsCount:           mergeRequests["opened"] + mergeRequests["closed"] + mergeRequests["merged"],
+		OpenPullRequestsCount:       mergeRequests["opened"],
+		ClosedPullRequestsCount:     mergeRequests["closed"],
+		MergedPullRequestsCount:     mergeRequests["merged"],
+		StargazersCount:             p.StarCount,
+		IssuesCount:                 issues["opened"] + issues["closed"],
+		OpenIssuesCount:             issues["opened"],
+		ClosedIssuesCount:           issues["closed"],
+		CommitsCountForTheLastWeek:  commitsCountForTheLastWeek(nodes, now),
+		CommitsCountForTheLastMonth: commitsCountForTheLastMonth(nodes, now),
+		CommitWindows:               commitWindowCounts(nodes, now, commits.Windows),
+		Contributors:                activeContributors(nodes, now),
+		AuthorDomains:               commits.authorDomains(nodes, now),
+		CommitsCount:                commitsCount,
+		ContributorsCount:           contributors,
+		ForksCount:                  p.ForksCount,
+		ReleasesCount:               releasesCount,
+		TagsCount:                   tags,
+		DefaultBranch:               p.DefaultBranch,
+		LastCommitAt:                optionalTime(lastCommit),
+		UpdatedAt:                   now,
+
+		LatestReleaseTag:              latestRelease(releases).tag(),
+		LatestReleaseAt:               latestRelease(releases).publishedAt(),
+		ReleasesCountForTheLast90Days: recentReleasesCount(releases, now),
+		MedianIssueCloseSeconds:       medianTurnaround(closedIssues),
+		MedianPullRequestMergeSeconds: medianTurnaround(mergedRequests),
+	}, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// grafanaQuery is the body Grafana posts to /grafana/query.
+	grafanaQuery struct {
+		Range struct {
+			From time.Time `json:"from"`
+			To   time.Time `json:"to"`
+		} `json:"range"`
+		Targets []struct {
+			Target string `json:"target"`
+		} `json:"targets"`
+		MaxDataPoints int `json:"maxDataPoints"`
+	}
+
+	// grafanaSeries is a time series answered to Grafana. Each datapoint is
+	// a value and its Unix time in milliseconds.
+	grafanaSeries struct {
+		Target     string       `json:"target"`
+		Datapoints [][2]float64 `json:"datapoints"`
+	}
+
+	grafanaPoint struct {
+		CapturedAt time.Time
+		Value      float64
+	}
+)
+
+// grafanaColumns are the snapshot columns that can be charted.
+func grafanaColumns() []string {
+	var columns []string
+	for _, f := range timeSeriesFields(RepositorySnapshot{}) {
+		columns = append(columns, f.name)
+	}
+	return columns
+}
+
+// grafana serves the Grafana JSON datasource protocol under /grafana/ over
+// the snapshots. A target is either "owner/name:column", the history of a
+// repository, or "SYMBOL:column", the sum over the repositories of a coin at
+// each run. Backfilled snapshots are left out, as they lack most columns.
+func (s *server) grafana(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, "/grafana") {
+	case "", "/":
+		// Grafana's "Save & test" only checks for a 200.
+		w.WriteHeader(http.StatusOK)
+	case "/search":
+		s.grafanaSearch(w, r)
+	case "/query":
+		s.grafanaQuery(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// grafanaSearch answers the targets containing the posted target, ignoring
+// case.
+func (s *server) grafanaSearch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Target string `json:"target"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && r.ContentLength != 0 {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	var coins []Coin
+	if err := s.db.WithContext(r.Context()).Preload("Repositories").Order("symbol").Find(&coins).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	var names []string
+	for _, coin := range coins {
+		names = append(names, coin.Symbol)
+		for _, repo := range coin.Repositories {
+			names = append(names, coin.Owner+"/"+repo.Name)
+		}
+	}
+
+	search := strings.ToLower(body.Target)
+	targets := []string{}
+	for _, name := range names {
+		for _, column := range grafanaColumns() {
+			if target := name + ":" + column; strings.Contains(strings.ToLower(target), search) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	sort.Strings(targets)
+	writeJSON(w, http.StatusOK, targets)
+}
+
+func (s *server) grafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var q grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	db := s.db.WithContext(r.Context())
+	series := []grafanaSeries{}
+	for _, t := range q.Targets {
+		points, err := grafanaPoints(db, t.Target, q.Range.From, q.Range.To)
+		if errors.Is(err, errBadParam("target")) || errors.Is(err, errNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown target %q", t.Target))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read the DB")
+			return
+		}
+		points = downsample(points, q.MaxDataPoints)
+		datapoints := make([][2]float64, len(points))
+		for i, p := range points {
+			datapoints[i] = [2]float64{p.Value, float64(p.CapturedAt.UnixMilli())}
+		}
+		series = append(series, grafanaSeries{Target: t.Target, Datapoints: datapoints})
+	}
+	writeJSON(w, http.StatusOK, series)
+}
+
+// grafanaPoints reads the values of target captured between from and to,
+// oldest first.
+func grafanaPoints(db *gorm.DB, target string, from, to time.Time) ([]grafanaPoint, error) {
+	name, column, ok := strings.Cut(target, ":")
+	// Only known columns are accepted, so column can go straight into the
+	// query.
+	if !ok || !contains(grafanaColumns(), column) {
+		return nil, errBadParam("target")
+	}
+
+	scope := db.Model(&RepositorySnapshot{}).
+		Where("repository_snapshots.captured_at BETWEEN ? AND ? AND repository_snapshots.backfilled = ?", from, to, false).
+		Order("repository_snapshots.captured_at")
+	if owner, repoName, isRepo := strings.Cut(name, "/"); isRepo {
+		var repo Repository
+		err := db.Joins("JOIN coins ON coins.id = repositories.coin_id").
+			Where("LOWER(coins.owner) = ? AND LOWER(repositories.name) = ?", strings.ToLower(owner), strings.ToLower(repoName)).
+			First(&repo).Error
+		if err != nil {
+			return nil, err
+		}
+		scope = scope.Select("repository_snapshots.captured_at, repository_snapshots."+column+" AS value").
+			Where("repository_snapshots.repository_id = ?", repo.Id)
+	} else {
+		coin, err := findCoin(db, name)
+		if err != nil {
+			return nil, err
+		}
+		scope = scope.Select("repository_snapshots.captured_at, SUM(repository_snapshots."+column+") AS value").
+			Joins("JOIN repositories ON repositories.id = repository_snapshots.repository_id").
+			Where("repositories.coin_id = ?", coin.Id).
+			Group("repository_snapshots.captured_at")
+	}
+
+	var points []grafanaPoint
+	err := scope.Scan(&points).Error
+	return points, err
+}
+
+// downsample keeps every nth point so no more than max are left. A max of
+// zero keeps them all.
+func downsample(points []grafanaPoint, max int) []grafanaPoint {
+	if max <= 0 || len(points) <= max {
+		return points
+	}
+	step := (len(points) + max - 1) / max
+	kept := make([]grafanaPoint, 0, max)
+	for i := 0; i < len(points); i += step {
+		kept = append(kept, points[i])
+	}
+	return kept
+}
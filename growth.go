@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	growthPeriodWeek  = "week"
+	growthPeriodMonth = "month"
+)
+
+// newGrowths compares freshly collected metrics with the latest snapshot
+// that is at least a week and a month old. It has to run before the
+// snapshot of the current run is written. Periods without such a snapshot
+// are skipped.
+func newGrowths(db *gorm.DB, repositoryId int, m Repository, now time.Time) ([]RepositoryGrowth, error) {
+	periods := []struct {
+		name    string
+		since   time.Time
+		commits func(RepositorySnapshot) int
+	}{
+		{growthPeriodWeek, now.AddDate(0, 0, -7), func(s RepositorySnapshot) int { return s.CommitsCountForTheLastWeek }},
+		{growthPeriodMonth, now.AddDate(0, -1, 0), func(s RepositorySnapshot) int { return s.CommitsCountForTheLastMonth }},
+	}
+	current := RepositorySnapshot{
+		CommitsCountForTheLastWeek:  m.CommitsCountForTheLastWeek,
+		CommitsCountForTheLastMonth: m.CommitsCountForTheLastMonth,
+	}
+
+	var growths []RepositoryGrowth
+	for _, p := range periods {
+		var base RepositorySnapshot
+		// Backfilled snapshots lack most of the compared columns.
+		err := db.Where("repository_id = ? AND captured_at <= ? AND backfilled = ?", repositoryId, p.since, false).
+			Order("captured_at DESC").
+			First(&base).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		commits, baseCommits := p.commits(current), p.commits(base)
+		growths = append(growths, RepositoryGrowth{
+			RepositoryId:       repositoryId,
+			Period:             p.name,
+			BaseCapturedAt:     base.CapturedAt,
+			StarsGained:        m.StargazersCount - base.StargazersCount,
+			StarsGrowth:        growthPercent(m.StargazersCount, base.StargazersCount),
+			CommitsDelta:       commits - baseCommits,
+			CommitsGrowth:      growthPercent(commits, baseCommits),
+			ContributorsDelta:  m.ContributorsCount - base.ContributorsCount,
+			ContributorsGrowth: growthPercent(m.ContributorsCount, base.ContributorsCount),
+			UpdatedAt:          now,
+		})
+	}
+	return growths, nil
+}
+
+// growthPercent is the change from base to v in percent, or nil when base
+// is zero and the change has no meaningful percentage.
+func growthPercent(v, base int) *float64 {
+	if base == 0 {
+		return nil
+	}
+	p := float64(v-base) / float64(base) * 100
+	return &p
+}
+
+// saveGrowths upserts the growth rows of a repository, one per period.
+func saveGrowths(db *gorm.DB, growths []RepositoryGrowth) error {
+	for _, g := range growths {
+		var existing RepositoryGrowth
+		err := db.Where("repository_id = ? AND period = ?", g.RepositoryId, g.Period).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		g.Id = existing.Id
+		if err := db.Save(&g).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
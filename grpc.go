@@ -0,0 +1,222 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative collectorpb/collector.proto
+
+import (
+	"context"
+	"errors"
+	"github.com/horizon67/commit-count-collector/collectorpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type grpcServer struct {
+	collectorpb.UnimplementedCollectorServer
+	db *gorm.DB
+}
+
+// serveGRPC serves the Collector service of collectorpb on addr.
+func serveGRPC(db *gorm.DB, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer()
+	collectorpb.RegisterCollectorServer(s, &grpcServer{db: db})
+
+	slog.Info("Listening for gRPC.", "addr", addr)
+	return s.Serve(lis)
+}
+
+// ListCoins pages through the coins by id. The page token is the offset of
+// the next page.
+func (s *grpcServer) ListCoins(ctx context.Context, req *collectorpb.ListCoinsRequest) (*collectorpb.ListCoinsResponse, error) {
+	size := int(req.GetPageSize())
+	if size < 0 || size > maxPerPage {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", maxPerPage)
+	}
+	if size == 0 {
+		size = defaultPerPage
+	}
+	offset := 0
+	if token := req.GetPageToken(); token != "" {
+		n, err := strconv.Atoi(token)
+		if err != nil || n < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		offset = n
+	}
+
+	db := s.db.WithContext(ctx)
+	var coins []Coin
+	// One more coin than asked tells whether there is a next page.
+	if err := db.Preload("Repositories").Order("id").Offset(offset).Limit(size + 1).Find(&coins).Error; err != nil {
+		return nil, status.Error(codes.Internal, "failed to read the DB")
+	}
+	res := &collectorpb.ListCoinsResponse{}
+	if len(coins) > size {
+		coins = coins[:size]
+		res.NextPageToken = strconv.Itoa(offset + size)
+	}
+
+	ids := make([]int, len(coins))
+	for i, coin := range coins {
+		ids[i] = coin.Id
+	}
+	var stats []CoinStat
+	if err := db.Where("coin_id IN ?", ids).Find(&stats).Error; err != nil {
+		return nil, status.Error(codes.Internal, "failed to read the DB")
+	}
+	statsByCoin := make(map[int]CoinStat, len(stats))
+	for _, st := range stats {
+		statsByCoin[st.CoinId] = st
+	}
+
+	for _, coin := range coins {
+		c := &collectorpb.Coin{Id: int32(coin.Id), Name: coin.Name, Symbol: coin.Symbol, Owner: coin.Owner}
+		for _, repo := range coin.Repositories {
+			c.RepositoryIds = append(c.RepositoryIds, int32(repo.Id))
+		}
+		if st, ok := statsByCoin[coin.Id]; ok {
+			c.Stats = &collectorpb.CoinStats{
+				RepositoriesCount:           int32(st.RepositoriesCount),
+				PullRequestsCount:           int32(st.PullRequestsCount),
+				WatchersCount:               int32(st.WatchersCount),
+				StargazersCount:             int32(st.StargazersCount),
+				IssuesCount:                 int32(st.IssuesCount),
+				CommitsCountForTheLastWeek:  int32(st.CommitsCountForTheLastWeek),
+				CommitsCountForTheLastMonth: int32(st.CommitsCountForTheLastMonth),
+				CommitsCount:                int32(st.CommitsCount),
+				ContributorsCount:           int32(st.ContributorsCount),
+				UpdatedAt:                   timestamppb.New(st.UpdatedAt),
+			}
+		}
+		res.Coins = append(res.Coins, c)
+	}
+	return res, nil
+}
+
+func (s *grpcServer) GetRepository(ctx context.Context, req *collectorpb.GetRepositoryRequest) (*collectorpb.Repository, error) {
+	scope := s.db.WithContext(ctx).Preload("Coin")
+	switch {
+	case req.GetId() != 0:
+		scope = scope.Where("repositories.id = ?", req.GetId())
+	case req.GetOwner() != "" && req.GetName() != "":
+		scope = scope.Joins("JOIN coins ON coins.id = repositories.coin_id").
+			Where("LOWER(coins.owner) = ? AND LOWER(repositories.name) = ?", strings.ToLower(req.GetOwner()), strings.ToLower(req.GetName()))
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or owner and name are required")
+	}
+
+	var repo Repository
+	err := scope.First(&repo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "repository not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to read the DB")
+	}
+	return repositoryMessage(repo), nil
+}
+
+// StreamSnapshots sends the snapshots as they are read, a batch at a time.
+func (s *grpcServer) StreamSnapshots(req *collectorpb.StreamSnapshotsRequest, stream collectorpb.Collector_StreamSnapshotsServer) error {
+	if req.GetRepositoryId() == 0 {
+		return status.Error(codes.InvalidArgument, "repository_id is required")
+	}
+	scope := s.db.WithContext(stream.Context()).Where("repository_id = ?", req.GetRepositoryId())
+	if req.GetSince() != nil {
+		scope = scope.Where("captured_at >= ?", req.GetSince().AsTime())
+	}
+	if req.GetUntil() != nil {
+		scope = scope.Where("captured_at < ?", req.GetUntil().AsTime())
+	}
+
+	var snapshots []RepositorySnapshot
+	err := scope.Order("captured_at, id").FindInBatches(&snapshots, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, snapshot := range snapshots {
+			if err := stream.Send(snapshotMessage(snapshot)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil && status.Code(err) == codes.Unknown {
+		return status.Error(codes.Internal, "failed to read the DB")
+	}
+	return err
+}
+
+func repositoryMessage(r Repository) *collectorpb.Repository {
+	loc := locationOf(r)
+	return &collectorpb.Repository{
+		Id:                          int32(r.Id),
+		CoinId:                      int32(r.CoinId),
+		Owner:                       loc.Owner,
+		Name:                        loc.Name,
+		Provider:                    loc.Provider,
+		Status:                      r.Status,
+		Language:                    r.Language,
+		License:                     r.License,
+		DefaultBranch:               r.DefaultBranch,
+		PullRequestsCount:           int32(r.PullRequestsCount),
+		OpenPullRequestsCount:       int32(r.OpenPullRequestsCount),
+		ClosedPullRequestsCount:     int32(r.ClosedPullRequestsCount),
+		MergedPullRequestsCount:     int32(r.MergedPullRequestsCount),
+		WatchersCount:               int32(r.WatchersCount),
+		StargazersCount:             int32(r.StargazersCount),
+		IssuesCount:                 int32(r.IssuesCount),
+		OpenIssuesCount:             int32(r.OpenIssuesCount),
+		ClosedIssuesCount:           int32(r.ClosedIssuesCount),
+		CommitsCountForTheLastWeek:  int32(r.CommitsCountForTheLastWeek),
+		CommitsCountForTheLastMonth: int32(r.CommitsCountForTheLastMonth),
+		CommitsCount:                int32(r.CommitsCount),
+		ContributorsCount:           int32(r.ContributorsCount),
+		ForksCount:                  int32(r.ForksCount),
+		ReleasesCount:               int32(r.ReleasesCount),
+		TagsCount:                   int32(r.TagsCount),
+		LastCommitAt:                timestampOf(r.LastCommitAt),
+		UpdatedAt:                   timestamppb.New(r.UpdatedAt),
+	}
+}
+
+func snapshotMessage(s RepositorySnapshot) *collectorpb.Snapshot {
+	return &collectorpb.Snapshot{
+		RepositoryId:                int32(s.RepositoryId),
+		RunId:                       int32(s.RunId),
+		CapturedAt:                  timestamppb.New(s.CapturedAt),
+		Backfilled:                  s.Backfilled,
+		PullRequestsCount:           int32(s.PullRequestsCount),
+		OpenPullRequestsCount:       int32(s.OpenPullRequestsCount),
+		ClosedPullRequestsCount:     int32(s.ClosedPullRequestsCount),
+		MergedPullRequestsCount:     int32(s.MergedPullRequestsCount),
+		WatchersCount:               int32(s.WatchersCount),
+		StargazersCount:             int32(s.StargazersCount),
+		IssuesCount:                 int32(s.IssuesCount),
+		OpenIssuesCount:             int32(s.OpenIssuesCount),
+		ClosedIssuesCount:           int32(s.ClosedIssuesCount),
+		CommitsCountForTheLastWeek:  int32(s.CommitsCountForTheLastWeek),
+		CommitsCountForTheLastMonth: int32(s.CommitsCountForTheLastMonth),
+		CommitsCount:                int32(s.CommitsCount),
+		ContributorsCount:           int32(s.ContributorsCount),
+		ForksCount:                  int32(s.ForksCount),
+		ReleasesCount:               int32(s.ReleasesCount),
+		TagsCount:                   int32(s.TagsCount),
+	}
+}
+
+// timestampOf leaves a nil t unset.
+func timestampOf(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	defaultUserAgent   = "commit-count-collector"
+)
+
+type (
+	// httpClients builds the clients of the provider APIs and of scraping on
+	// one transport, so they share its connections, proxy and User-Agent.
+	httpClients struct {
+		transport http.RoundTripper
+		timeout   time.Duration
+	}
+
+	// userAgentTransport sets the User-Agent of every request.
+	userAgentTransport struct {
+		base      http.RoundTripper
+		userAgent string
+	}
+)
+
+func newHTTPClients(config HTTPConfig) (*httpClients, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.Proxy != "" {
+		proxy, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &httpClients{
+		transport: &userAgentTransport{
+			base:      newPacingTransport(transport, config.Delay.Duration, config.Jitter.Duration),
+			userAgent: config.userAgent(),
+		},
+		timeout: config.timeout(),
+	}, nil
+}
+
+// client returns a client whose requests are traced as name.
+func (h *httpClients) client(name string) *http.Client {
+	return h.clientWith(name, h.transport)
+}
+
+// clientWith returns a client sending its requests through transport, which
+// should end in h.transport, traced as name.
+func (h *httpClients) clientWith(name string, transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Transport: &tracingTransport{base: transport, name: name},
+		Timeout:   h.timeout,
+	}
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it was given.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
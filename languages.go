@@ -0,0 +1,74 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// languagesLimit bounds how many languages are kept per repository, like the
+// languages(first: 10) GitHub connection.
+const languagesLimit = 10
+
+// languageBreakdown turns the bytes of code per language into the largest
+// languagesLimit languages, largest first, with their share of total bytes.
+func languageBreakdown(bytes map[string]int64, total int64) []RepositoryLanguage {
+	if total == 0 {
+		for _, b := range bytes {
+			total += b
+		}
+	}
+	languages := make([]RepositoryLanguage, 0, len(bytes))
+	for name, b := range bytes {
+		l := RepositoryLanguage{Language: name, Bytes: b}
+		if total > 0 {
+			l.Share = float64(b) * 100 / float64(total)
+		}
+		languages = append(languages, l)
+	}
+	return topLanguages(languages)
+}
+
+// topLanguages sorts languages by share, breaking ties by name so the order
+// is stable, and keeps the first languagesLimit.
+func topLanguages(languages []RepositoryLanguage) []RepositoryLanguage {
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Share != languages[j].Share {
+			return languages[i].Share > languages[j].Share
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	if len(languages) > languagesLimit {
+		languages = languages[:languagesLimit]
+	}
+	return languages
+}
+
+// primaryLanguage is the largest of languages sorted by topLanguages.
+func primaryLanguage(languages []RepositoryLanguage) string {
+	if len(languages) == 0 {
+		return ""
+	}
+	return languages[0].Language
+}
+
+// saveLanguages replaces the language breakdown of a repository. Collectors
+// that read no languages leave the stored breakdown alone.
+func saveLanguages(db *gorm.DB, repositoryId int, languages []RepositoryLanguage, now time.Time) error {
+	if len(languages) == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repositoryId).Delete(&RepositoryLanguage{}).Error; err != nil {
+			return err
+		}
+		for _, l := range languages {
+			l.RepositoryId = repositoryId
+			l.UpdatedAt = now
+			if err := tx.Create(&l).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
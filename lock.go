@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// collectLockName is the lock every collect and retry-failed run takes, so
+// two of them never write the same repositories at once.
+const collectLockName = "collect"
+
+// errLockHeld means another process holds an unexpired lease on the lock.
+var errLockHeld = errors.New("lock is held by another run")
+
+// runLock is a held lease on a row of run_locks.
+type runLock struct {
+	db    *gorm.DB
+	name  string
+	owner string
+	lease time.Duration
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// lockOwner identifies this process in run_locks.
+func lockOwner() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// acquireLock takes the lock called name for lease, or returns errLockHeld
+// along with the current holder. The lease is renewed in the background until
+// release is called.
+func acquireLock(db *gorm.DB, name string, lease time.Duration) (*runLock, error) {
+	now := time.Now()
+	l := &runLock{db: db, name: name, owner: lockOwner(), lease: lease}
+	row := RunLock{Name: name, Owner: l.owner, AcquiredAt: now, ExpiresAt: now.Add(lease)}
+
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// Take over a lease its holder let expire.
+		res = db.Model(&RunLock{}).Where("name = ? AND expires_at < ?", name, now).
+			Updates(map[string]interface{}{"owner": l.owner, "acquired_at": now, "expires_at": now.Add(lease)})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			var held RunLock
+			if err := db.Where("name = ?", name).First(&held).Error; err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %s until %s", errLockHeld, held.Owner, held.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+
+	l.stop, l.done = make(chan struct{}), make(chan struct{})
+	go l.renew()
+	return l, nil
+}
+
+// renew extends the lease every third of its length.
+func (l *runLock) renew() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			res := l.db.Model(&RunLock{}).Where("name = ? AND owner = ?", l.name, l.owner).
+				Update("expires_at", time.Now().Add(l.lease))
+			if res.Error != nil {
+				slog.Error("Failed to renew the run lock.", "lock", l.name, "error", res.Error)
+			} else if res.RowsAffected == 0 {
+				slog.Error("Lost the run lock, another run may be writing too.", "lock", l.name)
+			}
+		}
+	}
+}
+
+func (l *runLock) release() error {
+	close(l.stop)
+	<-l.done
+	return l.db.Where("name = ? AND owner = ?", l.name, l.owner).Delete(&RunLock{}).Error
+}
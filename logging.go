@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const logFile = "batch.log"
+
+// logOptions holds the --log-format and --log-level flags shared by the
+// long-running subcommands.
+type logOptions struct {
+	format *string
+	level  *string
+}
+
+func addLogFlags(fs *flag.FlagSet) *logOptions {
+	return &logOptions{
+		format: fs.String("log-format", "text", "log output format: json or text"),
+		level:  fs.String("log-level", "info", "minimum log level: debug, info, warn or error"),
+	}
+}
+
+// loggingSettings sends logs to stdout and batch.log in the requested format.
+func loggingSettings(opts *logOptions) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*opts.level)); err != nil {
+		fatal("Invalid log level.", "log_level", *opts.level)
+	}
+
+	logfile, err := os.OpenFile(logFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		fatal("Failed to open the log file.", "error", err)
+	}
+	multiLogFile := io.MultiWriter(os.Stdout, logfile)
+	handlerOptions := &slog.HandlerOptions{AddSource: true, Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(*opts.format) {
+	case "json":
+		handler = slog.NewJSONHandler(multiLogFile, handlerOptions)
+	case "text":
+		handler = slog.NewTextHandler(multiLogFile, handlerOptions)
+	default:
+		fatal("Invalid log format.", "log_format", *opts.format)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// fatal logs at error level and exits, like log.Fatal.
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// repoName is the owner/name pair used in the repo log field.
+func repoName(repo Repository) string {
+	return fmt.Sprintf("%s/%s", repo.Coin.Owner, repo.Name)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	priceProviderCoinGecko = "coingecko"
+
+	// coingeckoPriceBatch is how many ids one simple/price request asks for.
+	coingeckoPriceBatch = 100
+)
+
+type (
+	// priceProvider reads the market data of coins, keyed by coin id. Coins
+	// the provider does not know are left out.
+	priceProvider interface {
+		Name() string
+		Prices(ctx context.Context, coins []Coin) (map[int]coinPrice, error)
+	}
+
+	// coinPrice is in USD.
+	coinPrice struct {
+		Price     float64
+		MarketCap float64
+		Volume    float64
+	}
+
+	// coingeckoPrices reads the prices of the coins enrich found on
+	// CoinGecko.
+	coingeckoPrices struct {
+		client *coingeckoClient
+	}
+)
+
+// priceProviders builds the provider [Prices] names.
+var priceProviders = map[string]func(config Config, h *httpClients) priceProvider{
+	priceProviderCoinGecko: func(config Config, h *httpClients) priceProvider {
+		return coingeckoPrices{client: newCoinGeckoClient(config.CoinGecko, h)}
+	},
+}
+
+func (p coingeckoPrices) Name() string { return priceProviderCoinGecko }
+
+func (p coingeckoPrices) Prices(ctx context.Context, coins []Coin) (map[int]coinPrice, error) {
+	byId := map[string][]int{}
+	var ids []string
+	for _, coin := range coins {
+		if coin.CoingeckoId == "" {
+			slog.Debug("No CoinGecko id, run enrich to find it.", "coin_id", coin.Id, "symbol", coin.Symbol)
+			continue
+		}
+		if _, ok := byId[coin.CoingeckoId]; !ok {
+			ids = append(ids, coin.CoingeckoId)
+		}
+		byId[coin.CoingeckoId] = append(byId[coin.CoingeckoId], coin.Id)
+	}
+
+	prices := map[int]coinPrice{}
+	for start := 0; start < len(ids); start += coingeckoPriceBatch {
+		end := min(start+coingeckoPriceBatch, len(ids))
+		var body map[string]struct {
+			USD          float64 `json:"usd"`
+			USDMarketCap float64 `json:"usd_market_cap"`
+			USD24hVol    float64 `json:"usd_24h_vol"`
+		}
+		query := url.Values{
+			"ids":                {strings.Join(ids[start:end], ",")},
+			"vs_currencies":      {"usd"},
+			"include_market_cap": {"true"},
+			"include_24hr_vol":   {"true"},
+		}
+		if err := p.client.get(ctx, "/simple/price", query, &body); err != nil {
+			return nil, err
+		}
+		for id, v := range body {
+			for _, coinId := range byId[id] {
+				prices[coinId] = coinPrice{Price: v.USD, MarketCap: v.USDMarketCap, Volume: v.USD24hVol}
+			}
+		}
+	}
+	return prices, nil
+}
+
+// newPriceProvider returns nil when [Prices] has no provider.
+func newPriceProvider(config Config, h *httpClients) (priceProvider, error) {
+	if config.Prices.Provider == "" {
+		return nil, nil
+	}
+	newProvider, ok := priceProviders[config.Prices.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown price provider %q", config.Prices.Provider)
+	}
+	return newProvider(config, h), nil
+}
+
+// priceProviderNames lists the accepted [Prices] providers.
+func priceProviderNames() []string {
+	var names []string
+	for name := range priceProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// recordMarketSnapshots writes today's market snapshot of every active coin
+// the provider has a price for, with the development activity of its
+// refreshed CoinStat.
+func recordMarketSnapshots(ctx context.Context, db *gorm.DB, provider priceProvider, now time.Time) (int, error) {
+	var coins []Coin
+	if err := db.Where("active = ?", true).Order("id").Find(&coins).Error; err != nil {
+		return 0, err
+	}
+	prices, err := provider.Prices(ctx, coins)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", provider.Name(), err)
+	}
+	if len(prices) == 0 {
+		return 0, nil
+	}
+	var stats []CoinStat
+	if err := db.Find(&stats).Error; err != nil {
+		return 0, err
+	}
+	statOf := make(map[int]CoinStat, len(stats))
+	for _, s := range stats {
+		statOf[s.CoinId] = s
+	}
+
+	date := now.UTC().Truncate(24 * time.Hour)
+	var snapshots []CoinMarketSnapshot
+	for _, coin := range coins {
+		price, ok := prices[coin.Id]
+		if !ok {
+			continue
+		}
+		stat := statOf[coin.Id]
+		snapshots = append(snapshots, CoinMarketSnapshot{
+			CoinId:                      coin.Id,
+			Date:                        date,
+			Provider:                    provider.Name(),
+			PriceUSD:                    price.Price,
+			MarketCapUSD:                price.MarketCap,
+			VolumeUSD:                   price.Volume,
+			CommitsCountForTheLastWeek:  stat.CommitsCountForTheLastWeek,
+			CommitsCountForTheLastMonth: stat.CommitsCountForTheLastMonth,
+			ContributorsCount:           stat.ContributorsCount,
+			StargazersCount:             stat.StargazersCount,
+			CapturedAt:                  now,
+		})
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "coin_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "price_usd", "market_cap_usd", "volume_usd", "commits_count_for_the_last_week", "commits_count_for_the_last_month", "contributors_count", "stargazers_count", "captured_at"}),
+	}).Create(&snapshots).Error
+	return len(snapshots), err
+}
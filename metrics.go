@@ -0,0 +1,114 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const metricsNamespace = "commit_count_collector"
+
+// runMetrics are the Prometheus metrics of a collect run. They live in their
+// own registry so the same set can be scraped and pushed.
+type runMetrics struct {
+	registry           *prometheus.Registry
+	processed          *prometheus.CounterVec
+	errors             *prometheus.CounterVec
+	repositoryDuration prometheus.Histogram
+	runDuration        prometheus.Histogram
+	rateLimitRemaining prometheus.Gauge
+	lastSuccess        prometheus.Gauge
+}
+
+func newRunMetrics() *runMetrics {
+	m := &runMetrics{
+		registry: prometheus.NewRegistry(),
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "repositories_processed_total",
+			Help:      "Repositories processed, by outcome.",
+		}, []string{"status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "errors_total",
+			Help:      "Collection errors, by kind (api, scrape, clone, missing, panic, other).",
+		}, []string{"kind"}),
+		repositoryDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "repository_duration_seconds",
+			Help:      "Time spent collecting a single repository.",
+			Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10),
+		}),
+		runDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "run_duration_seconds",
+			Help:      "Duration of a whole collect run.",
+			Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+		}),
+		rateLimitRemaining: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "github_rate_limit_remaining",
+			Help:      "GraphQL rate limit points left after the last query.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time of the last run that finished without being interrupted.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.processed, m.errors, m.repositoryDuration, m.runDuration,
+		m.rateLimitRemaining, m.lastSuccess,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// observe records the outcome of one repository.
+func (m *runMetrics) observe(r result) {
+	m.repositoryDuration.Observe(r.Duration.Seconds())
+	if r.Err != nil {
+		m.processed.WithLabelValues("error").Inc()
+		m.errors.WithLabelValues(errorKind(r.Err)).Inc()
+		return
+	}
+	if r.Unchanged {
+		m.processed.WithLabelValues("unchanged").Inc()
+		return
+	}
+	m.processed.WithLabelValues("ok").Inc()
+}
+
+func (m *runMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// listen serves /metrics on addr for the duration of the run.
+func (m *runMetrics) listen(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("The metrics endpoint stopped.", "error", err)
+		}
+	}()
+}
+
+// finish records the run duration and pushes everything to the Pushgateway
+// when url is set.
+func (m *runMetrics) finish(start time.Time, interrupted bool, url string) {
+	m.runDuration.Observe(time.Since(start).Seconds())
+	if !interrupted {
+		m.lastSuccess.SetToCurrentTime()
+	}
+	if url == "" {
+		return
+	}
+	if err := push.New(url, metricsNamespace).Gatherer(m.registry).Push(); err != nil {
+		slog.Error("Failed to push metrics.", "pushgateway", url, "error", err)
+	}
+}
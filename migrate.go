@@ -0,0 +1,615 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"gorm.io/gorm"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	// migration is one step of the schema history. Down must undo exactly
+	// what Up did so that `migrate -rollback` can walk the history back.
+	migration struct {
+		Id   string
+		Up   func(db *gorm.DB) error
+		Down func(db *gorm.DB) error
+	}
+
+	SchemaMigration struct {
+		Id        string `gorm:"primaryKey"`
+		AppliedAt time.Time
+	}
+)
+
+// migrations must only ever be appended to. The tables a later migration
+// changes are migrated with the structs of schema.go, not the models.
+var migrations = []migration{
+	{
+		Id: "001_create_coins_and_repositories",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&coin001{}, &repository001{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &Repository{}, "coin_id", "coins(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&Repository{}, &Coin{})
+		},
+	},
+	{
+		Id: "002_create_repository_snapshots",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&repositorySnapshot002{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositorySnapshot{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositorySnapshot{})
+		},
+	},
+	{
+		Id: "003_create_coin_stats",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&CoinStat{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &CoinStat{}, "coin_id", "coins(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&CoinStat{})
+		},
+	},
+	{
+		Id: "004_create_runs",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&run004{}, &repositorySnapshot004{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropIndex(&RepositorySnapshot{}, "idx_repository_snapshots_run_id"); err != nil {
+				return err
+			}
+			if err := dropColumn(db, &RepositorySnapshot{}, "run_id"); err != nil {
+				return err
+			}
+			return db.Migrator().DropTable(&Run{})
+		},
+	},
+	{
+		Id: "005_create_collection_errors",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&CollectionError{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &CollectionError{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&CollectionError{})
+		},
+	},
+	{
+		Id: "006_add_repositories_status",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository006{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropIndex(&Repository{}, "idx_repositories_status"); err != nil {
+				return err
+			}
+			return dropColumn(db, &Repository{}, "status")
+		},
+	},
+	{
+		Id: "007_add_forks_releases_and_tags_counts",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository007{}, &repositorySnapshot007{})
+		},
+		Down: func(db *gorm.DB) error {
+			for _, model := range []interface{}{&Repository{}, &RepositorySnapshot{}} {
+				for _, column := range []string{"forks_count", "releases_count", "tags_count"} {
+					if err := dropColumn(db, model, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Id: "008_add_issue_and_pull_request_states",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository008{}, &repositorySnapshot008{})
+		},
+		Down: func(db *gorm.DB) error {
+			columns := []string{
+				"open_pull_requests_count", "closed_pull_requests_count", "merged_pull_requests_count",
+				"open_issues_count", "closed_issues_count",
+			}
+			for _, model := range []interface{}{&Repository{}, &RepositorySnapshot{}} {
+				for _, column := range columns {
+					if err := dropColumn(db, model, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Id: "009_create_repository_commit_windows",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&RepositoryCommitWindow{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryCommitWindow{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositoryCommitWindow{})
+		},
+	},
+	{
+		Id: "010_create_repository_contributors",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&RepositoryContributor{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryContributor{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositoryContributor{})
+		},
+	},
+	{
+		Id: "011_create_repository_code_frequencies",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&RepositoryCodeFrequency{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryCodeFrequency{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositoryCodeFrequency{})
+		},
+	},
+	{
+		Id: "012_create_coin_scores",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&CoinScore{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &CoinScore{}, "coin_id", "coins(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&CoinScore{})
+		},
+	},
+	{
+		Id: "013_create_repository_growths",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&RepositoryGrowth{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryGrowth{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositoryGrowth{})
+		},
+	},
+	{
+		Id: "014_create_run_locks",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&RunLock{})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RunLock{})
+		},
+	},
+	{
+		Id: "015_create_run_repositories",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&RunRepository{})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RunRepository{})
+		},
+	},
+	{
+		Id: "016_add_repositories_base_url",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository016{})
+		},
+		Down: func(db *gorm.DB) error {
+			return dropColumn(db, &Repository{}, "base_url")
+		},
+	},
+	{
+		Id: "017_add_repositories_all_branches",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository017{})
+		},
+		Down: func(db *gorm.DB) error {
+			return dropColumn(db, &Repository{}, "all_branches")
+		},
+	},
+	{
+		Id: "018_add_repositories_default_branch",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository018{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := dropColumn(db, &Repository{}, "default_branch"); err != nil {
+				return err
+			}
+			return dropColumn(db, &Repository{}, "last_commit_at")
+		},
+	},
+	{
+		Id: "019_create_repository_languages",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&RepositoryLanguage{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryLanguage{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositoryLanguage{})
+		},
+	},
+	{
+		Id: "020_create_repository_topics",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&repository020{}, &RepositoryTopic{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryTopic{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropTable(&RepositoryTopic{}); err != nil {
+				return err
+			}
+			return dropColumn(db, &Repository{}, "license")
+		},
+	},
+	{
+		Id: "021_add_repositories_latest_release",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository021{})
+		},
+		Down: func(db *gorm.DB) error {
+			for _, column := range []string{"LatestReleaseTag", "LatestReleaseAt", "ReleasesCountForTheLast90Days"} {
+				if err := dropColumn(db, &Repository{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Id: "022_add_repositories_median_turnaround",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository022{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := dropColumn(db, &Repository{}, "median_issue_close_seconds"); err != nil {
+				return err
+			}
+			return dropColumn(db, &Repository{}, "median_pull_request_merge_seconds")
+		},
+	},
+	{
+		Id: "023_add_repository_snapshots_backfilled",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repositorySnapshot023{})
+		},
+		Down: func(db *gorm.DB) error {
+			return dropColumn(db, &RepositorySnapshot{}, "backfilled")
+		},
+	},
+	{
+		Id: "024_add_repositories_validators",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository024{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := dropColumn(db, &Repository{}, "last_modified"); err != nil {
+				return err
+			}
+			return dropColumn(db, &Repository{}, "etag")
+		},
+	},
+	{
+		Id: "025_add_repositories_last_run_id",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository025{})
+		},
+		Down: func(db *gorm.DB) error {
+			return dropColumn(db, &Repository{}, "last_run_id")
+		},
+	},
+	{
+		Id: "026_add_active_and_deleted_at",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&coin026{}, &repository026{})
+		},
+		Down: func(db *gorm.DB) error {
+			for table, model := range map[string]interface{}{"coins": &Coin{}, "repositories": &Repository{}} {
+				if err := db.Migrator().DropIndex(model, "idx_"+table+"_deleted_at"); err != nil {
+					return err
+				}
+				if err := dropColumn(db, model, "deleted_at"); err != nil {
+					return err
+				}
+				if err := dropColumn(db, model, "active"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// A repository has no owner of its own but its coin's, one per
+		// coin, so (coin_id, name) is unique per (coin_id, owner, name).
+		// Rows that break it must be removed first.
+		Id: "027_add_coins_and_repositories_indexes",
+		Up: func(db *gorm.DB) error {
+			dups, err := duplicateRepositories(db)
+			if err != nil {
+				return err
+			}
+			if len(dups) > 0 {
+				return fmt.Errorf("duplicate repositories, delete all but one row of each: %s", strings.Join(dups, ", "))
+			}
+			return db.AutoMigrate(&coin027{}, &repository027{})
+		},
+		Down: func(db *gorm.DB) error {
+			for _, index := range []string{"idx_repositories_coin_id_name", "idx_repositories_updated_at"} {
+				if err := db.Migrator().DropIndex(&Repository{}, index); err != nil {
+					return err
+				}
+			}
+			return db.Migrator().DropIndex(&Coin{}, "idx_coins_symbol")
+		},
+	},
+	{
+		Id: "028_add_coins_coingecko",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&coin028{})
+		},
+		Down: func(db *gorm.DB) error {
+			for _, column := range []string{"coingecko_id", "market_cap_rank", "homepage"} {
+				if err := dropColumn(db, &Coin{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Id: "029_create_coin_market_snapshots",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&CoinMarketSnapshot{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &CoinMarketSnapshot{}, "coin_id", "coins(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&CoinMarketSnapshot{})
+		},
+	},
+	{
+		Id: "030_create_coin_ranks",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&CoinRank{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &CoinRank{}, "coin_id", "coins(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&CoinRank{})
+		},
+	},
+	{
+		Id: "031_create_anomalies",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&Anomaly{}, &run031{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &Anomaly{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropTable(&Anomaly{}); err != nil {
+				return err
+			}
+			return dropColumn(db, &Run{}, "anomalies")
+		},
+	},
+	{
+		Id: "032_add_repositories_signed_commits_percentage",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository032{})
+		},
+		Down: func(db *gorm.DB) error {
+			return dropColumn(db, &Repository{}, "signed_commits_percentage")
+		},
+	},
+	{
+		Id: "033_add_repositories_security_posture",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&repository033{})
+		},
+		Down: func(db *gorm.DB) error {
+			for _, column := range []string{"has_security_policy", "default_branch_protected", "dependabot_configured"} {
+				if err := dropColumn(db, &Repository{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Id: "034_create_repository_author_domains",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&RepositoryAuthorDomain{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryAuthorDomain{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositoryAuthorDomain{})
+		},
+	},
+	{
+		Id: "035_create_repository_top_contributors",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&RepositoryTopContributor{}); err != nil {
+				return err
+			}
+			return addForeignKey(db, &RepositoryTopContributor{}, "repository_id", "repositories(id)")
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&RepositoryTopContributor{})
+		},
+	},
+}
+
+// duplicateRepositories lists the repositories added more than once to the
+// same coin, removed ones included, as "coin_id/name".
+func duplicateRepositories(db *gorm.DB) ([]string, error) {
+	var rows []struct {
+		CoinId int
+		Name   string
+	}
+	err := db.Unscoped().Model(&Repository{}).Select("coin_id, name").
+		Group("coin_id, name").Having("COUNT(*) > 1").Order("coin_id, name").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	dups := make([]string, len(rows))
+	for i, row := range rows {
+		dups[i] = fmt.Sprintf("%d/%s", row.CoinId, row.Name)
+	}
+	return dups, nil
+}
+
+// addForeignKey is a no-op on SQLite, which cannot add constraints to an
+// existing table. The constraint is named like gorm v1 named it, so schemas
+// created before the gorm v2 port keep matching.
+func addForeignKey(db *gorm.DB, model interface{}, field, dest string) error {
+	if db.Dialector.Name() == "sqlite" {
+		return nil
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+	name := foreignKeyName(db, table, field, dest)
+	return db.Exec(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s ON DELETE CASCADE ON UPDATE CASCADE",
+		db.Statement.Quote(table), db.Statement.Quote(name), db.Statement.Quote(field), dest)).Error
+}
+
+var keyNamePattern = regexp.MustCompile("[^a-zA-Z0-9]+")
+
+// foreignKeyName replicates gorm v1, including the hashed form it used for
+// names over the 64 characters MySQL allows.
+func foreignKeyName(db *gorm.DB, table, field, dest string) string {
+	name := keyNamePattern.ReplaceAllString(fmt.Sprintf("%s_%s_%s_foreign", table, field, dest), "_")
+	if db.Dialector.Name() != driverMySQL || utf8.RuneCountInString(name) <= 64 {
+		return name
+	}
+	prefix := []rune(keyNamePattern.ReplaceAllString(dest, "_"))
+	if len(prefix) > 24 {
+		prefix = prefix[:24]
+	}
+	return fmt.Sprintf("%s%x", string(prefix), sha1.Sum([]byte(name)))
+}
+
+// dropColumn is a no-op on SQLite so that rolling back never rebuilds a
+// table. The leftover column is ignored by AutoMigrate when migrating up
+// again.
+func dropColumn(db *gorm.DB, model interface{}, column string) error {
+	if db.Dialector.Name() == "sqlite" {
+		return nil
+	}
+	return db.Migrator().DropColumn(model, column)
+}
+
+func appliedMigrations(db *gorm.DB) (map[string]bool, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		applied[r.Id] = true
+	}
+	return applied, nil
+}
+
+// migrateSchema applies every pending migration in order.
+func migrateSchema(db *gorm.DB) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.Id] {
+			continue
+		}
+		tx := db.Begin()
+		if err := m.Up(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Create(&SchemaMigration{Id: m.Id, AppliedAt: time.Now()}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+		slog.Info("Applied migration.", "migration", m.Id)
+	}
+	return nil
+}
+
+// rollbackSchema reverts the most recently applied migration.
+func rollbackSchema(db *gorm.DB) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if !applied[m.Id] {
+			continue
+		}
+		tx := db.Begin()
+		if err := m.Down(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Delete(&SchemaMigration{Id: m.Id}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+		slog.Info("Rolled back migration.", "migration", m.Id)
+		return nil
+	}
+	slog.Info("Nothing to roll back.")
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+// TestMigrationsCreateTheModels checks that the frozen structs of the
+// migrations add up to the current models.
+func TestMigrationsCreateTheModels(t *testing.T) {
+	db := dbConnect(testConfig(t))
+	defer closeDB(db)
+
+	for _, model := range []interface{}{&Coin{}, &Repository{}, &RepositorySnapshot{}, &Run{}} {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		for _, column := range stmt.Schema.DBNames {
+			if !db.Migrator().HasColumn(model, column) {
+				t.Errorf("%s has no column %s", stmt.Schema.Table, column)
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			if !db.Migrator().HasIndex(model, index.Name) {
+				t.Errorf("%s has no index %s", stmt.Schema.Table, index.Name)
+			}
+		}
+	}
+}
+
+// TestRepositoriesIndexRefusesDuplicates migrates a DB up to the unique
+// index, with a repository added twice to the same coin before it.
+func TestRepositoriesIndexRefusesDuplicates(t *testing.T) {
+	config := testConfig(t)
+	dialector, err := config.Database.dialector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{DisableForeignKeyConstraintWhenMigrating: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(db)
+
+	var index migration
+	for _, m := range migrations {
+		if m.Id == "027_add_coins_and_repositories_indexes" {
+			index = m
+			break
+		}
+		if err := m.Up(db); err != nil {
+			t.Fatalf("%s: %v", m.Id, err)
+		}
+	}
+	if db.Migrator().HasIndex(&Repository{}, "idx_repositories_coin_id_name") {
+		t.Fatal("idx_repositories_coin_id_name was created before its migration")
+	}
+
+	if err := db.Exec("INSERT INTO coins (id, symbol, owner) VALUES (1, 'BTC', 'bitcoin')").Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := db.Exec("INSERT INTO repositories (coin_id, name) VALUES (1, 'bitcoin')").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = index.Up(db)
+	if err == nil || !strings.Contains(err.Error(), "1/bitcoin") {
+		t.Errorf("%s returned %v, want the duplicate listed", index.Id, err)
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type (
+	Coin struct {
+		Id           int           `gorm:"primaryKey" json:"id"`
+		Name         string        `json:"name"`
+		Symbol       string        `gorm:"index" json:"symbol"`
+		Owner        string        `json:"owner"`
+		Repositories []*Repository `gorm:"foreignKey:CoinId;references:Id" json:"repositories,omitempty"`
+		UpdatedAt    time.Time     `json:"updated_at"`
+		CreatedAt    time.Time     `json:"created_at"`
+
+		// Inactive coins keep their data but none of their repositories is
+		// collected. Removed coins are soft-deleted, hidden from every query
+		// until they are reactivated.
+		Active    bool           `gorm:"default:true" json:"active"`
+		DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+		// The CoinGecko id and what the enrich command read from it.
+		CoingeckoId   string `json:"coingecko_id,omitempty"`
+		MarketCapRank int    `json:"market_cap_rank,omitempty"`
+		Homepage      string `json:"homepage,omitempty"`
+	}
+
+	// Repository holds the latest metrics of a repository. PullRequestsCount
+	// and IssuesCount are the sums of their per-state counts.
+	Repository struct {
+		Id                          int       `gorm:"primaryKey" json:"id"`
+		CoinId                      int       `gorm:"index:idx_repositories_coin_id;uniqueIndex:idx_repositories_coin_id_name" json:"coin_id"`
+		Coin                        Coin      `json:"-"`
+		Provider                    string    `gorm:"default:'github'" json:"provider"`
+		BaseURL                     string    `json:"base_url,omitempty"`
+		AllBranches                 bool      `json:"all_branches"`
+		Status                      string    `gorm:"default:'active';index" json:"status"`
+		Name                        string    `gorm:"size:191;uniqueIndex:idx_repositories_coin_id_name" json:"name"`
+		Language                    string    `json:"language"`
+		PullRequestsCount           int       `json:"pull_requests_count"`
+		OpenPullRequestsCount       int       `json:"open_pull_requests_count"`
+		ClosedPullRequestsCount     int       `json:"closed_pull_requests_count"`
+		MergedPullRequestsCount     int       `json:"merged_pull_requests_count"`
+		WatchersCount               int       `json:"watchers_count"`
+		StargazersCount             int       `json:"stargazers_count"`
+		IssuesCount                 int       `json:"issues_count"`
+		OpenIssuesCount             int       `json:"open_issues_count"`
+		ClosedIssuesCount           int       `json:"closed_issues_count"`
+		CommitsCountForTheLastWeek  int       `json:"commits_count_for_the_last_week"`
+		CommitsCountForTheLastMonth int       `json:"commits_count_for_the_last_month"`
+		CommitsCount                int       `json:"commits_count"`
+		ContributorsCount           int       `json:"contributors_count"`
+		ForksCount                  int       `json:"forks_count"`
+		ReleasesCount               int       `json:"releases_count"`
+		TagsCount                   int       `json:"tags_count"`
+		DefaultBranch               string    `json:"default_branch"`
+		License                     string    `json:"license"`
+		UpdatedAt                   time.Time `gorm:"index" json:"updated_at"`
+		CreatedAt                   time.Time `json:"created_at"`
+
+		// LastCommitAt is the commit date of the newest commit of the
+		// default branch, nil until a collector has read it.
+		LastCommitAt *time.Time `json:"last_commit_at"`
+
+		// The latest release and the number of releases published in the
+		// last 90 days, of which at most the 50 newest are counted.
+		LatestReleaseTag              string     `json:"latest_release_tag"`
+		LatestReleaseAt               *time.Time `json:"latest_release_at"`
+		ReleasesCountForTheLast90Days int        `json:"releases_count_for_the_last_90_days"`
+
+		// The median seconds from opening to closing of the 50 most
+		// recently closed issues and to merging of the 50 most recently
+		// merged pull requests.
+		MedianIssueCloseSeconds       int `json:"median_issue_close_seconds"`
+		MedianPullRequestMergeSeconds int `json:"median_pull_request_merge_seconds"`
+
+		// SignedCommitsPercentage is the percentage of the commits of the
+		// last month with a verified signature. Only GitHub reports
+		// signatures; it stays nil elsewhere and without recent commits.
+		SignedCommitsPercentage *float64 `json:"signed_commits_percentage"`
+
+		// The security posture of the repository, read by the security
+		// collector: whether it has a security policy, whether its default
+		// branch is protected and whether it configures Dependabot. Nil
+		// until the collector has read them.
+		HasSecurityPolicy      *bool `json:"has_security_policy"`
+		DefaultBranchProtected *bool `json:"default_branch_protected"`
+		DependabotConfigured   *bool `json:"dependabot_configured"`
+
+		// ETag and LastModified are the validators GitHub answered for the
+		// repository when it was last collected, sent back by conditional
+		// requests.
+		ETag         string `gorm:"column:etag" json:"-"`
+		LastModified string `json:"-"`
+
+		// LastRunId is the run that last wrote the metrics. A run only
+		// writes them while it is still the one it selected, so overlapping
+		// runs never overwrite each other.
+		LastRunId int `gorm:"default:0" json:"last_run_id"`
+
+		// Inactive repositories are skipped by collect runs and removed
+		// ones are soft-deleted, both keeping their snapshots.
+		Active    bool           `gorm:"default:true" json:"active"`
+		DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+		// CommitWindows carries the collected per-window counts to the
+		// repository_commit_windows table.
+		CommitWindows map[string]int `gorm:"-" json:"-"`
+		// Contributors carries the recently active authors to the
+		// repository_contributors table.
+		Contributors []RepositoryContributor `gorm:"-" json:"-"`
+		// CodeFrequency carries the weekly additions and deletions to the
+		// repository_code_frequencies table. Only GitHub provides them.
+		CodeFrequency []RepositoryCodeFrequency `gorm:"-" json:"-"`
+		// Languages carries the language breakdown to the
+		// repository_languages table. Language stays the largest of them.
+		Languages []RepositoryLanguage `gorm:"-" json:"-"`
+		// Topics carries the topics to the repository_topics table. Nil
+		// means the collector does not read topics.
+		Topics []RepositoryTopic `gorm:"-" json:"-"`
+		// TopContributors carries the most active of Contributors to the
+		// repository_top_contributors table.
+		TopContributors []RepositoryTopContributor `gorm:"-" json:"-"`
+		// AuthorDomains carries the commits by author domain to the
+		// repository_author_domains table. Nil means the collector does not
+		// read commit authors.
+		AuthorDomains []RepositoryAuthorDomain `gorm:"-" json:"-"`
+	}
+
+	RepositorySnapshot struct {
+		Id                          int       `gorm:"primaryKey" json:"id"`
+		RepositoryId                int       `gorm:"index" json:"repository_id"`
+		RunId                       int       `gorm:"index" json:"run_id"`
+		Language                    string    `json:"language"`
+		PullRequestsCount           int       `json:"pull_requests_count"`
+		OpenPullRequestsCount       int       `json:"open_pull_requests_count"`
+		ClosedPullRequestsCount     int       `json:"closed_pull_requests_count"`
+		MergedPullRequestsCount     int       `json:"merged_pull_requests_count"`
+		WatchersCount               int       `json:"watchers_count"`
+		StargazersCount             int       `json:"stargazers_count"`
+		IssuesCount                 int       `json:"issues_count"`
+		OpenIssuesCount             int       `json:"open_issues_count"`
+		ClosedIssuesCount           int       `json:"closed_issues_count"`
+		CommitsCountForTheLastWeek  int       `json:"commits_count_for_the_last_week"`
+		CommitsCountForTheLastMonth int       `json:"commits_count_for_the_last_month"`
+		CommitsCount                int       `json:"commits_count"`
+		ContributorsCount           int       `json:"contributors_count"`
+		ForksCount                  int       `json:"forks_count"`
+		ReleasesCount               int       `json:"releases_count"`
+		TagsCount                   int       `json:"tags_count"`
+		CapturedAt                  time.Time `gorm:"index" json:"captured_at"`
+		// Backfilled snapshots are reconstructed from history by the
+		// backfill commands rather than written by a run, and only hold the
+		// columns their command could reconstruct.
+		Backfilled bool `gorm:"not null;default:false" json:"backfilled"`
+	}
+
+	// CoinStat is the rollup of every repository of a coin, refreshed at
+	// the end of each collect run.
+	CoinStat struct {
+		Id                          int       `gorm:"primaryKey" json:"-"`
+		CoinId                      int       `gorm:"uniqueIndex:uix_coin_stats_coin_id" json:"coin_id"`
+		RepositoriesCount           int       `json:"repositories_count"`
+		PullRequestsCount           int       `json:"pull_requests_count"`
+		WatchersCount               int       `json:"watchers_count"`
+		StargazersCount             int       `json:"stargazers_count"`
+		IssuesCount                 int       `json:"issues_count"`
+		CommitsCountForTheLastWeek  int       `json:"commits_count_for_the_last_week"`
+		CommitsCountForTheLastMonth int       `json:"commits_count_for_the_last_month"`
+		CommitsCount                int       `json:"commits_count"`
+		ContributorsCount           int       `json:"contributors_count"`
+		UpdatedAt                   time.Time `json:"updated_at"`
+	}
+
+	// Run is the audit record of one collect execution. FinishedAt stays
+	// NULL while the run is in progress or when it crashed.
+	Run struct {
+		Id                 int        `gorm:"primaryKey" json:"id"`
+		StartedAt          time.Time  `json:"started_at"`
+		FinishedAt         *time.Time `json:"finished_at"`
+		Interrupted        bool       `json:"interrupted"`
+		ReposProcessed     int        `json:"repos_processed"`
+		ApiErrors          int        `json:"api_errors"`
+		ScrapeErrors       int        `json:"scrape_errors"`
+		OtherErrors        int        `json:"other_errors"`
+		RateLimitRemaining int        `json:"rate_limit_remaining"`
+		Anomalies          int        `json:"anomalies"`
+	}
+
+	// CollectionError is a failed attempt to collect a repository. It stays
+	// unresolved until the repository is collected successfully again.
+	CollectionError struct {
+		Id           int        `gorm:"primaryKey" json:"id"`
+		RunId        int        `gorm:"index" json:"run_id"`
+		RepositoryId int        `gorm:"index" json:"repository_id"`
+		Kind         string     `json:"kind"`
+		Message      string     `gorm:"type:text" json:"message"`
+		CreatedAt    time.Time  `json:"created_at"`
+		ResolvedAt   *time.Time `json:"resolved_at"`
+	}
+
+	// Anomaly is a suspicious change of a repository's metrics between its
+	// last collection and the one of the run, such as its stars dropping at
+	// once, which tends to mean the collection broke or the repository was
+	// replaced.
+	Anomaly struct {
+		Id           int       `gorm:"primaryKey" json:"id"`
+		RunId        int       `gorm:"index" json:"run_id"`
+		RepositoryId int       `gorm:"index" json:"repository_id"`
+		Kind         string    `json:"kind"`
+		Previous     int       `json:"previous"`
+		Current      int       `json:"current"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	// RepositoryCommitWindow is the commit count of a repository over one
+	// configured trailing window.
+	RepositoryCommitWindow struct {
+		Id           int       `gorm:"primaryKey" json:"-"`
+		RepositoryId int       `gorm:"uniqueIndex:idx_repository_commit_windows_repository_window" json:"repository_id"`
+		Window       string    `gorm:"uniqueIndex:idx_repository_commit_windows_repository_window" json:"window"`
+		Days         int       `json:"days"`
+		CommitsCount int       `json:"commits_count"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+
+	// RepositoryContributor is a distinct author who committed to the
+	// default branch of a repository within the last 90 days.
+	RepositoryContributor struct {
+		Id                           int       `gorm:"primaryKey" json:"-"`
+		RepositoryId                 int       `gorm:"uniqueIndex:idx_repository_contributors_repository_author" json:"repository_id"`
+		Author                       string    `gorm:"uniqueIndex:idx_repository_contributors_repository_author" json:"author"`
+		CommitsCountForTheLast30Days int       `json:"commits_count_for_the_last_30_days"`
+		CommitsCountForTheLast90Days int       `json:"commits_count_for_the_last_90_days"`
+		LastCommittedAt              time.Time `json:"last_committed_at"`
+		UpdatedAt                    time.Time `json:"updated_at"`
+	}
+
+	// RepositoryTopContributor is one of the authors with the most commits
+	// to a repository in the last 90 days, Rank 1 having the most. Login
+	// falls back to the email, then the name, of authors without an
+	// account.
+	RepositoryTopContributor struct {
+		Id                           int       `gorm:"primaryKey" json:"-"`
+		RepositoryId                 int       `gorm:"uniqueIndex:idx_repository_top_contributors_repository_login" json:"repository_id"`
+		Login                        string    `gorm:"size:191;uniqueIndex:idx_repository_top_contributors_repository_login;index" json:"login"`
+		Rank                         int       `json:"rank"`
+		CommitsCountForTheLast90Days int       `json:"commits_count_for_the_last_90_days"`
+		UpdatedAt                    time.Time `json:"updated_at"`
+	}
+
+	// RepositoryCodeFrequency is the number of lines added and deleted on
+	// the default branch of a repository during the week starting at Week.
+	RepositoryCodeFrequency struct {
+		Id           int       `gorm:"primaryKey" json:"-"`
+		RepositoryId int       `gorm:"uniqueIndex:idx_repository_code_frequencies_repository_week" json:"repository_id"`
+		Week         time.Time `gorm:"uniqueIndex:idx_repository_code_frequencies_repository_week" json:"week"`
+		Additions    int       `json:"additions"`
+		Deletions    int       `json:"deletions"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+
+	// RepositoryLanguage is one of the largest languages of a repository.
+	// Share is its percentage of the code; Bytes is left at zero by GitLab,
+	// which only reports percentages.
+	RepositoryLanguage struct {
+		Id           int       `gorm:"primaryKey" json:"-"`
+		RepositoryId int       `gorm:"uniqueIndex:idx_repository_languages_repository_language" json:"repository_id"`
+		Language     string    `gorm:"uniqueIndex:idx_repository_languages_repository_language" json:"language"`
+		Bytes        int64     `json:"bytes"`
+		Share        float64   `json:"share"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+
+	// RepositoryAuthorDomain is the share of the commits of the last 90
+	// days whose author email is under Domain, the registered domain of an
+	// organization. Domain is "" for personal and unknown addresses, the
+	// community's.
+	RepositoryAuthorDomain struct {
+		Id           int       `gorm:"primaryKey" json:"-"`
+		RepositoryId int       `gorm:"uniqueIndex:idx_repository_author_domains_repository_domain" json:"repository_id"`
+		Domain       string    `gorm:"size:191;uniqueIndex:idx_repository_author_domains_repository_domain" json:"domain"`
+		CommitsCount int       `json:"commits_count"`
+		Share        float64   `json:"share"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+
+	// RepositoryTopic is a topic a repository is tagged with, such as
+	// "blockchain".
+	RepositoryTopic struct {
+		Id           int       `gorm:"primaryKey" json:"-"`
+		RepositoryId int       `gorm:"uniqueIndex:idx_repository_topics_repository_topic" json:"repository_id"`
+		Topic        string    `gorm:"uniqueIndex:idx_repository_topics_repository_topic;index" json:"topic"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+
+	// CoinScore is the weighted activity score of a coin in one run, along
+	// with the raw components it was computed from. Rank 1 is the most
+	// active coin of the run.
+	CoinScore struct {
+		Id                int       `gorm:"primaryKey" json:"-"`
+		RunId             int       `gorm:"index" json:"run_id"`
+		CoinId            int       `gorm:"index" json:"coin_id"`
+		Score             float64   `json:"score"`
+		Rank              int       `json:"rank"`
+		CommitsCount      int       `json:"commits_count"`
+		ContributorsCount int       `json:"contributors_count"`
+		StarsGained       int       `json:"stars_gained"`
+		PullRequestsCount int       `json:"pull_requests_count"`
+		CreatedAt         time.Time `json:"created_at"`
+	}
+
+	// CoinRank is where a coin stood among the active coins in one run, by
+	// commits in the last month, by contributors and by both. Ties share a
+	// rank. A percentile is the share of the other coins the coin beat, so
+	// 100 is the best and 0 the worst. The composite percentile is the mean
+	// of the two others.
+	CoinRank struct {
+		Id                          int       `gorm:"primaryKey" json:"-"`
+		RunId                       int       `gorm:"uniqueIndex:idx_coin_ranks_run_coin" json:"run_id"`
+		CoinId                      int       `gorm:"uniqueIndex:idx_coin_ranks_run_coin;index" json:"coin_id"`
+		CommitsCountForTheLastMonth int       `json:"commits_count_for_the_last_month"`
+		CommitsRank                 int       `json:"commits_rank"`
+		CommitsPercentile           float64   `json:"commits_percentile"`
+		ContributorsCount           int       `json:"contributors_count"`
+		ContributorsRank            int       `json:"contributors_rank"`
+		ContributorsPercentile      float64   `json:"contributors_percentile"`
+		CompositeRank               int       `json:"composite_rank"`
+		CompositePercentile         float64   `json:"composite_percentile"`
+		CreatedAt                   time.Time `json:"created_at"`
+	}
+
+	// CoinMarketSnapshot is the price, market cap and 24h volume of a coin
+	// in USD on Date, from Provider, next to its development activity when
+	// it was captured, so the two can be compared row by row. A coin has
+	// one snapshot a day, the last run of the day's.
+	CoinMarketSnapshot struct {
+		Id                          int       `gorm:"primaryKey" json:"-"`
+		CoinId                      int       `gorm:"uniqueIndex:idx_coin_market_snapshots_coin_date" json:"coin_id"`
+		Date                        time.Time `gorm:"uniqueIndex:idx_coin_market_snapshots_coin_date" json:"date"`
+		Provider                    string    `json:"provider"`
+		PriceUSD                    float64   `json:"price_usd"`
+		MarketCapUSD                float64   `json:"market_cap_usd"`
+		VolumeUSD                   float64   `json:"volume_usd"`
+		CommitsCountForTheLastWeek  int       `json:"commits_count_for_the_last_week"`
+		CommitsCountForTheLastMonth int       `json:"commits_count_for_the_last_month"`
+		ContributorsCount           int       `json:"contributors_count"`
+		StargazersCount             int       `json:"stargazers_count"`
+		CapturedAt                  time.Time `json:"captured_at"`
+	}
+
+	// RepositoryGrowth is the change of a repository's metrics over the
+	// last week or month, measured against the snapshot captured at
+	// BaseCapturedAt. The growth percentages are NULL when the base value
+	// is zero.
+	RepositoryGrowth struct {
+		Id                 int       `gorm:"primaryKey" json:"-"`
+		RepositoryId       int       `gorm:"uniqueIndex:idx_repository_growths_repository_period" json:"repository_id"`
+		Period             string    `gorm:"uniqueIndex:idx_repository_growths_repository_period" json:"period"`
+		BaseCapturedAt     time.Time `json:"base_captured_at"`
+		StarsGained        int       `json:"stars_gained"`
+		StarsGrowth        *float64  `json:"stars_growth"`
+		CommitsDelta       int       `json:"commits_delta"`
+		CommitsGrowth      *float64  `json:"commits_growth"`
+		ContributorsDelta  int       `json:"contributors_delta"`
+		ContributorsGrowth *float64  `json:"contributors_growth"`
+		UpdatedAt          time.Time `json:"updated_at"`
+	}
+
+	// RunRepository records that a run processed a repository, so that an
+	// interrupted run can be resumed without collecting it again.
+	RunRepository struct {
+		Id           int `gorm:"primaryKey" json:"-"`
+		RunId        int `gorm:"uniqueIndex:idx_run_repositories_run_repository" json:"run_id"`
+		RepositoryId int `gorm:"uniqueIndex:idx_run_repositories_run_repository" json:"repository_id"`
+	}
+
+	// RunLock is a lease on a named lock. Its holder renews ExpiresAt while
+	// running, so a crashed holder releases the lock once the lease ends.
+	RunLock struct {
+		Name       string    `gorm:"primaryKey" json:"name"`
+		Owner      string    `json:"owner"`
+		AcquiredAt time.Time `json:"acquired_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+	}
+)
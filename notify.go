@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	notifyTimeout = 10 * time.Second
+
+	// moversCount is how many repositories the run summary lists.
+	moversCount = 5
+
+	// defaultAlertMinRepos is how many repositories a run processes before
+	// its error rate is trusted enough to alert on.
+	defaultAlertMinRepos = 10
+)
+
+type (
+	// notifier posts run summaries and error rate alerts to the Slack and
+	// Discord webhooks of the [Notifications] config.
+	notifier struct {
+		config  NotificationsConfig
+		http    *http.Client
+		alerted bool
+		movers  []mover
+	}
+
+	// mover is a repository whose stars moved during the run.
+	mover struct {
+		Repo  string
+		Stars int
+	}
+)
+
+func newNotifier(config NotificationsConfig) *notifier {
+	return &notifier{config: config, http: http.DefaultClient}
+}
+
+func (n *notifier) enabled() bool {
+	return n.config.Slack != "" || n.config.Discord != ""
+}
+
+// observe follows a run after each result, recording the star movers and
+// alerting once when the share of failed repositories exceeds ErrorRate.
+func (n *notifier) observe(run *Run, r result) {
+	if !n.enabled() {
+		return
+	}
+	if r.Err == nil && r.Metrics.StargazersCount > 0 {
+		if gained := r.Metrics.StargazersCount - r.Repository.StargazersCount; gained != 0 {
+			n.movers = append(n.movers, mover{Repo: repoName(r.Repository), Stars: gained})
+		}
+	}
+
+	minRepos := n.config.AlertMinRepos
+	if minRepos == 0 {
+		minRepos = defaultAlertMinRepos
+	}
+	if n.alerted || n.config.ErrorRate <= 0 || run.ReposProcessed < minRepos {
+		return
+	}
+	failed := run.failures()
+	if rate := float64(failed) / float64(run.ReposProcessed); rate > n.config.ErrorRate {
+		n.alerted = true
+		n.send(fmt.Sprintf(":rotating_light: Run %d: %d of %d repositories failed so far (%.0f%%, threshold %.0f%%).",
+			run.Id, failed, run.ReposProcessed, rate*100, n.config.ErrorRate*100))
+	}
+}
+
+// summarize posts the outcome of the finished run.
+func (n *notifier) summarize(run *Run, interrupted bool, took time.Duration) {
+	if !n.enabled() {
+		return
+	}
+	var b strings.Builder
+	status := "finished"
+	if interrupted {
+		status = "was interrupted"
+	}
+	fmt.Fprintf(&b, "Run %d %s in %s: %d repositories processed, %d failed", run.Id, status, took.Round(time.Second), run.ReposProcessed, run.failures())
+	if run.failures() > 0 {
+		fmt.Fprintf(&b, " (%d API, %d scrape, %d other)", run.ApiErrors, run.ScrapeErrors, run.OtherErrors)
+	}
+	if run.Anomalies > 0 {
+		fmt.Fprintf(&b, ", %d anomalies", run.Anomalies)
+	}
+	b.WriteString(".")
+
+	sort.Slice(n.movers, func(i, j int) bool { return abs(n.movers[i].Stars) > abs(n.movers[j].Stars) })
+	movers := n.movers
+	if len(movers) > moversCount {
+		movers = movers[:moversCount]
+	}
+	if len(movers) > 0 {
+		b.WriteString("\nBiggest movers:")
+		for _, m := range movers {
+			fmt.Fprintf(&b, "\n• %s %+d stars", m.Repo, m.Stars)
+		}
+	}
+	n.send(b.String())
+}
+
+// send posts text to every configured webhook. Failures are only logged, a
+// notification never fails the run.
+func (n *notifier) send(text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if n.config.Slack != "" {
+		if err := n.post(ctx, n.config.Slack, map[string]string{"text": text}); err != nil {
+			slog.Error("Failed to notify Slack.", "error", err)
+		}
+	}
+	if n.config.Discord != "" {
+		// Discord rejects messages over 2000 characters.
+		if r := []rune(text); len(r) > 2000 {
+			text = string(r[:1999]) + "…"
+		}
+		if err := n.post(ctx, n.config.Discord, map[string]string{"content": text}); err != nil {
+			slog.Error("Failed to notify Discord.", "error", err)
+		}
+	}
+}
+
+func (n *notifier) post(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := n.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		// The URL holds the webhook token, so it is left out of the error.
+		return fmt.Errorf("webhook answered %s", res.Status)
+	}
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// pacingTransport spaces out the requests to each host by delay plus a
+// random jitter, across every worker, to stay under the secondary rate
+// limits and keep the traffic from looking like a bot's. Hosts are paced
+// independently, so a slow GitHub does not hold up GitLab.
+type pacingTransport struct {
+	base   http.RoundTripper
+	delay  time.Duration
+	jitter time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func newPacingTransport(base http.RoundTripper, delay, jitter time.Duration) http.RoundTripper {
+	if delay <= 0 && jitter <= 0 {
+		return base
+	}
+	return &pacingTransport{base: base, delay: delay, jitter: jitter, next: map[string]time.Time{}}
+}
+
+// slot reserves the time the next request to host may start at.
+func (t *pacingTransport) slot(host string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at := time.Now()
+	if next := t.next[host]; next.After(at) {
+		at = next
+	}
+	gap := t.delay
+	if t.jitter > 0 {
+		gap += time.Duration(rand.Int63n(int64(t.jitter)))
+	}
+	t.next[host] = at.Add(gap)
+	return at
+}
+
+func (t *pacingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if delay := time.Until(t.slot(req.URL.Host)); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return t.base.RoundTrip(req)
+}
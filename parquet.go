@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+	"gorm.io/gorm"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// parquetFile is the name of the file written in each date partition.
+const parquetFile = "snapshots.parquet"
+
+type (
+	// parquetSnapshot is a row of the Parquet export: a snapshot along with
+	// the coin and repository it belongs to.
+	parquetSnapshot struct {
+		CoinSymbol                  string    `parquet:"coin_symbol,dict"`
+		Owner                       string    `parquet:"owner,dict"`
+		Repository                  string    `parquet:"repository,dict"`
+		RepositoryId                int32     `parquet:"repository_id"`
+		RunId                       int32     `parquet:"run_id"`
+		CapturedAt                  time.Time `parquet:"captured_at,timestamp(millisecond)"`
+		Language                    string    `parquet:"language,dict"`
+		PullRequestsCount           int32     `parquet:"pull_requests_count"`
+		OpenPullRequestsCount       int32     `parquet:"open_pull_requests_count"`
+		ClosedPullRequestsCount     int32     `parquet:"closed_pull_requests_count"`
+		MergedPullRequestsCount     int32     `parquet:"merged_pull_requests_count"`
+		WatchersCount               int32     `parquet:"watchers_count"`
+		StargazersCount             int32     `parquet:"stargazers_count"`
+		IssuesCount                 int32     `parquet:"issues_count"`
+		OpenIssuesCount             int32     `parquet:"open_issues_count"`
+		ClosedIssuesCount           int32     `parquet:"closed_issues_count"`
+		CommitsCountForTheLastWeek  int32     `parquet:"commits_count_for_the_last_week"`
+		CommitsCountForTheLastMonth int32     `parquet:"commits_count_for_the_last_month"`
+		CommitsCount                int32     `parquet:"commits_count"`
+		ContributorsCount           int32     `parquet:"contributors_count"`
+		ForksCount                  int32     `parquet:"forks_count"`
+		ReleasesCount               int32     `parquet:"releases_count"`
+		TagsCount                   int32     `parquet:"tags_count"`
+		Backfilled                  bool      `parquet:"backfilled"`
+	}
+
+	// objectStore is where the partitions are written: a local directory or
+	// an S3 prefix.
+	objectStore interface {
+		put(ctx context.Context, key string, data []byte) error
+	}
+
+	localStore struct {
+		dir string
+	}
+
+	s3Store struct {
+		client *s3.Client
+		bucket string
+		prefix string
+	}
+)
+
+func newParquetSnapshot(repo Repository, s RepositorySnapshot) parquetSnapshot {
+	return parquetSnapshot{
+		CoinSymbol:                  repo.Coin.Symbol,
+		Owner:                       repo.Coin.Owner,
+		Repository:                  repo.Name,
+		RepositoryId:                int32(s.RepositoryId),
+		RunId:                       int32(s.RunId),
+		CapturedAt:                  s.CapturedAt.UTC(),
+		Language:                    s.Language,
+		PullRequestsCount:           int32(s.PullRequestsCount),
+		OpenPullRequestsCount:       int32(s.OpenPullRequestsCount),
+		ClosedPullRequestsCount:     int32(s.ClosedPullRequestsCount),
+		MergedPullRequestsCount:     int32(s.MergedPullRequestsCount),
+		WatchersCount:               int32(s.WatchersCount),
+		StargazersCount:             int32(s.StargazersCount),
+		IssuesCount:                 int32(s.IssuesCount),
+		OpenIssuesCount:             int32(s.OpenIssuesCount),
+		ClosedIssuesCount:           int32(s.ClosedIssuesCount),
+		CommitsCountForTheLastWeek:  int32(s.CommitsCountForTheLastWeek),
+		CommitsCountForTheLastMonth: int32(s.CommitsCountForTheLastMonth),
+		CommitsCount:                int32(s.CommitsCount),
+		ContributorsCount:           int32(s.ContributorsCount),
+		ForksCount:                  int32(s.ForksCount),
+		ReleasesCount:               int32(s.ReleasesCount),
+		TagsCount:                   int32(s.TagsCount),
+		Backfilled:                  s.Backfilled,
+	}
+}
+
+// newObjectStore opens out, either s3://bucket/prefix or a local directory.
+// The S3 region and credentials come from the default AWS chain unless
+// region is set.
+func newObjectStore(ctx context.Context, out, region string) (objectStore, error) {
+	rest, ok := strings.CutPrefix(out, "s3://")
+	if !ok {
+		return localStore{dir: out}, nil
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("%q has no bucket", out)
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	return s3Store{client: s3.NewFromConfig(cfg), bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+func (s localStore) put(ctx context.Context, key string, data []byte) error {
+	name := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+func (s s3Store) put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path.Join(s.prefix, key)),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// partitionKey is the key of the file holding the snapshots captured on the
+// UTC date of t, in the Hive layout Athena and Spark discover partitions
+// from.
+func partitionKey(t time.Time) string {
+	return fmt.Sprintf("captured_date=%s/%s", t.UTC().Format("2006-01-02"), parquetFile)
+}
+
+func encodeParquet(rows []parquetSnapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[parquetSnapshot](&buf, parquet.Compression(&parquet.Snappy))
+	if _, err := w.Write(rows); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportParquet writes the snapshots captured from since, or all of them when
+// since is zero, one file per date. A date that is exported again has its
+// file replaced. It returns the number of files written.
+func exportParquet(ctx context.Context, db *gorm.DB, store objectStore, repos []Repository, since time.Time) (int, error) {
+	byId := make(map[int]Repository, len(repos))
+	ids := make([]int, 0, len(repos))
+	for _, repo := range repos {
+		byId[repo.Id] = repo
+		ids = append(ids, repo.Id)
+	}
+
+	files := 0
+	var key string
+	var rows []parquetSnapshot
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		data, err := encodeParquet(rows)
+		if err != nil {
+			return err
+		}
+		if err := store.put(ctx, key, data); err != nil {
+			return err
+		}
+		slog.Info("Wrote partition.", "key", key, "rows", len(rows))
+		files++
+		rows = rows[:0]
+		return nil
+	}
+
+	// Snapshots come ordered by capture time, so each date is read in one
+	// stretch and only one partition is held in memory at a time.
+	var snapshots []RepositorySnapshot
+	err := db.Where("repository_id IN ? AND captured_at >= ?", ids, since).
+		Order("captured_at, id").
+		FindInBatches(&snapshots, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, s := range snapshots {
+				if k := partitionKey(s.CapturedAt); k != key {
+					if err := flush(); err != nil {
+						return err
+					}
+					key = k
+				}
+				rows = append(rows, newParquetSnapshot(byId[s.RepositoryId], s))
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return files, err
+	}
+	return files, flush()
+}
+
+func runExportParquet(args []string) {
+	fs := flag.NewFlagSet("export-parquet", flag.ExitOnError)
+	addConfigFlag(fs)
+	out := fs.String("out", "", "local directory or s3://bucket/prefix to write the partitions to")
+	region := fs.String("region", "", "AWS region of the S3 bucket, default from the AWS config")
+	sinceFlag := fs.String("since", "", "only export the dates from this one on, as 2006-01-02")
+	var filter repositoryFilter
+	filter.IncludeMissing = true
+	filter.IncludeInactive = true
+	fs.StringVar(&filter.Coin, "coin", "", "only export the repositories of the coin with this symbol")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	if *out == "" {
+		fatal("--out is required.")
+	}
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		if since, err = time.Parse("2006-01-02", *sinceFlag); err != nil {
+			fatal("Invalid --since.", "error", err)
+		}
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	store, err := newObjectStore(ctx, *out, *region)
+	if err != nil {
+		fatal("Failed to open the output.", "out", *out, "error", err)
+	}
+	repos, err := selectRepositories(db, filter, clock.Now())
+	if err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+	files, err := exportParquet(ctx, db, store, repos, since)
+	if err != nil {
+		fatal("Failed to export.", "error", err)
+	}
+	slog.Info("Exported.", "files", files)
+}
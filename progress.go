@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// progress reports how far a run got at a fixed interval, and sums the run
+// up once it ends.
+type progress struct {
+	total    int
+	start    time.Time
+	interval time.Duration
+	budget   *rateBudget
+
+	mu        sync.Mutex
+	processed int
+	failed    int
+}
+
+func newProgress(total int, interval time.Duration, budget *rateBudget) *progress {
+	return &progress{total: total, start: time.Now(), interval: interval, budget: budget}
+}
+
+// observe counts the result of one repository.
+func (p *progress) observe(r result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed++
+	if r.Err != nil {
+		p.failed++
+	}
+}
+
+// report logs a progress line every interval until ctx is done. A zero
+// interval reports nothing.
+func (p *progress) report(ctx context.Context) {
+	if p.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.log()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *progress) log() {
+	p.mu.Lock()
+	processed, failed := p.processed, p.failed
+	p.mu.Unlock()
+
+	args := []interface{}{"processed", processed, "total", p.total, "errors", failed}
+	if remaining, _, _, ok := p.budget.summary(); ok {
+		args = append(args, "rate_limit_remaining", remaining)
+	}
+	if eta, ok := p.eta(processed); ok {
+		args = append(args, "eta", eta.Round(time.Second).String())
+	}
+	slog.Info("Progress.", args...)
+}
+
+// eta extrapolates the time left from the pace of the run so far. It is
+// unknown until a repository was processed.
+func (p *progress) eta(processed int) (time.Duration, bool) {
+	if processed == 0 {
+		return 0, false
+	}
+	elapsed := time.Since(p.start)
+	return elapsed / time.Duration(processed) * time.Duration(p.total-processed), true
+}
+
+// summarize logs the outcome of run, as a warning when repositories failed
+// or the run was cut short.
+func (p *progress) summarize(run *Run, interrupted bool) {
+	p.mu.Lock()
+	processed, failed := p.processed, p.failed
+	p.mu.Unlock()
+
+	// The errors by kind also count those of the first attempt of a resumed
+	// run.
+	args := []interface{}{
+		"run_id", run.Id,
+		"processed", processed,
+		"total", p.total,
+		"errors", failed,
+		"api_errors", run.ApiErrors,
+		"scrape_errors", run.ScrapeErrors,
+		"other_errors", run.OtherErrors,
+		"anomalies", run.Anomalies,
+		"interrupted", interrupted,
+		"duration", time.Since(p.start).Round(time.Second).String(),
+	}
+	if remaining, _, _, ok := p.budget.summary(); ok {
+		args = append(args, "rate_limit_remaining", remaining)
+	}
+	if interrupted || failed > 0 {
+		slog.Warn("Run finished with errors.", args...)
+		return
+	}
+	slog.Info("Run finished.", args...)
+}
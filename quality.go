@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// dataProblem is an impossible value in a row of the current data.
+type dataProblem struct {
+	Coin    string
+	Row     string
+	Problem string
+}
+
+// dataProblems lists the impossible values of a Repository or CoinStat:
+// negative counts, commits without contributors, and more commits in the
+// last week than in the last month or ever.
+func dataProblems(row interface{}) []string {
+	v := reflect.ValueOf(row)
+	var problems []string
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Type.Kind() != reflect.Int || !strings.HasSuffix(name, "_count") && !strings.Contains(name, "_count_") {
+			continue
+		}
+		if n := v.Field(i).Int(); n < 0 {
+			problems = append(problems, fmt.Sprintf("%s is negative: %d", name, n))
+		}
+	}
+
+	count := func(field string) int { return int(v.FieldByName(field).Int()) }
+	week, month, total := count("CommitsCountForTheLastWeek"), count("CommitsCountForTheLastMonth"), count("CommitsCount")
+	contributors := count("ContributorsCount")
+	if month > 0 && contributors == 0 {
+		problems = append(problems, fmt.Sprintf("%d commits in the last month but no contributors", month))
+	}
+	if month < week {
+		problems = append(problems, fmt.Sprintf("fewer commits in the last month (%d) than in the last week (%d)", month, week))
+	}
+	// Scraped repositories may not report the total.
+	if total > 0 && total < month {
+		problems = append(problems, fmt.Sprintf("fewer commits in total (%d) than in the last month (%d)", total, month))
+	}
+	return problems
+}
+
+// runValidate reports the impossible values of the collected metrics, so
+// they can be fixed before the data is published. It exits with 1 when it
+// finds any.
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	addConfigFlag(fs)
+	filter := repositoryFilter{IncludeMissing: true, IncludeInactive: true}
+	fs.StringVar(&filter.Coin, "coin", "", "only validate the coin with this symbol and its repositories")
+	fs.StringVar(&filter.Repo, "repo", "", "only validate this owner/name repository")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	repos, err := selectRepositories(db, filter, clock.Now())
+	if err != nil {
+		fatal("Failed to read the DB.", "error", err)
+	}
+	var found []dataProblem
+	coins := map[int]string{}
+	for _, repo := range repos {
+		coins[repo.CoinId] = repo.Coin.Symbol
+		for _, p := range dataProblems(repo) {
+			found = append(found, dataProblem{Coin: repo.Coin.Symbol, Row: repoName(repo), Problem: p})
+		}
+	}
+	// A coin's rollup is only checked as a whole, not for one repository.
+	if filter.Repo == "" {
+		var stats []CoinStat
+		if err := db.Order("coin_id").Find(&stats).Error; err != nil {
+			fatal("Failed to read the DB.", "error", err)
+		}
+		for _, s := range stats {
+			symbol, ok := coins[s.CoinId]
+			if !ok {
+				continue
+			}
+			for _, p := range dataProblems(s) {
+				found = append(found, dataProblem{Coin: symbol, Row: "coin stats", Problem: p})
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		fmt.Printf("No problems in %d repositories.\n", len(repos))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "COIN\tROW\tPROBLEM")
+	for _, p := range found {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Coin, p.Row, p.Problem)
+	}
+	w.Flush()
+	os.Exit(1)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// refreshCoinRanks writes one coin_ranks row per active coin for the run,
+// from the CoinStat rollup refreshed just before.
+func refreshCoinRanks(db *gorm.DB, runId int, now time.Time) error {
+	var stats []CoinStat
+	err := db.Joins("JOIN coins ON coins.id = coin_stats.coin_id").
+		Where("coins.active = ? AND coins.deleted_at IS NULL", true).
+		Order("coin_stats.coin_id").
+		Find(&stats).Error
+	if err != nil {
+		return err
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+
+	commits := make([]float64, len(stats))
+	contributors := make([]float64, len(stats))
+	for i, s := range stats {
+		commits[i] = float64(s.CommitsCountForTheLastMonth)
+		contributors[i] = float64(s.ContributorsCount)
+	}
+	commitsRank, commitsPercentile := rankValues(commits)
+	contributorsRank, contributorsPercentile := rankValues(contributors)
+	composite := make([]float64, len(stats))
+	for i := range stats {
+		composite[i] = (commitsPercentile[i] + contributorsPercentile[i]) / 2
+	}
+	compositeRank, _ := rankValues(composite)
+
+	ranks := make([]CoinRank, len(stats))
+	for i, s := range stats {
+		ranks[i] = CoinRank{
+			RunId:                       runId,
+			CoinId:                      s.CoinId,
+			CommitsCountForTheLastMonth: s.CommitsCountForTheLastMonth,
+			CommitsRank:                 commitsRank[i],
+			CommitsPercentile:           commitsPercentile[i],
+			ContributorsCount:           s.ContributorsCount,
+			ContributorsRank:            contributorsRank[i],
+			ContributorsPercentile:      contributorsPercentile[i],
+			CompositeRank:               compositeRank[i],
+			CompositePercentile:         composite[i],
+			CreatedAt:                   now,
+		}
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		// A resumed run ranks the coins again.
+		if err := tx.Where("run_id = ?", runId).Delete(&CoinRank{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&ranks, defaultWriteBatch).Error
+	})
+}
+
+// rankValues ranks values from the highest down, equal values sharing the
+// best of their ranks, and returns each one's rank and percentile.
+func rankValues(values []float64) ([]int, []float64) {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] > values[order[b]] })
+
+	ranks := make([]int, len(values))
+	tied := map[float64]int{}
+	for pos, i := range order {
+		if pos > 0 && values[i] == values[order[pos-1]] {
+			ranks[i] = ranks[order[pos-1]]
+		} else {
+			ranks[i] = pos + 1
+		}
+		tied[values[i]]++
+	}
+	percentiles := make([]float64, len(values))
+	for i, v := range values {
+		if len(values) == 1 {
+			percentiles[i] = 100
+			continue
+		}
+		// Every coin ranked after the ones tied with this one is beaten.
+		beaten := len(values) - (ranks[i] - 1) - tied[v]
+		percentiles[i] = 100 * float64(beaten) / float64(len(values)-1)
+	}
+	return ranks, percentiles
+}
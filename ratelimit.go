@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const defaultMinRemaining = 100
+
+var errRateLimitExhausted = errors.New("GitHub rate limit budget exhausted")
+
+// rateLimit mirrors the GraphQL rateLimit object queried alongside every
+// repository.
+type rateLimit struct {
+	Remaining int
+	ResetAt   time.Time
+	Cost      int
+}
+
+// rateBudget tracks the most recent rate limit reported by GitHub and is
+// shared by all workers.
+type rateBudget struct {
+	mu           sync.Mutex
+	known        bool
+	remaining    int
+	resetAt      time.Time
+	spent        int
+	minRemaining int
+	abort        bool
+}
+
+func newRateBudget(config GitHubConfig) *rateBudget {
+	b := &rateBudget{minRemaining: config.MinRemaining, abort: config.OnExhausted == "abort"}
+	if b.minRemaining <= 0 {
+		b.minRemaining = defaultMinRemaining
+	}
+	return b
+}
+
+// update records the rate limit returned by a query.
+func (b *rateBudget) update(r rateLimit) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent += r.Cost
+	if b.known && r.ResetAt.Equal(b.resetAt) && r.Remaining > b.remaining {
+		// A slower worker reporting an older value.
+		return
+	}
+	b.known = true
+	b.remaining = r.Remaining
+	b.resetAt = r.ResetAt
+}
+
+// wait blocks until the budget allows another query. When the budget is
+// configured to abort it returns errRateLimitExhausted instead.
+func (b *rateBudget) wait(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.known || b.remaining > b.minRemaining || time.Now().After(b.resetAt) {
+		return nil
+	}
+	if b.abort {
+		return errRateLimitExhausted
+	}
+
+	// Holding the lock makes the other workers wait for the same reset.
+	delay := time.Until(b.resetAt) + time.Second
+	slog.Warn("GitHub rate limit nearly exhausted, pausing.", "remaining", b.remaining, "reset_at", b.resetAt)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+	b.known = false
+	return nil
+}
+
+func (b *rateBudget) summary() (remaining, spent int, resetAt time.Time, known bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining, b.spent, b.resetAt, b.known
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"time"
+)
+
+// releaseCadenceDays is the window of ReleasesCountForTheLast90Days.
+const releaseCadenceDays = 90
+
+// releasesPageSize is how many of the newest releases are read to count the
+// recent ones.
+const releasesPageSize = 50
+
+// release is a published release, as listed by any provider.
+type release struct {
+	Tag         string
+	PublishedAt time.Time
+}
+
+// latestRelease is the most recently published of releases, nil if there is
+// none.
+func latestRelease(releases []release) *release {
+	var latest *release
+	for i, r := range releases {
+		if r.PublishedAt.IsZero() {
+			continue
+		}
+		if latest == nil || r.PublishedAt.After(latest.PublishedAt) {
+			latest = &releases[i]
+		}
+	}
+	return latest
+}
+
+// recentReleasesCount counts the releases published in the releaseCadenceDays
+// before now.
+func recentReleasesCount(releases []release, now time.Time) int {
+	from := now.AddDate(0, 0, -releaseCadenceDays)
+	n := 0
+	for _, r := range releases {
+		if !r.PublishedAt.Before(from) && !r.PublishedAt.After(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// tag and publishedAt are the LatestReleaseTag and LatestReleaseAt of a
+// repository whose latest release is r, which may be nil.
+func (r *release) tag() string {
+	if r == nil {
+		return ""
+	}
+	return r.Tag
+}
+
+func (r *release) publishedAt() *time.Time {
+	if r == nil {
+		return nil
+	}
+	return optionalTime(r.PublishedAt)
+}
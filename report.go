@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	htmltemplate "html/template"
+	"io"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	reportFormatMarkdown = "markdown"
+	reportFormatHTML     = "html"
+
+	// reportTopCount is how many coins each ranking of a report lists.
+	reportTopCount = 10
+)
+
+type (
+	// digest is what a report tells about the coins over its period: the
+	// coins whose repositories got the most commits and new contributors,
+	// and the repositories without a commit during the period.
+	digest struct {
+		Title        string
+		Since        time.Time
+		Until        time.Time
+		Commits      []coinChange
+		Contributors []coinChange
+		Stalled      []stalledRepository
+	}
+
+	// coinChange sums the changes of the repositories of a coin that have a
+	// snapshot from before the period.
+	coinChange struct {
+		Symbol       string
+		Name         string
+		Commits      int
+		Contributors int
+		Stars        int
+	}
+
+	stalledRepository struct {
+		Symbol       string
+		Repo         string
+		LastCommitAt time.Time
+	}
+)
+
+// reportSince is when a report of period ending at now starts.
+func reportSince(period string, now time.Time) (time.Time, error) {
+	switch period {
+	case growthPeriodWeek:
+		return now.AddDate(0, 0, -7), nil
+	case growthPeriodMonth:
+		return now.AddDate(0, -1, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("period must be %q or %q, got %q", growthPeriodWeek, growthPeriodMonth, period)
+}
+
+// buildDigest compares the active repositories with their latest snapshot
+// captured before the period. Repositories without such a snapshot are left
+// out of the rankings, and those whose last commit date is unknown out of
+// the stalled list.
+func buildDigest(db *gorm.DB, period string, now time.Time) (digest, error) {
+	since, err := reportSince(period, now)
+	if err != nil {
+		return digest{}, err
+	}
+	d := digest{Title: strings.ToUpper(period[:1]) + period[1:] + "ly digest", Since: since, Until: now}
+
+	var repos []Repository
+	if err := db.Preload("Coin").Where("status = ?", statusActive).Order("id").Find(&repos).Error; err != nil {
+		return digest{}, err
+	}
+
+	byCoin := map[int]*coinChange{}
+	for _, repo := range repos {
+		if repo.LastCommitAt != nil && repo.LastCommitAt.Before(since) {
+			d.Stalled = append(d.Stalled, stalledRepository{Symbol: repo.Coin.Symbol, Repo: repoName(repo), LastCommitAt: *repo.LastCommitAt})
+		}
+
+		var base RepositorySnapshot
+		err := db.Where("repository_id = ? AND captured_at <= ? AND backfilled = ?", repo.Id, since, false).
+			Order("captured_at DESC").
+			First(&base).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return digest{}, err
+		}
+		c, ok := byCoin[repo.CoinId]
+		if !ok {
+			c = &coinChange{Symbol: repo.Coin.Symbol, Name: repo.Coin.Name}
+			byCoin[repo.CoinId] = c
+		}
+		c.Commits += repo.CommitsCount - base.CommitsCount
+		c.Contributors += repo.ContributorsCount - base.ContributorsCount
+		c.Stars += repo.StargazersCount - base.StargazersCount
+	}
+
+	var changes []coinChange
+	for _, c := range byCoin {
+		changes = append(changes, *c)
+	}
+	d.Commits = topChanges(changes, func(c coinChange) int { return c.Commits })
+	d.Contributors = topChanges(changes, func(c coinChange) int { return c.Contributors })
+	sort.Slice(d.Stalled, func(i, j int) bool { return d.Stalled[i].LastCommitAt.Before(d.Stalled[j].LastCommitAt) })
+	return d, nil
+}
+
+// topChanges returns the reportTopCount coins with the largest positive
+// value, largest first and ties broken by symbol.
+func topChanges(changes []coinChange, value func(coinChange) int) []coinChange {
+	var top []coinChange
+	for _, c := range changes {
+		if value(c) > 0 {
+			top = append(top, c)
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if value(top[i]) != value(top[j]) {
+			return value(top[i]) > value(top[j])
+		}
+		return top[i].Symbol < top[j].Symbol
+	})
+	if len(top) > reportTopCount {
+		top = top[:reportTopCount]
+	}
+	return top
+}
+
+const markdownReport = `# {{.Title}}
+
+{{date .Since}} to {{date .Until}}
+
+## Top coins by commits
+
+{{if .Commits}}| Coin | Commits | Stars |
+| --- | ---: | ---: |
+{{range .Commits}}| {{.Symbol}} ({{.Name}}) | +{{.Commits}} | {{printf "%+d" .Stars}} |
+{{end}}{{else}}No coin gained commits.
+{{end}}
+## New contributors
+
+{{if .Contributors}}| Coin | Contributors |
+| --- | ---: |
+{{range .Contributors}}| {{.Symbol}} ({{.Name}}) | +{{.Contributors}} |
+{{end}}{{else}}No coin gained contributors.
+{{end}}
+## Stalled repositories
+
+{{if .Stalled}}| Coin | Repository | Last commit |
+| --- | --- | --- |
+{{range .Stalled}}| {{.Symbol}} | {{.Repo}} | {{date .LastCommitAt}} |
+{{end}}{{else}}Every repository got commits.
+{{end}}`
+
+const htmlReport = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{date .Since}} to {{date .Until}}</p>
+<h2>Top coins by commits</h2>
+{{if .Commits}}<table>
+<tr><th>Coin</th><th>Commits</th><th>Stars</th></tr>
+{{range .Commits}}<tr><td>{{.Symbol}} ({{.Name}})</td><td>+{{.Commits}}</td><td>{{printf "%+d" .Stars}}</td></tr>
+{{end}}</table>
+{{else}}<p>No coin gained commits.</p>
+{{end}}<h2>New contributors</h2>
+{{if .Contributors}}<table>
+<tr><th>Coin</th><th>Contributors</th></tr>
+{{range .Contributors}}<tr><td>{{.Symbol}} ({{.Name}})</td><td>+{{.Contributors}}</td></tr>
+{{end}}</table>
+{{else}}<p>No coin gained contributors.</p>
+{{end}}<h2>Stalled repositories</h2>
+{{if .Stalled}}<table>
+<tr><th>Coin</th><th>Repository</th><th>Last commit</th></tr>
+{{range .Stalled}}<tr><td>{{.Symbol}}</td><td>{{.Repo}}</td><td>{{date .LastCommitAt}}</td></tr>
+{{end}}</table>
+{{else}}<p>Every repository got commits.</p>
+{{end}}</body>
+</html>
+`
+
+func reportDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// renderDigest writes d as Markdown or, escaping the coin and repository
+// names, as HTML.
+func renderDigest(w io.Writer, d digest, format string) error {
+	switch format {
+	case reportFormatMarkdown:
+		t := texttemplate.Must(texttemplate.New("report").Funcs(texttemplate.FuncMap{"date": reportDate}).Parse(markdownReport))
+		return t.Execute(w, d)
+	case reportFormatHTML:
+		t := htmltemplate.Must(htmltemplate.New("report").Funcs(htmltemplate.FuncMap{"date": reportDate}).Parse(htmlReport))
+		return t.Execute(w, d)
+	}
+	return fmt.Errorf("format must be %q or %q, got %q", reportFormatMarkdown, reportFormatHTML, format)
+}
+
+// mailReport sends the rendered report to the [SMTP] recipients.
+func mailReport(config SMTPConfig, subject, format string, body []byte) error {
+	contentType := "text/plain"
+	if format == reportFormatHTML {
+		contentType = "text/html"
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=UTF-8\r\n\r\n", contentType)
+	msg.Write(body)
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+	return smtp.SendMail(fmt.Sprintf("%s:%d", config.Host, config.smtpPort()), auth, config.From, config.To, msg.Bytes())
+}
+
+func runReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	addConfigFlag(fs)
+	period := fs.String("period", growthPeriodWeek, "period the digest covers: week or month")
+	format := fs.String("format", reportFormatMarkdown, "output format: markdown or html")
+	out := fs.String("out", "", "file to write the digest to, default stdout")
+	email := fs.Bool("email", false, "also email the digest to the [SMTP] recipients")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	if *format != reportFormatMarkdown && *format != reportFormatHTML {
+		fatal("Invalid format.", "format", *format)
+	}
+	config := loadConfig()
+	if *email && config.SMTP.Host == "" {
+		fatal("--email needs the [SMTP] host.")
+	}
+	db := dbConnect(config)
+	defer closeDB(db)
+
+	d, err := buildDigest(db, *period, clock.Now())
+	if err != nil {
+		fatal("Failed to build the digest.", "error", err)
+	}
+	var body bytes.Buffer
+	if err := renderDigest(&body, d, *format); err != nil {
+		fatal("Failed to render the digest.", "error", err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(body.Bytes())
+	} else if err := os.WriteFile(*out, body.Bytes(), 0o644); err != nil {
+		fatal("Failed to write the digest.", "path", *out, "error", err)
+	}
+	if *email {
+		subject := fmt.Sprintf("%s, %s to %s", d.Title, reportDate(d.Since), reportDate(d.Until))
+		if err := mailReport(config.SMTP, subject, *format, body.Bytes()); err != nil {
+			fatal("Failed to email the digest.", "error", err)
+		}
+	}
+}
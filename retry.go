@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = time.Minute
+)
+
+// retryTransport retries requests that failed for transient reasons with
+// exponential backoff and jitter. A Retry-After header always wins over the
+// computed delay.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		res, err := t.base.RoundTrip(r)
+		if attempt >= t.maxAttempts || !retryable(res, err) {
+			return res, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			// The body was consumed and cannot be replayed.
+			return res, err
+		}
+
+		delay := backoff(attempt)
+		if res != nil {
+			if d, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+				delay = d
+			}
+			res.Body.Close()
+		}
+		slog.Warn("Retrying request.", "method", req.Method, "url", req.URL.String(), "delay_ms", delay.Milliseconds(), "attempt", attempt+1, "max_attempts", t.maxAttempts, "reason", retryReason(res, err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a response is worth another attempt: network
+// errors, server errors, and primary or secondary (abuse) rate limits.
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusInternalServerError:
+		return true
+	case http.StatusForbidden:
+		if res.Header.Get("Retry-After") != "" || res.Header.Get("X-RateLimit-Remaining") == "0" {
+			return true
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return bytes.Contains(body, []byte("abuse")) || bytes.Contains(body, []byte("secondary rate limit"))
+	}
+	return false
+}
+
+func retryReason(res *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return res.Status
+}
+
+// backoff returns a delay in [d/2, d) where d doubles with every attempt.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << uint(attempt-1)
+	if d <= 0 || d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
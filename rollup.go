@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// refreshCoinStats recomputes the coin_stats row of every coin that has at
+// least one repository.
+func refreshCoinStats(db *gorm.DB, now time.Time) error {
+	var stats []CoinStat
+	err := db.Model(&Repository{}).
+		Select(`coin_id,
+			COUNT(*) AS repositories_count,
+			SUM(pull_requests_count) AS pull_requests_count,
+			SUM(watchers_count) AS watchers_count,
+			SUM(stargazers_count) AS stargazers_count,
+			SUM(issues_count) AS issues_count,
+			SUM(commits_count_for_the_last_week) AS commits_count_for_the_last_week,
+			SUM(commits_count_for_the_last_month) AS commits_count_for_the_last_month,
+			SUM(commits_count) AS commits_count,
+			SUM(contributors_count) AS contributors_count`).
+		Group("coin_id").
+		Scan(&stats).Error
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	for _, s := range stats {
+		var existing CoinStat
+		err := tx.Where("coin_id = ?", s.CoinId).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			return err
+		}
+		s.Id = existing.Id
+		s.UpdatedAt = now
+		if err := tx.Save(&s).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
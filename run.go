@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log/slog"
+	"time"
+)
+
+func startRun(db *gorm.DB, now time.Time) (*Run, error) {
+	run := &Run{StartedAt: now}
+	err := db.Create(run).Error
+	return run, err
+}
+
+// record counts the outcome of one repository.
+func (r *Run) record(res result) {
+	r.ReposProcessed++
+	if res.Err == nil {
+		return
+	}
+	switch errorKind(res.Err) {
+	case errorKindAPI:
+		r.ApiErrors++
+	case errorKindScrape:
+		r.ScrapeErrors++
+	default:
+		r.OtherErrors++
+	}
+}
+
+// failures is the number of repositories that could not be collected.
+func (r *Run) failures() int {
+	return r.ApiErrors + r.ScrapeErrors + r.OtherErrors
+}
+
+// finish records the end of the run. The progress of a complete run is no
+// longer needed for resuming, so it is dropped.
+func (r *Run) finish(db *gorm.DB, interrupted bool) error {
+	now := clock.Now()
+	r.FinishedAt = &now
+	r.Interrupted = interrupted
+	if err := db.Save(r).Error; err != nil {
+		return err
+	}
+	if interrupted {
+		return nil
+	}
+	return db.Where("run_id = ?", r.Id).Delete(&RunRepository{}).Error
+}
+
+func markProcessed(db *gorm.DB, runId, repositoryId int) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&RunRepository{RunId: runId, RepositoryId: repositoryId}).Error
+}
+
+// resumeRun reopens the latest run when it was interrupted or crashed and
+// drops the repositories it already processed from repos. It returns a nil
+// run when there is nothing to resume, leaving repos as they are.
+func resumeRun(db *gorm.DB, repos []Repository) (*Run, []Repository, error) {
+	var run Run
+	err := db.Order("id DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repos, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if run.FinishedAt != nil && !run.Interrupted {
+		slog.Info("The last run completed, starting a new one.", "run_id", run.Id)
+		return nil, repos, nil
+	}
+
+	var done []int
+	if err := db.Model(&RunRepository{}).Where("run_id = ?", run.Id).Pluck("repository_id", &done).Error; err != nil {
+		return nil, nil, err
+	}
+	skip := make(map[int]bool, len(done))
+	for _, id := range done {
+		skip[id] = true
+	}
+	var rest []Repository
+	for _, repo := range repos {
+		if !skip[repo.Id] {
+			rest = append(rest, repo)
+		}
+	}
+
+	run.FinishedAt = nil
+	run.Interrupted = false
+	if err := db.Save(&run).Error; err != nil {
+		return nil, nil, err
+	}
+	slog.Info("Resuming the run.", "run_id", run.Id, "processed", len(done))
+	return &run, rest, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// cronLogger sends the scheduler's own messages to slog, logging the
+// informational ones at level.
+type cronLogger struct {
+	level slog.Level
+}
+
+func (l cronLogger) Info(msg string, keysAndValues ...interface{}) {
+	slog.Log(context.Background(), l.level, "Scheduler: "+msg, keysAndValues...)
+}
+
+func (cronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	slog.Error("Scheduler: "+msg, append(keysAndValues, "error", err)...)
+}
+
+// runScheduled calls run on every tick of the cron spec until SIGINT or
+// SIGTERM. A tick that comes while the previous run is still going is
+// skipped, so slow runs never overlap.
+func runScheduled(spec string, run func()) {
+	// Every wake-up is only worth a debug line, but a skipped run is a warning.
+	c := cron.New(
+		cron.WithLogger(cronLogger{level: slog.LevelDebug}),
+		cron.WithChain(cron.SkipIfStillRunning(cronLogger{level: slog.LevelWarn})),
+	)
+	id, err := c.AddFunc(spec, run)
+	if err != nil {
+		fatal("Invalid schedule.", "schedule", spec, "error", err)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	c.Start()
+	slog.Info("Scheduled the collector.", "schedule", spec, "next_run", c.Entry(id).Next)
+	<-sig
+	slog.Info("Stopping the scheduler, waiting for the current run to finish.")
+	<-c.Stop().Done()
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// The tables that later migrations change, as each migration declares them.
+// AutoMigrate creates every column and index of the struct it is given, so a
+// migration migrating the current model would create what the later ones add
+// ahead of them, skipping their checks on a fresh DB. Each struct only holds
+// what its migration adds to the table, and must never change.
+type (
+	coin001 struct {
+		Id        int `gorm:"primaryKey"`
+		Name      string
+		Symbol    string
+		Owner     string
+		UpdatedAt time.Time
+		CreatedAt time.Time
+	}
+
+	repository001 struct {
+		Id                          int    `gorm:"primaryKey"`
+		CoinId                      int    `gorm:"index:idx_repositories_coin_id"`
+		Provider                    string `gorm:"default:'github'"`
+		Name                        string
+		Language                    string
+		PullRequestsCount           int
+		WatchersCount               int
+		StargazersCount             int
+		IssuesCount                 int
+		CommitsCountForTheLastWeek  int
+		CommitsCountForTheLastMonth int
+		CommitsCount                int
+		ContributorsCount           int
+		UpdatedAt                   time.Time
+		CreatedAt                   time.Time
+	}
+
+	repositorySnapshot002 struct {
+		Id                          int `gorm:"primaryKey"`
+		RepositoryId                int `gorm:"index"`
+		Language                    string
+		PullRequestsCount           int
+		WatchersCount               int
+		StargazersCount             int
+		IssuesCount                 int
+		CommitsCountForTheLastWeek  int
+		CommitsCountForTheLastMonth int
+		CommitsCount                int
+		ContributorsCount           int
+		CapturedAt                  time.Time `gorm:"index"`
+	}
+
+	run004 struct {
+		Id                 int `gorm:"primaryKey"`
+		StartedAt          time.Time
+		FinishedAt         *time.Time
+		Interrupted        bool
+		ReposProcessed     int
+		ApiErrors          int
+		ScrapeErrors       int
+		OtherErrors        int
+		RateLimitRemaining int
+	}
+
+	repositorySnapshot004 struct {
+		RunId int `gorm:"index"`
+	}
+
+	repository006 struct {
+		Status string `gorm:"default:'active';index"`
+	}
+
+	// Both tables get the three counts.
+	repository007 struct {
+		ForksCount    int
+		ReleasesCount int
+		TagsCount     int
+	}
+	repositorySnapshot007 repository007
+
+	// Both tables get the per-state counts.
+	repository008 struct {
+		OpenPullRequestsCount   int
+		ClosedPullRequestsCount int
+		MergedPullRequestsCount int
+		OpenIssuesCount         int
+		ClosedIssuesCount       int
+	}
+	repositorySnapshot008 repository008
+
+	repository016 struct {
+		BaseURL string
+	}
+
+	repository017 struct {
+		AllBranches bool
+	}
+
+	repository018 struct {
+		DefaultBranch string
+		LastCommitAt  *time.Time
+	}
+
+	repository020 struct {
+		License string
+	}
+
+	repository021 struct {
+		LatestReleaseTag              string
+		LatestReleaseAt               *time.Time
+		ReleasesCountForTheLast90Days int
+	}
+
+	repository022 struct {
+		MedianIssueCloseSeconds       int
+		MedianPullRequestMergeSeconds int
+	}
+
+	repositorySnapshot023 struct {
+		Backfilled bool `gorm:"not null;default:false"`
+	}
+
+	repository024 struct {
+		ETag         string `gorm:"column:etag"`
+		LastModified string
+	}
+
+	repository025 struct {
+		LastRunId int `gorm:"default:0"`
+	}
+
+	// Both tables get the flags.
+	coin026 struct {
+		Active    bool           `gorm:"default:true"`
+		DeletedAt gorm.DeletedAt `gorm:"index"`
+	}
+	repository026 coin026
+
+	coin027 struct {
+		Symbol string `gorm:"index"`
+	}
+
+	repository027 struct {
+		CoinId    int       `gorm:"index:idx_repositories_coin_id;uniqueIndex:idx_repositories_coin_id_name"`
+		Name      string    `gorm:"size:191;uniqueIndex:idx_repositories_coin_id_name"`
+		UpdatedAt time.Time `gorm:"index"`
+	}
+
+	coin028 struct {
+		CoingeckoId   string
+		MarketCapRank int
+		Homepage      string
+	}
+
+	run031 struct {
+		Anomalies int
+	}
+
+	repository032 struct {
+		SignedCommitsPercentage *float64
+	}
+
+	repository033 struct {
+		HasSecurityPolicy      *bool
+		DefaultBranchProtected *bool
+		DependabotConfigured   *bool
+	}
+)
+
+func (coin001) TableName() string { return "coins" }
+func (coin026) TableName() string { return "coins" }
+func (coin027) TableName() string { return "coins" }
+func (coin028) TableName() string { return "coins" }
+
+func (repository001) TableName() string { return "repositories" }
+func (repository006) TableName() string { return "repositories" }
+func (repository007) TableName() string { return "repositories" }
+func (repository008) TableName() string { return "repositories" }
+func (repository016) TableName() string { return "repositories" }
+func (repository017) TableName() string { return "repositories" }
+func (repository018) TableName() string { return "repositories" }
+func (repository020) TableName() string { return "repositories" }
+func (repository021) TableName() string { return "repositories" }
+func (repository022) TableName() string { return "repositories" }
+func (repository024) TableName() string { return "repositories" }
+func (repository025) TableName() string { return "repositories" }
+func (repository026) TableName() string { return "repositories" }
+func (repository027) TableName() string { return "repositories" }
+func (repository032) TableName() string { return "repositories" }
+func (repository033) TableName() string { return "repositories" }
+
+func (repositorySnapshot002) TableName() string { return "repository_snapshots" }
+func (repositorySnapshot004) TableName() string { return "repository_snapshots" }
+func (repositorySnapshot007) TableName() string { return "repository_snapshots" }
+func (repositorySnapshot008) TableName() string { return "repository_snapshots" }
+func (repositorySnapshot023) TableName() string { return "repository_snapshots" }
+
+func (run004) TableName() string { return "runs" }
+func (run031) TableName() string { return "runs" }
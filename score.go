@@ -0,0 +1,174 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// scoreComponents are the per-coin inputs of the activity score, measured
+// over the last 30 days.
+type scoreComponents struct {
+	Commits      float64
+	Contributors float64
+	StarsGained  float64
+	PullRequests float64
+}
+
+// enabled reports whether any weight is set, since a run without weights
+// has nothing to rank by.
+func (w ScoreConfig) enabled() bool {
+	return w.Commits != 0 || w.Contributors != 0 || w.Stars != 0 || w.PullRequests != 0
+}
+
+// refreshCoinScores writes one coin_scores row per active coin for the run,
+// replacing the ones a resumed run wrote before. Each component is divided
+// by its maximum across coins before the weights are applied, so no metric
+// dominates just because of its scale.
+func refreshCoinScores(db *gorm.DB, runId int, weights ScoreConfig, now time.Time) error {
+	if !weights.enabled() {
+		return nil
+	}
+	components, err := coinScoreComponents(db, now)
+	if err != nil {
+		return err
+	}
+
+	var max scoreComponents
+	for _, c := range components {
+		max.Commits = maxFloat(max.Commits, c.Commits)
+		max.Contributors = maxFloat(max.Contributors, c.Contributors)
+		max.StarsGained = maxFloat(max.StarsGained, c.StarsGained)
+		max.PullRequests = maxFloat(max.PullRequests, c.PullRequests)
+	}
+
+	scores := make([]CoinScore, 0, len(components))
+	for coinId, c := range components {
+		scores = append(scores, CoinScore{
+			RunId:  runId,
+			CoinId: coinId,
+			Score: weights.Commits*ratio(c.Commits, max.Commits) +
+				weights.Contributors*ratio(c.Contributors, max.Contributors) +
+				weights.Stars*ratio(c.StarsGained, max.StarsGained) +
+				weights.PullRequests*ratio(c.PullRequests, max.PullRequests),
+			CommitsCount:      int(c.Commits),
+			ContributorsCount: int(c.Contributors),
+			StarsGained:       int(c.StarsGained),
+			PullRequestsCount: int(c.PullRequests),
+			CreatedAt:         now,
+		})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].CoinId < scores[j].CoinId
+	})
+
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("run_id = ?", runId).Delete(&CoinScore{}).Error; err != nil {
+			return err
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&scores, defaultWriteBatch).Error
+	})
+}
+
+// coinScoreComponents gathers the score inputs of every active coin. Stars
+// gained and pull requests opened are the growth since the oldest snapshot
+// of each repository within the last 30 days.
+func coinScoreComponents(db *gorm.DB, now time.Time) (map[int]*scoreComponents, error) {
+	var active []int
+	if err := db.Model(&Coin{}).Where("active = ?", true).Pluck("id", &active).Error; err != nil {
+		return nil, err
+	}
+	isActive := make(map[int]bool, len(active))
+	for _, id := range active {
+		isActive[id] = true
+	}
+	components := map[int]*scoreComponents{}
+	of := func(coinId int) *scoreComponents {
+		// Rows of inactive and removed coins are counted into nothing.
+		if !isActive[coinId] {
+			return &scoreComponents{}
+		}
+		if components[coinId] == nil {
+			components[coinId] = &scoreComponents{}
+		}
+		return components[coinId]
+	}
+
+	var stats []CoinStat
+	if err := db.Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range stats {
+		c := of(s.CoinId)
+		c.Commits = float64(s.CommitsCountForTheLastMonth)
+	}
+
+	var contributors []struct {
+		CoinId int
+		Count  int
+	}
+	err := db.Table("repository_contributors").
+		Select("repositories.coin_id, COUNT(DISTINCT repository_contributors.author) AS count").
+		Joins("JOIN repositories ON repositories.id = repository_contributors.repository_id").
+		Where("repository_contributors.commits_count_for_the_last30_days > 0").
+		Group("repositories.coin_id").
+		Scan(&contributors).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range contributors {
+		of(r.CoinId).Contributors = float64(r.Count)
+	}
+
+	var repos []Repository
+	if err := db.Select("id, coin_id, stargazers_count, pull_requests_count").Find(&repos).Error; err != nil {
+		return nil, err
+	}
+	var snapshots []RepositorySnapshot
+	err = db.Select("repository_id, stargazers_count, pull_requests_count").
+		Where("captured_at >= ? AND backfilled = ?", now.AddDate(0, 0, -30), false).
+		Order("captured_at").
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	oldest := map[int]RepositorySnapshot{}
+	for _, s := range snapshots {
+		if _, ok := oldest[s.RepositoryId]; !ok {
+			oldest[s.RepositoryId] = s
+		}
+	}
+	for _, repo := range repos {
+		s, ok := oldest[repo.Id]
+		if !ok {
+			continue
+		}
+		c := of(repo.CoinId)
+		c.StarsGained += float64(repo.StargazersCount - s.StargazersCount)
+		c.PullRequests += float64(repo.PullRequestsCount - s.PullRequestsCount)
+	}
+	return components, nil
+}
+
+func ratio(v, max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return v / max
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type (
+	// scrapeSettings are the strategies of the [Scrape] section, with the
+	// defaults filled in.
+	scrapeSettings struct {
+		Commits      []ScrapeSelector
+		Contributors []ScrapeSelector
+	}
+
+	// pageFetcher returns the HTML of a web page.
+	pageFetcher interface {
+		fetch(ctx context.Context, url string) (*goquery.Document, error)
+	}
+
+	// staticPages fetches pages as the server sends them.
+	staticPages struct{ client *http.Client }
+
+	// scraper reads the counts from the pages of its fetchers, trying the
+	// next one when a page has none: the static page first, then the
+	// rendered one when the browser is enabled.
+	scraper struct {
+		pages     []pageFetcher
+		selectors scrapeSettings
+		browser   *headlessBrowser
+	}
+)
+
+func newScraper(config ScrapeConfig, h *httpClients, hc HTTPConfig) scraper {
+	s := scraper{
+		pages:     []pageFetcher{staticPages{h.client("scrape")}},
+		selectors: newScrapeSettings(config),
+	}
+	if config.Browser {
+		s.browser = newHeadlessBrowser(config, hc)
+		s.pages = append(s.pages, s.browser)
+	}
+	return s
+}
+
+func (s scraper) close() {
+	if s.browser != nil {
+		s.browser.close()
+	}
+}
+
+// The built-in strategies, tried when [Scrape] lists none. The last ones
+// read the older layout where every count was a span.text-emphasized.
+var (
+	defaultCommitsSelectors = []ScrapeSelector{
+		{Selector: "span.d-sm-inline strong", Index: -1},
+		{Selector: "span.text-emphasized", Index: 0},
+	}
+	defaultContributorsSelectors = []ScrapeSelector{
+		{Selector: "div.BorderGrid-cell span.Counter", Index: -1},
+		{Selector: "span.text-emphasized", Index: 4},
+	}
+)
+
+func newScrapeSettings(config ScrapeConfig) scrapeSettings {
+	s := scrapeSettings{Commits: config.Commits, Contributors: config.Contributors}
+	if len(s.Commits) == 0 {
+		s.Commits = defaultCommitsSelectors
+	}
+	if len(s.Contributors) == 0 {
+		s.Contributors = defaultContributorsSelectors
+	}
+	return s
+}
+
+// counts reads the commits and contributors counts from the repository page
+// under webURL. It is only used when the API cannot provide them.
+func (s scraper) counts(ctx context.Context, webURL, owner, name string) (int, int, error) {
+	repo := owner + "/" + name
+	for i, pages := range s.pages {
+		if i > 0 {
+			slog.Info("No counts on the page, rendering it in the browser.", "repo", repo)
+		}
+		doc, err := pages.fetch(ctx, webURL+"/"+repo)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		// A missing commits count is left at zero, as only the
+		// contributors count has no other source.
+		commitsCount, _ := scrapeCount(doc, s.selectors.Commits, repo, "commits")
+		if contributorsCount, ok := scrapeCount(doc, s.selectors.Contributors, repo, "contributors"); ok {
+			return commitsCount, contributorsCount, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("contributors count not found")
+}
+
+// scrapeCount tries the strategies in order and returns the count the first
+// one finds. A later strategy matching is logged louder, as it usually means
+// the page layout changed.
+func scrapeCount(doc *goquery.Document, strategies []ScrapeSelector, repo, count string) (int, bool) {
+	for i, s := range strategies {
+		n, ok := s.find(doc)
+		if !ok {
+			continue
+		}
+		level := slog.LevelDebug
+		if i > 0 {
+			level = slog.LevelWarn
+		}
+		slog.Log(context.Background(), level, "Scraped a count.", "repo", repo, "count", count, "strategy", i, "selector", s.Selector, "index", s.Index)
+		return n, true
+	}
+	return 0, false
+}
+
+// find returns the number of the Index-th element matching the selector
+// whose text is a number. A negative Index counts from the last one.
+func (s ScrapeSelector) find(doc *goquery.Document) (int, bool) {
+	var numbers []int
+	doc.Find(s.Selector).Each(func(_ int, sel *goquery.Selection) {
+		text := strings.ReplaceAll(strings.TrimSpace(sel.Text()), ",", "")
+		if n, err := strconv.Atoi(text); err == nil {
+			numbers = append(numbers, n)
+		}
+	})
+	i := s.Index
+	if i < 0 {
+		i += len(numbers)
+	}
+	if i < 0 || i >= len(numbers) {
+		return 0, false
+	}
+	return numbers[i], true
+}
+
+func (p staticPages) fetch(ctx context.Context, url string) (*goquery.Document, error) {
+	return fetchDocument(ctx, p.client, url)
+}
+
+func fetchDocument(ctx context.Context, client *http.Client, url string) (*goquery.Document, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, res.Status)
+	}
+	return goquery.NewDocumentFromReader(res.Body)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	secretsProviderEnv   = "env"
+	secretsProviderVault = "vault"
+	secretsProviderSSM   = "ssm"
+
+	secretsTimeout = 30 * time.Second
+
+	// ssmMaxParameters is the most parameters one GetParameters call takes.
+	ssmMaxParameters = 10
+)
+
+// secretNames are the secrets the collector reads. They are looked up under
+// the same names as the environment variables they replace. There are more
+// than ssmMaxParameters of them, so SSM is asked in batches.
+var secretNames = []string{
+	"DB_PASSWORD", "GITHUB_TOKEN", "GITHUB_APP_PRIVATE_KEY", "GITHUB_WEBHOOK_SECRET",
+	"GITLAB_TOKEN", "BITBUCKET_TOKEN", "GITEA_TOKEN", "SMTP_PASSWORD",
+	"INFLUXDB_TOKEN", "TIMESCALE_DSN", "REDIS_PASSWORD", "COINGECKO_API_KEY",
+}
+
+// loadSecrets fetches secretNames from the configured provider. A secret the
+// provider does not hold falls back to its environment variable, so a single
+// one can still be overridden locally.
+func loadSecrets(config SecretsConfig) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	var secrets map[string]string
+	var err error
+	switch config.Provider {
+	case "", secretsProviderEnv:
+		secrets = map[string]string{}
+	case secretsProviderVault:
+		secrets, err = vaultSecrets(ctx, config)
+	case secretsProviderSSM:
+		secrets, err = ssmSecrets(ctx, config)
+	default:
+		err = fmt.Errorf("provider %q is not supported, use %q, %q or %q", config.Provider, secretsProviderEnv, secretsProviderVault, secretsProviderSSM)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range secretNames {
+		if secrets[name] == "" {
+			secrets[name] = os.Getenv(name)
+		}
+	}
+	return secrets, nil
+}
+
+// vaultSecrets reads Path from a KV secrets engine, authenticating with
+// VAULT_TOKEN. Both KV version 1 and version 2 paths are understood.
+func vaultSecrets(ctx context.Context, config SecretsConfig) (map[string]string, error) {
+	addr := config.VaultAddr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" || config.Path == "" {
+		return nil, errors.New("vault needs vaultAddr (or VAULT_ADDR), VAULT_TOKEN and path")
+	}
+
+	endpoint := strings.TrimSuffix(addr, "/") + "/v1/" + strings.TrimPrefix(config.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault answered %s for %s", resp.Status, config.Path)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	// KV version 2 nests the values in data.data next to data.metadata.
+	data := body.Data
+	if nested, ok := data["data"]; ok {
+		if err := json.Unmarshal(nested, &data); err != nil {
+			return nil, err
+		}
+	}
+	secrets := map[string]string{}
+	for _, name := range secretNames {
+		if raw, ok := data[name]; ok {
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("vault secret %s is not a string", name)
+			}
+			secrets[name] = value
+		}
+	}
+	return secrets, nil
+}
+
+// ssmSecrets reads the SecureString parameters Path/<name> from AWS Systems
+// Manager Parameter Store with the default AWS credentials chain, up to
+// ssmMaxParameters at a time.
+func ssmSecrets(ctx context.Context, config SecretsConfig) (map[string]string, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(config.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS config: %w", err)
+	}
+	prefix := strings.TrimSuffix(config.Path, "/") + "/"
+	names := make([]string, len(secretNames))
+	for i, name := range secretNames {
+		names[i] = prefix + name
+	}
+
+	client := ssm.NewFromConfig(cfg)
+	secrets := map[string]string{}
+	for len(names) > 0 {
+		batch := names[:min(len(names), ssmMaxParameters)]
+		names = names[len(batch):]
+		out, err := client.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          batch,
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range out.Parameters {
+			secrets[strings.TrimPrefix(aws.ToString(p.Name), prefix)] = aws.ToString(p.Value)
+		}
+	}
+	return secrets, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/shurcooL/githubv4"
+	"net/http"
+)
+
+// dependabotConfigs are the paths GitHub reads the Dependabot config from.
+var dependabotConfigs = []string{".github/dependabot.yml", ".github/dependabot.yaml"}
+
+// securityPolicyQuery asks whether a repository has a security policy,
+// either its own SECURITY.md or one inherited from its owner's .github
+// repository.
+type securityPolicyQuery struct {
+	Repository struct {
+		IsSecurityPolicyEnabled bool
+	} `graphql:"repository(owner: $owner, name: $name)"`
+	RateLimit rateLimit
+}
+
+// securityCollector reads the security posture of GitHub repositories. It
+// only supplements the metrics the github collector found, so it has to be
+// enabled by name.
+type securityCollector struct{ c *clients }
+
+func (securityCollector) Name() string     { return collectorSecurity }
+func (securityCollector) Provider() string { return providerGitHub }
+
+func (s securityCollector) Collect(ctx context.Context, coin Coin, repo Repository) (Metrics, error) {
+	loc := repositoryLocation{Provider: providerGitHub, Owner: coin.Owner, Name: repo.Name}
+	client := s.c.github
+	policy, err := client.hasSecurityPolicy(ctx, loc.Owner, loc.Name)
+	if err != nil {
+		return Metrics{Location: loc}, apiError(err)
+	}
+	columns := []string{"has_security_policy", "dependabot_configured"}
+	var protected *bool
+	if repo.DefaultBranch != "" {
+		p, err := client.branchProtected(ctx, loc.Owner, loc.Name, repo.DefaultBranch)
+		if err != nil {
+			return Metrics{Location: loc}, apiError(err)
+		}
+		protected = &p
+		columns = append(columns, "default_branch_protected")
+	}
+	dependabot := false
+	for _, path := range dependabotConfigs {
+		if dependabot, err = client.fileExists(ctx, loc.Owner, loc.Name, path); err != nil {
+			return Metrics{Location: loc}, apiError(err)
+		}
+		if dependabot {
+			break
+		}
+	}
+	return Metrics{
+		Values: Repository{
+			HasSecurityPolicy:      &policy,
+			DefaultBranchProtected: protected,
+			DependabotConfigured:   &dependabot,
+		},
+		Location: loc,
+		Columns:  columns,
+	}, nil
+}
+
+// supplement adds the security posture of the repository at m.Location to
+// m.
+func (s securityCollector) supplement(ctx context.Context, m *Metrics) error {
+	found, err := s.Collect(ctx, Coin{Owner: m.Location.Owner}, Repository{Name: m.Location.Name, DefaultBranch: m.Values.DefaultBranch})
+	if err != nil {
+		return err
+	}
+	m.Values.HasSecurityPolicy = found.Values.HasSecurityPolicy
+	m.Values.DefaultBranchProtected = found.Values.DefaultBranchProtected
+	m.Values.DependabotConfigured = found.Values.DependabotConfigured
+	// The columns of the collector are shared by its every result.
+	m.Columns = append(m.Columns[:len(m.Columns):len(m.Columns)], found.Columns...)
+	return nil
+}
+
+func (c *githubClient) hasSecurityPolicy(ctx context.Context, owner, name string) (bool, error) {
+	var query securityPolicyQuery
+	variables := map[string]interface{}{
+		"owner": githubv4.String(owner),
+		"name":  githubv4.String(name),
+	}
+	if err := c.budget.wait(ctx); err != nil {
+		return false, err
+	}
+	if err := c.Query(ctx, &query, variables); err != nil {
+		return false, err
+	}
+	c.budget.update(query.RateLimit)
+	return query.Repository.IsSecurityPolicyEnabled, nil
+}
+
+// branchProtected reports whether the branch has protection rules. Reading
+// the rules themselves takes admin access, the flag does not.
+func (c *githubClient) branchProtected(ctx context.Context, owner, name, branch string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/branches/%s", c.apiURL, owner, name, branch)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("GET %s: %s", endpoint, res.Status)
+	}
+
+	var body struct {
+		Protected bool `json:"protected"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return false, err
+	}
+	return body.Protected, nil
+}
+
+// fileExists reports whether the default branch of the repository has a
+// file at path.
+func (c *githubClient) fileExists(ctx context.Context, owner, name, path string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/contents/%s", c.apiURL, owner, name, path)
+	req, err := http.NewRequest(http.MethodHead, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("HEAD %s: %s", endpoint, res.Status)
+}
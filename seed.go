@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// seedCoin is one coin of a seed file. Repositories are names under Owner,
+// owner/name pairs or full URLs.
+type seedCoin struct {
+	Symbol       string   `json:"symbol"`
+	Name         string   `json:"name"`
+	Owner        string   `json:"owner"`
+	Repositories []string `json:"repositories"`
+}
+
+type seedStats struct {
+	CoinsCreated, CoinsUpdated, ReposCreated, ReposUpdated int
+}
+
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	addConfigFlag(fs)
+	file := fs.String("file", "", "CSV or JSON file with coins and repositories")
+	fs.Parse(args)
+	if *file == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	coins, err := readSeedFile(*file)
+	if err != nil {
+		fatal("Failed to read the seed file.", "file", *file, "error", err)
+	}
+
+	db := dbConnect(loadConfig())
+	defer closeDB(db)
+
+	tx := db.Begin()
+	stats, err := seed(tx, coins)
+	if err != nil {
+		tx.Rollback()
+		fatal("Failed to seed the DB.", "error", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		fatal("Failed to seed the DB.", "error", err)
+	}
+	fmt.Printf("Coins: %d created, %d updated. Repositories: %d created, %d updated.\n",
+		stats.CoinsCreated, stats.CoinsUpdated, stats.ReposCreated, stats.ReposUpdated)
+}
+
+// readSeedFile picks the format from the file extension.
+func readSeedFile(path string) ([]seedCoin, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return readSeedCSV(f)
+	case ".json":
+		var coins []seedCoin
+		err := json.NewDecoder(f).Decode(&coins)
+		return coins, err
+	default:
+		return nil, fmt.Errorf("unsupported seed file %q, use .csv or .json", path)
+	}
+}
+
+// readSeedCSV reads rows of symbol,name,owner,repository with a header line.
+// A coin spans as many rows as it has repositories.
+func readSeedCSV(r io.Reader) ([]seedCoin, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var coins []seedCoin
+	index := map[string]int{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(record[0], "symbol") {
+			continue
+		}
+
+		symbol := strings.ToUpper(record[0])
+		i, ok := index[symbol]
+		if !ok {
+			i = len(coins)
+			index[symbol] = i
+			coins = append(coins, seedCoin{Symbol: symbol, Name: record[1], Owner: record[2]})
+		}
+		if record[3] != "" {
+			coins[i].Repositories = append(coins[i].Repositories, record[3])
+		}
+	}
+	return coins, nil
+}
+
+// seed upserts coins by symbol and repositories by coin and name.
+func seed(db *gorm.DB, coins []seedCoin) (seedStats, error) {
+	var stats seedStats
+	for _, sc := range coins {
+		if sc.Symbol == "" || sc.Name == "" || sc.Owner == "" {
+			return stats, fmt.Errorf("coin %q needs a symbol, name and owner", sc.Symbol)
+		}
+		owner, err := parseOwner(sc.Owner)
+		if err != nil {
+			return stats, err
+		}
+
+		coin, err := findCoin(db, sc.Symbol)
+		switch {
+		case err == nil:
+			coin.Name, coin.Owner = sc.Name, owner
+			if err := db.Save(&coin).Error; err != nil {
+				return stats, err
+			}
+			stats.CoinsUpdated++
+		case errors.Is(err, errNotFound):
+			if coin, err = addCoin(db, Coin{Symbol: strings.ToUpper(sc.Symbol), Name: sc.Name, Owner: owner}); err != nil {
+				return stats, err
+			}
+			stats.CoinsCreated++
+		default:
+			return stats, err
+		}
+
+		for _, raw := range sc.Repositories {
+			if !strings.Contains(raw, "/") {
+				raw = owner + "/" + raw
+			}
+			loc, err := parseRepositoryURL(raw)
+			if err != nil {
+				return stats, err
+			}
+
+			var repo Repository
+			err = db.Where("coin_id = ? AND name = ?", coin.Id, loc.Name).First(&repo).Error
+			switch {
+			case err == nil:
+				if err := db.Model(&repo).Updates(map[string]interface{}{"provider": loc.Provider, "base_url": loc.BaseURL}).Error; err != nil {
+					return stats, err
+				}
+				stats.ReposUpdated++
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if _, err := addRepository(db, coin, loc); err != nil {
+					return stats, err
+				}
+				stats.ReposCreated++
+			default:
+				return stats, err
+			}
+		}
+	}
+	return stats, nil
+}
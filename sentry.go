@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/getsentry/sentry-go"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+const sentryFlushTimeout = 5 * time.Second
+
+// startSentry sends the errors reported from now on to the Sentry project of
+// config. The returned function delivers the events still queued and must be
+// called before exiting. Without a DSN, or when the client cannot be set up,
+// reporting does nothing.
+func startSentry(config SentryConfig) func() {
+	if config.DSN == "" {
+		return func() {}
+	}
+	environment := config.Environment
+	if environment == "" {
+		environment = os.Getenv("ENVIRONMENT")
+	}
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         config.DSN,
+		Environment: environment,
+		ServerName:  defaultServiceName,
+	})
+	if err != nil {
+		slog.Error("Failed to set up Sentry.", "error", err)
+		return func() {}
+	}
+	return func() {
+		sentry.Flush(sentryFlushTimeout)
+	}
+}
+
+// reportCollectionError sends the error of r to Sentry, tagged with its
+// coin, repository and kind. Missing repositories and runs canceled by a
+// signal are expected, so they are left out.
+func reportCollectionError(runId int, r result) {
+	if errors.Is(r.Err, errRepositoryMissing) || errors.Is(r.Err, context.Canceled) {
+		return
+	}
+	loc := locationOf(r.Repository)
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetTags(map[string]string{
+			"coin":       r.Repository.Coin.Symbol,
+			"repository": loc.Owner + "/" + loc.Name,
+			"provider":   loc.Provider,
+			"kind":       errorKind(r.Err),
+			"run_id":     strconv.Itoa(runId),
+		})
+		sentry.CaptureException(r.Err)
+	})
+}
+
+// reportPanic is deferred at the top of a goroutine. It sends a panic to
+// Sentry and waits for the event to be delivered before panicking again, as
+// the process is about to crash.
+func reportPanic() {
+	if v := recover(); v != nil {
+		sentry.CurrentHub().Recover(v)
+		sentry.Flush(sentryFlushTimeout)
+		panic(v)
+	}
+}
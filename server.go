@@ -0,0 +1,455 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 100
+)
+
+type (
+	server struct {
+		db *gorm.DB
+	}
+
+	// page is the envelope of every list response.
+	page struct {
+		Data    interface{} `json:"data"`
+		Page    int         `json:"page"`
+		PerPage int         `json:"per_page"`
+		Total   int64       `json:"total"`
+	}
+
+	// listParams holds the pagination and sorting query parameters.
+	listParams struct {
+		Page    int
+		PerPage int
+		Order   string
+	}
+)
+
+var (
+	coinSortColumns = []string{"id", "name", "symbol", "updated_at"}
+
+	repositorySortColumns = []string{
+		"id", "name", "language", "pull_requests_count", "open_pull_requests_count",
+		"closed_pull_requests_count", "merged_pull_requests_count", "watchers_count",
+		"stargazers_count", "issues_count", "open_issues_count", "closed_issues_count", "commits_count_for_the_last_week",
+		"commits_count_for_the_last_month", "commits_count", "contributors_count",
+		"forks_count", "releases_count", "tags_count", "license", "latest_release_at",
+		"releases_count_for_the_last90_days", "median_issue_close_seconds",
+		"median_pull_request_merge_seconds", "signed_commits_percentage", "updated_at",
+	}
+
+	snapshotSortColumns = []string{"captured_at"}
+
+	marketSortColumns = []string{"date", "price_usd", "market_cap_usd"}
+
+	rankSortColumns = []string{"composite_rank", "commits_rank", "contributors_rank"}
+)
+
+func serve(db *gorm.DB, addr string) error {
+	s := &server{db: db}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/coins", s.coins)
+	mux.HandleFunc("/coins/", s.coin)
+	mux.HandleFunc("/leaderboard", s.leaderboard)
+	mux.HandleFunc("/repositories/", s.repository)
+	mux.HandleFunc("/grafana", s.grafana)
+	mux.HandleFunc("/grafana/", s.grafana)
+
+	slog.Info("Listening.", "addr", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// parseListParams reads page, per_page, sort and direction. Only columns in
+// sortable are accepted for sort, so the value can go straight into ORDER BY.
+func parseListParams(r *http.Request, sortable []string, defaultSort string) (listParams, error) {
+	q := r.URL.Query()
+	p := listParams{Page: 1, PerPage: defaultPerPage}
+
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return p, errBadParam("page")
+		}
+		p.Page = n
+	}
+	if v := q.Get("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPerPage {
+			return p, errBadParam("per_page")
+		}
+		p.PerPage = n
+	}
+
+	column := defaultSort
+	if v := q.Get("sort"); v != "" {
+		if !contains(sortable, v) {
+			return p, errBadParam("sort")
+		}
+		column = v
+	}
+	direction := "asc"
+	if v := q.Get("direction"); v != "" {
+		if v != "asc" && v != "desc" {
+			return p, errBadParam("direction")
+		}
+		direction = v
+	}
+	p.Order = column + " " + direction
+
+	return p, nil
+}
+
+func (p listParams) apply(db *gorm.DB) *gorm.DB {
+	return db.Order(p.Order).Offset((p.Page - 1) * p.PerPage).Limit(p.PerPage)
+}
+
+func (s *server) coins(w http.ResponseWriter, r *http.Request) {
+	db := s.db.WithContext(r.Context())
+	p, err := parseListParams(r, coinSortColumns, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var total int64
+	var coins []Coin
+	if err := db.Model(&Coin{}).Count(&total).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	if err := p.apply(db).Find(&coins).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: coins, Page: p.Page, PerPage: p.PerPage, Total: total})
+}
+
+// coin serves the /coins/{symbol}/... resources.
+func (s *server) coin(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/coins/"), "/"), "/")
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	coin, err := findCoin(s.db.WithContext(r.Context()), parts[0])
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			writeError(w, http.StatusNotFound, "coin not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+
+	switch parts[1] {
+	case "repositories":
+		s.coinRepositories(w, r, coin)
+	case "licenses":
+		s.coinLicenses(w, r, coin)
+	case "market":
+		s.coinMarket(w, r, coin)
+	case "contributors":
+		s.coinContributors(w, r, coin)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// coinRepositories serves /coins/{symbol}/repositories. The topic and
+// license query parameters narrow the list down to the repositories tagged
+// with a topic or under an SPDX license.
+func (s *server) coinRepositories(w http.ResponseWriter, r *http.Request, coin Coin) {
+	db := s.db.WithContext(r.Context())
+	p, err := parseListParams(r, repositorySortColumns, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var total int64
+	var repos []Repository
+	scope := db.Model(&Repository{}).Where("coin_id = ?", coin.Id)
+	if topic := r.URL.Query().Get("topic"); topic != "" {
+		scope = scope.Where("id IN (?)", db.Model(&RepositoryTopic{}).Select("repository_id").Where("topic = ?", strings.ToLower(topic)))
+	}
+	if license := r.URL.Query().Get("license"); license != "" {
+		scope = scope.Where("license = ?", license)
+	}
+	scope = scope.Session(&gorm.Session{})
+	if err := scope.Count(&total).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	if err := p.apply(scope).Find(&repos).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: repos, Page: p.Page, PerPage: p.PerPage, Total: total})
+}
+
+// coinLicenses serves /coins/{symbol}/licenses, the number of repositories
+// of the coin under each license. Repositories without a detected license
+// are counted under "".
+func (s *server) coinLicenses(w http.ResponseWriter, r *http.Request, coin Coin) {
+	db := s.db.WithContext(r.Context())
+	var licenses []struct {
+		License           string `json:"license"`
+		RepositoriesCount int    `json:"repositories_count"`
+	}
+	err := db.Model(&Repository{}).
+		Select("COALESCE(license, '') AS license, COUNT(*) AS repositories_count").
+		Where("coin_id = ?", coin.Id).
+		Group("COALESCE(license, '')").
+		Order("repositories_count DESC, license").
+		Scan(&licenses).Error
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": licenses})
+}
+
+// coinMarket serves /coins/{symbol}/market, the daily market snapshots of
+// the coin with its development activity on each day.
+func (s *server) coinMarket(w http.ResponseWriter, r *http.Request, coin Coin) {
+	db := s.db.WithContext(r.Context())
+	p, err := parseListParams(r, marketSortColumns, "date")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var total int64
+	var snapshots []CoinMarketSnapshot
+	scope := db.Model(&CoinMarketSnapshot{}).Where("coin_id = ?", coin.Id).Session(&gorm.Session{})
+	if err := scope.Count(&total).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	if err := p.apply(scope).Find(&snapshots).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: snapshots, Page: p.Page, PerPage: p.PerPage, Total: total})
+}
+
+// leaderboard serves /leaderboard, the ranks of the coins in the last run
+// that ranked them, or in the run given with run_id. The coin query
+// parameter narrows it down to the coin with that symbol, in any case.
+func (s *server) leaderboard(w http.ResponseWriter, r *http.Request) {
+	db := s.db.WithContext(r.Context())
+	p, err := parseListParams(r, rankSortColumns, "composite_rank")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var runId int
+	if v := r.URL.Query().Get("run_id"); v != "" {
+		if runId, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, errBadParam("run_id").Error())
+			return
+		}
+	} else if err := db.Model(&CoinRank{}).Select("COALESCE(MAX(run_id), 0)").Scan(&runId).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+
+	var total int64
+	var ranks []struct {
+		CoinSymbol string `json:"coin_symbol"`
+		CoinName   string `json:"coin_name"`
+		CoinRank
+	}
+	scope := db.Model(&CoinRank{}).Where("run_id = ?", runId)
+	if symbol := r.URL.Query().Get("coin"); symbol != "" {
+		coin, err := findCoin(db, symbol)
+		if err != nil {
+			if errors.Is(err, errNotFound) {
+				writeError(w, http.StatusNotFound, "coin not found")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to read the DB")
+			return
+		}
+		scope = scope.Where("coin_id = ?", coin.Id)
+	}
+	scope = scope.Session(&gorm.Session{})
+	if err := scope.Count(&total).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	// The coin id breaks the ties so pages do not overlap.
+	err = p.apply(scope).
+		Select("coin_ranks.*, coins.symbol AS coin_symbol, coins.name AS coin_name").
+		Joins("JOIN coins ON coins.id = coin_ranks.coin_id").
+		Order("coin_ranks.coin_id").
+		Scan(&ranks).Error
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: ranks, Page: p.Page, PerPage: p.PerPage, Total: total})
+}
+
+// coinContributors serves /coins/{symbol}/contributors, the top
+// contributors of the coin's repositories with their commits of the last 90
+// days summed over the repositories, most commits first.
+func (s *server) coinContributors(w http.ResponseWriter, r *http.Request, coin Coin) {
+	db := s.db.WithContext(r.Context())
+	var contributors []struct {
+		Login                        string `json:"login"`
+		RepositoriesCount            int    `json:"repositories_count"`
+		CommitsCountForTheLast90Days int    `json:"commits_count_for_the_last_90_days"`
+	}
+	err := db.Model(&RepositoryTopContributor{}).
+		Select("repository_top_contributors.login, COUNT(*) AS repositories_count, SUM(repository_top_contributors.commits_count_for_the_last90_days) AS commits_count_for_the_last90_days").
+		Joins("JOIN repositories ON repositories.id = repository_top_contributors.repository_id").
+		Where("repositories.coin_id = ? AND repositories.deleted_at IS NULL", coin.Id).
+		Group("repository_top_contributors.login").
+		Order("commits_count_for_the_last90_days DESC, repository_top_contributors.login").
+		Scan(&contributors).Error
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": contributors})
+}
+
+// repository serves the /repositories/{id}/... resources.
+func (s *server) repository(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/repositories/"), "/"), "/")
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch parts[1] {
+	case "history":
+		s.repositoryHistory(w, r, id)
+	case "growth":
+		s.repositoryGrowth(w, r, id)
+	case "languages":
+		s.repositoryLanguages(w, r, id)
+	case "authors":
+		s.repositoryAuthorDomains(w, r, id)
+	case "contributors":
+		s.repositoryTopContributors(w, r, id)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// repositoryHistory serves /repositories/{id}/history.
+func (s *server) repositoryHistory(w http.ResponseWriter, r *http.Request, id int) {
+	db := s.db.WithContext(r.Context())
+	p, err := parseListParams(r, snapshotSortColumns, "captured_at")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var total int64
+	var snapshots []RepositorySnapshot
+	scope := db.Model(&RepositorySnapshot{}).Where("repository_id = ?", id).Session(&gorm.Session{})
+	if err := scope.Count(&total).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	if err := p.apply(scope).Find(&snapshots).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: snapshots, Page: p.Page, PerPage: p.PerPage, Total: total})
+}
+
+// repositoryGrowth serves /repositories/{id}/growth, the week-over-week and
+// month-over-month changes computed by the last run.
+func (s *server) repositoryGrowth(w http.ResponseWriter, r *http.Request, id int) {
+	db := s.db.WithContext(r.Context())
+	var growths []RepositoryGrowth
+	if err := db.Where("repository_id = ?", id).Order("period DESC").Find(&growths).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": growths})
+}
+
+// repositoryLanguages serves /repositories/{id}/languages, the language
+// breakdown read by the last run, largest first.
+func (s *server) repositoryLanguages(w http.ResponseWriter, r *http.Request, id int) {
+	db := s.db.WithContext(r.Context())
+	var languages []RepositoryLanguage
+	if err := db.Where("repository_id = ?", id).Order("share DESC, language").Find(&languages).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": languages})
+}
+
+// repositoryTopContributors serves /repositories/{id}/contributors, the top
+// contributors of the repository, most commits first.
+func (s *server) repositoryTopContributors(w http.ResponseWriter, r *http.Request, id int) {
+	db := s.db.WithContext(r.Context())
+	var contributors []RepositoryTopContributor
+	if err := db.Where("repository_id = ?", id).Order("commits_count_for_the_last90_days DESC, login").Find(&contributors).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": contributors})
+}
+
+// repositoryAuthorDomains serves /repositories/{id}/authors, the share of
+// the commits of the last 90 days by author domain, largest first.
+func (s *server) repositoryAuthorDomains(w http.ResponseWriter, r *http.Request, id int) {
+	db := s.db.WithContext(r.Context())
+	var domains []RepositoryAuthorDomain
+	if err := db.Where("repository_id = ?", id).Order("commits_count DESC, domain").Find(&domains).Error; err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": domains})
+}
+
+type errBadParam string
+
+func (e errBadParam) Error() string {
+	return "invalid " + string(e) + " parameter"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write the response.", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServerMatchesSymbolInAnyCase(t *testing.T) {
+	db := dbConnect(testConfig(t))
+	defer closeDB(db)
+	coins := []seedCoin{
+		{Symbol: "BTC", Name: "Bitcoin", Owner: "bitcoin", Repositories: []string{"bitcoin"}},
+		{Symbol: "ETH", Name: "Ethereum", Owner: "ethereum", Repositories: []string{"go-ethereum"}},
+	}
+	if _, err := seed(db, coins); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create([]CoinRank{{RunId: 1, CoinId: 1, CompositeRank: 2}, {RunId: 1, CoinId: 2, CompositeRank: 1}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	s := &server{db: db}
+
+	get := func(handler http.HandlerFunc, target string) page {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want %d", target, rec.Code, http.StatusOK)
+		}
+		var p page
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	if p := get(s.coin, "/coins/btc/repositories"); p.Total != 1 {
+		t.Errorf("/coins/btc/repositories has %d repositories, want 1", p.Total)
+	}
+	if p := get(s.leaderboard, "/leaderboard?coin=btc"); p.Total != 1 {
+		t.Errorf("/leaderboard?coin=btc has %d ranks, want 1", p.Total)
+	}
+}
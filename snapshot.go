@@ -0,0 +1,41 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+func newSnapshot(runId, repositoryId int, m Repository, capturedAt time.Time) RepositorySnapshot {
+	return RepositorySnapshot{
+		RepositoryId:                repositoryId,
+		RunId:                       runId,
+		Language:                    m.Language,
+		PullRequestsCount:           m.PullRequestsCount,
+		OpenPullRequestsCount:       m.OpenPullRequestsCount,
+		ClosedPullRequestsCount:     m.ClosedPullRequestsCount,
+		MergedPullRequestsCount:     m.MergedPullRequestsCount,
+		WatchersCount:               m.WatchersCount,
+		StargazersCount:             m.StargazersCount,
+		IssuesCount:                 m.IssuesCount,
+		OpenIssuesCount:             m.OpenIssuesCount,
+		ClosedIssuesCount:           m.ClosedIssuesCount,
+		CommitsCountForTheLastWeek:  m.CommitsCountForTheLastWeek,
+		CommitsCountForTheLastMonth: m.CommitsCountForTheLastMonth,
+		CommitsCount:                m.CommitsCount,
+		ContributorsCount:           m.ContributorsCount,
+		ForksCount:                  m.ForksCount,
+		ReleasesCount:               m.ReleasesCount,
+		TagsCount:                   m.TagsCount,
+		CapturedAt:                  capturedAt,
+	}
+}
+
+// pruneSnapshots deletes snapshots older than the retention period. Backfilled
+// snapshots are kept, they are the only history from before the first run.
+func pruneSnapshots(db *gorm.DB, config SnapshotConfig, now time.Time) (int64, error) {
+	if config.RetentionDays <= 0 {
+		return 0, nil
+	}
+	res := db.Where("captured_at < ? AND backfilled = ?", now.AddDate(0, 0, -config.RetentionDays), false).Delete(&RepositorySnapshot{})
+	return res.RowsAffected, res.Error
+}
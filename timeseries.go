@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	timeSeriesInfluxDB  = "influxdb"
+	timeSeriesTimescale = "timescale"
+
+	// timeSeriesMeasurement is the InfluxDB measurement and the Timescale
+	// hypertable the points are written to.
+	timeSeriesMeasurement = "repository_metrics"
+
+	timeSeriesBatchSize = 500
+	timeSeriesTimeout   = 30 * time.Second
+)
+
+type (
+	// timeSeriesPoint is the metrics of a repository collected by a run.
+	timeSeriesPoint struct {
+		Coin       string
+		Owner      string
+		Repository string
+		Provider   string
+		Snapshot   RepositorySnapshot
+	}
+
+	timeSeriesField struct {
+		name  string
+		value int
+	}
+
+	timeSeriesSink interface {
+		write(ctx context.Context, points []timeSeriesPoint) error
+		close() error
+	}
+
+	// timeSeries buffers the points of a run and writes them in batches to
+	// the [TimeSeries] sink. A failed write is logged and its points are
+	// dropped; the sink never fails the run.
+	timeSeries struct {
+		sink   timeSeriesSink
+		points []timeSeriesPoint
+	}
+
+	influxSink struct {
+		http   *http.Client
+		url    string
+		token  string
+		org    string
+		bucket string
+	}
+
+	timescaleSink struct {
+		db *gorm.DB
+	}
+
+	// timescaleRow is a row of the repository_metrics hypertable.
+	timescaleRow struct {
+		Time                        time.Time `gorm:"not null;index:idx_repository_metrics_repository_time,priority:2"`
+		Coin                        string
+		Owner                       string
+		Repository                  string `gorm:"index:idx_repository_metrics_repository_time,priority:1"`
+		Provider                    string
+		PullRequestsCount           int
+		OpenPullRequestsCount       int
+		ClosedPullRequestsCount     int
+		MergedPullRequestsCount     int
+		WatchersCount               int
+		StargazersCount             int
+		IssuesCount                 int
+		OpenIssuesCount             int
+		ClosedIssuesCount           int
+		CommitsCountForTheLastWeek  int
+		CommitsCountForTheLastMonth int
+		CommitsCount                int
+		ContributorsCount           int
+		ForksCount                  int
+		ReleasesCount               int
+		TagsCount                   int
+	}
+)
+
+func (timescaleRow) TableName() string {
+	return timeSeriesMeasurement
+}
+
+// newTimeSeries opens the configured sink. Without a driver it returns a
+// timeSeries that drops every point.
+func newTimeSeries(config TimeSeriesConfig) (*timeSeries, error) {
+	switch config.Driver {
+	case "":
+		return &timeSeries{}, nil
+	case timeSeriesInfluxDB:
+		return &timeSeries{sink: &influxSink{
+			http:   http.DefaultClient,
+			url:    strings.TrimSuffix(config.URL, "/"),
+			token:  config.Token,
+			org:    config.Org,
+			bucket: config.Bucket,
+		}}, nil
+	case timeSeriesTimescale:
+		sink, err := newTimescaleSink(config.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return &timeSeries{sink: sink}, nil
+	}
+	return nil, fmt.Errorf("unknown time series driver %q", config.Driver)
+}
+
+// add buffers the snapshot written for repo, flushing once a batch is full.
+func (t *timeSeries) add(repo Repository, snapshot RepositorySnapshot) {
+	if t.sink == nil {
+		return
+	}
+	loc := locationOf(repo)
+	t.points = append(t.points, timeSeriesPoint{
+		Coin:       repo.Coin.Symbol,
+		Owner:      loc.Owner,
+		Repository: loc.Name,
+		Provider:   loc.Provider,
+		Snapshot:   snapshot,
+	})
+	if len(t.points) >= timeSeriesBatchSize {
+		t.flush()
+	}
+}
+
+// flush writes the buffered points. It is called once more at the end of
+// the run, even an interrupted one, so it does not use the run context.
+func (t *timeSeries) flush() {
+	if t.sink == nil || len(t.points) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeSeriesTimeout)
+	defer cancel()
+	if err := t.sink.write(ctx, t.points); err != nil {
+		slog.Error("Failed to write the time series.", "points", len(t.points), "error", err)
+	}
+	t.points = t.points[:0]
+}
+
+// close flushes the points left and closes the sink.
+func (t *timeSeries) close() {
+	if t.sink == nil {
+		return
+	}
+	t.flush()
+	if err := t.sink.close(); err != nil {
+		slog.Error("Failed to close the time series sink.", "error", err)
+	}
+}
+
+// timeSeriesFields are the fields of a point, named like the snapshot
+// columns.
+func timeSeriesFields(s RepositorySnapshot) []timeSeriesField {
+	return []timeSeriesField{
+		{"pull_requests_count", s.PullRequestsCount},
+		{"open_pull_requests_count", s.OpenPullRequestsCount},
+		{"closed_pull_requests_count", s.ClosedPullRequestsCount},
+		{"merged_pull_requests_count", s.MergedPullRequestsCount},
+		{"watchers_count", s.WatchersCount},
+		{"stargazers_count", s.StargazersCount},
+		{"issues_count", s.IssuesCount},
+		{"open_issues_count", s.OpenIssuesCount},
+		{"closed_issues_count", s.ClosedIssuesCount},
+		{"commits_count_for_the_last_week", s.CommitsCountForTheLastWeek},
+		{"commits_count_for_the_last_month", s.CommitsCountForTheLastMonth},
+		{"commits_count", s.CommitsCount},
+		{"contributors_count", s.ContributorsCount},
+		{"forks_count", s.ForksCount},
+		{"releases_count", s.ReleasesCount},
+		{"tags_count", s.TagsCount},
+	}
+}
+
+// influxTagEscaper escapes tag values for the line protocol.
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// lineProtocol renders points in the InfluxDB line protocol with second
+// precision, tagged by coin, owner, repository and provider.
+func lineProtocol(points []timeSeriesPoint) []byte {
+	var b bytes.Buffer
+	for _, p := range points {
+		b.WriteString(timeSeriesMeasurement)
+		tags := []struct{ key, value string }{{"coin", p.Coin}, {"owner", p.Owner}, {"repository", p.Repository}, {"provider", p.Provider}}
+		for _, tag := range tags {
+			// InfluxDB rejects empty tag values.
+			if tag.value != "" {
+				fmt.Fprintf(&b, ",%s=%s", tag.key, influxTagEscaper.Replace(tag.value))
+			}
+		}
+		for i, f := range timeSeriesFields(p.Snapshot) {
+			sep := ","
+			if i == 0 {
+				sep = " "
+			}
+			fmt.Fprintf(&b, "%s%s=%di", sep, f.name, f.value)
+		}
+		fmt.Fprintf(&b, " %d\n", p.Snapshot.CapturedAt.Unix())
+	}
+	return b.Bytes()
+}
+
+// write posts the points to the InfluxDB 2 write API.
+func (s *influxSink) write(ctx context.Context, points []timeSeriesPoint) error {
+	query := url.Values{"org": {s.org}, "bucket": {s.bucket}, "precision": {"s"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/api/v2/write?"+query.Encode(), bytes.NewReader(lineProtocol(points)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+s.token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	res, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("InfluxDB answered %s", res.Status)
+	}
+	return nil
+}
+
+func (s *influxSink) close() error {
+	return nil
+}
+
+// newTimescaleSink connects to the TimescaleDB at dsn and creates the
+// repository_metrics hypertable if it does not exist yet.
+func newTimescaleSink(dsn string) (*timescaleSink, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&timescaleRow{}); err != nil {
+		return nil, err
+	}
+	err = db.Exec("SELECT create_hypertable(?, 'time', if_not_exists => TRUE)", timeSeriesMeasurement).Error
+	if err != nil {
+		return nil, err
+	}
+	return &timescaleSink{db: db}, nil
+}
+
+func (s *timescaleSink) write(ctx context.Context, points []timeSeriesPoint) error {
+	rows := make([]timescaleRow, len(points))
+	for i, p := range points {
+		m := p.Snapshot
+		rows[i] = timescaleRow{
+			Time:                        m.CapturedAt,
+			Coin:                        p.Coin,
+			Owner:                       p.Owner,
+			Repository:                  p.Repository,
+			Provider:                    p.Provider,
+			PullRequestsCount:           m.PullRequestsCount,
+			OpenPullRequestsCount:       m.OpenPullRequestsCount,
+			ClosedPullRequestsCount:     m.ClosedPullRequestsCount,
+			MergedPullRequestsCount:     m.MergedPullRequestsCount,
+			WatchersCount:               m.WatchersCount,
+			StargazersCount:             m.StargazersCount,
+			IssuesCount:                 m.IssuesCount,
+			OpenIssuesCount:             m.OpenIssuesCount,
+			ClosedIssuesCount:           m.ClosedIssuesCount,
+			CommitsCountForTheLastWeek:  m.CommitsCountForTheLastWeek,
+			CommitsCountForTheLastMonth: m.CommitsCountForTheLastMonth,
+			CommitsCount:                m.CommitsCount,
+			ContributorsCount:           m.ContributorsCount,
+			ForksCount:                  m.ForksCount,
+			ReleasesCount:               m.ReleasesCount,
+			TagsCount:                   m.TagsCount,
+		}
+	}
+	return s.db.WithContext(ctx).Create(&rows).Error
+}
+
+func (s *timescaleSink) close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
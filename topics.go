@@ -0,0 +1,45 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// topicsOf turns topic names into rows, lowercased and without duplicates
+// since GitLab topics are free-form. It never returns nil, so a repository
+// whose topics were all removed gets its stored ones cleared.
+func topicsOf(names []string) []RepositoryTopic {
+	topics := []RepositoryTopic{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		topics = append(topics, RepositoryTopic{Topic: name})
+	}
+	return topics
+}
+
+// saveTopics replaces the topics of a repository. Nil topics, from a
+// collector that does not read them, leave the stored ones alone.
+func saveTopics(db *gorm.DB, repositoryId int, topics []RepositoryTopic, now time.Time) error {
+	if topics == nil {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repositoryId).Delete(&RepositoryTopic{}).Error; err != nil {
+			return err
+		}
+		for _, t := range topics {
+			t.RepositoryId = repositoryId
+			t.UpdatedAt = now
+			if err := tx.Create(&t).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
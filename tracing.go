@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultServiceName = "commit-count-collector"
+
+	tracingTimeout = 30 * time.Second
+
+	// tracingSpanKey is where the SQL span of a statement is kept between
+	// its gorm callbacks.
+	tracingSpanKey = "tracing:span"
+)
+
+// tracer creates every span. Until setupTracing installs a provider it is a
+// no-op, so the instrumentation costs next to nothing when [Tracing] is off.
+var tracer = otel.Tracer("github.com/horizon67/commit-count-collector")
+
+type (
+	// tracingTransport wraps each request in a client span named after the
+	// API or site it goes to, and its method or "graphql" for GraphQL
+	// queries.
+	tracingTransport struct {
+		base http.RoundTripper
+		name string
+	}
+
+	// tracingPlugin is a gorm plugin that wraps each SQL statement in a span,
+	// a child of the span in the statement's context.
+	tracingPlugin struct {
+		system string
+	}
+)
+
+// setupTracing installs a provider exporting the spans to the OTLP/HTTP
+// endpoint of config. The returned function flushes the spans left and must
+// be called before exiting. Without an endpoint nothing is installed.
+func setupTracing(config TracingConfig) (func(), error) {
+	if config.Endpoint == "" {
+		return func() {}, nil
+	}
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(config.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+	name := config.ServiceName
+	if name == "" {
+		name = defaultServiceName
+	}
+	ratio := config.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(name))),
+		// A run is sampled as a whole: its spans follow the root's decision.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			slog.Error("Failed to export the traces.", "error", err)
+		}
+	}, nil
+}
+
+// startTracing is setupTracing for the commands, which go on untraced when
+// the exporter cannot be set up.
+func startTracing(config TracingConfig) func() {
+	shutdown, err := setupTracing(config)
+	if err != nil {
+		slog.Error("Failed to set up tracing.", "error", err)
+		return func() {}
+	}
+	return shutdown
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := req.Method
+	if strings.HasSuffix(req.URL.Path, "/graphql") {
+		operation = "graphql"
+	}
+	ctx, span := tracer.Start(req.Context(), t.name+" "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			// Redacted drops any password from the URL.
+			semconv.URLFull(req.URL.Redacted()),
+		),
+	)
+	res, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(res.StatusCode))
+	if res.StatusCode >= 500 {
+		span.SetStatus(codes.Error, res.Status)
+	}
+	span.End()
+	return res, nil
+}
+
+// dbSystem is the OpenTelemetry name of the database of driver.
+func dbSystem(driver string) string {
+	switch driver {
+	case driverPostgres:
+		return "postgresql"
+	case driverSQLite:
+		return "sqlite"
+	}
+	return driver
+}
+
+func (p tracingPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize registers the callbacks around each kind of statement gorm
+// runs.
+func (p tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+func (p tracingPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := tracer.Start(db.Statement.Context, "sql "+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemKey.String(p.system), semconv.DBOperation(operation)),
+		)
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func (p tracingPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	// The statement has placeholders, never the values bound to them.
+	span.SetAttributes(
+		semconv.DBStatement(db.Statement.SQL.String()),
+		semconv.DBSQLTable(db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	endSpan(span, err)
+}
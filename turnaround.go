@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// turnaroundSampleSize is how many of the most recently closed issues and
+// merged pull requests the median turnaround times are computed from.
+const turnaroundSampleSize = 50
+
+// turnaround is the lifetime of one issue or pull request.
+type turnaround struct {
+	Opened time.Time
+	Closed time.Time
+}
+
+// medianTurnaround is the median time in seconds from opening to closing of
+// samples, 0 when there is none. Samples missing either time are skipped.
+func medianTurnaround(samples []turnaround) int {
+	var durations []time.Duration
+	for _, s := range samples {
+		if s.Opened.IsZero() || s.Closed.IsZero() || s.Closed.Before(s.Opened) {
+			continue
+		}
+		durations = append(durations, s.Closed.Sub(s.Opened))
+	}
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	middle := len(durations) / 2
+	median := durations[middle]
+	if len(durations)%2 == 0 {
+		median = (durations[middle-1] + durations[middle]) / 2
+	}
+	return int(median / time.Second)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/getsentry/sentry-go"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// environments are the accepted values of ENVIRONMENT, one per file in
+// ./config/env/.
+var environments = []string{"local", "production", "sqlite"}
+
+// checkEnvironment runs before the config file named after ENVIRONMENT is
+// opened, so a typo is not reported as a missing file.
+func checkEnvironment(environment string) error {
+	if environment != "" && !contains(environments, environment) {
+		return fmt.Errorf("ENVIRONMENT %q is not one of %s", environment, strings.Join(environments, ", "))
+	}
+	return nil
+}
+
+// validate reports every problem of the config at once, each prefixed with
+// its section, so a broken deployment fails at startup with the whole list
+// instead of one cryptic driver or OAuth error at a time. github also
+// requires the GitHub credentials when the github collector is enabled.
+func (c Config) validate(github bool) error {
+	var problems []string
+	add := func(section string, list ...string) {
+		for _, p := range list {
+			problems = append(problems, section+" "+p)
+		}
+	}
+
+	add("[Database]", c.Database.problems()...)
+	add("[GitHub]", c.GitHub.problems(github && c.Collectors.enables(collectorGitHub))...)
+	if _, err := parseCommitWindows(c.Commits.Windows); err != nil {
+		add("[Commits]", err.Error())
+	}
+	if c.Commits.TopContributors < 0 {
+		add("[Commits]", "topContributors must not be negative")
+	}
+	for _, name := range c.Collectors.Enabled {
+		if !isCollector(name) {
+			add("[Collectors]", fmt.Sprintf("unknown collector %q", name))
+		}
+	}
+	for _, host := range c.Gitea.Hosts {
+		if host == "" || strings.ContainsAny(host, "/:") {
+			add("[Gitea]", fmt.Sprintf("host %q must be a bare host name", host))
+		}
+	}
+	if c.Score.Commits < 0 || c.Score.Contributors < 0 || c.Score.Stars < 0 || c.Score.PullRequests < 0 {
+		add("[Score]", "weights must not be negative")
+	}
+	if c.Snapshot.RetentionDays < 0 {
+		add("[Snapshot]", "retentionDays must not be negative")
+	}
+	add("[Notifications]", c.Notifications.problems()...)
+	add("[SMTP]", c.SMTP.problems()...)
+	add("[TimeSeries]", c.TimeSeries.problems()...)
+	add("[Events]", c.Events.problems()...)
+	add("[Tracing]", c.Tracing.problems()...)
+	add("[Sentry]", c.Sentry.problems()...)
+	add("[HTTP]", c.HTTP.problems()...)
+	add("[Redis]", c.Redis.problems()...)
+	add("[Scrape]", c.Scrape.problems()...)
+	add("[Repositories]", c.Repositories.problems()...)
+	if parsed, err := url.Parse(c.CoinGecko.APIURL); c.CoinGecko.APIURL != "" && (err != nil || parsed.Scheme == "" || parsed.Host == "") {
+		add("[CoinGecko]", fmt.Sprintf("apiUrl %q is not an absolute URL", c.CoinGecko.APIURL))
+	}
+	if _, ok := priceProviders[c.Prices.Provider]; c.Prices.Provider != "" && !ok {
+		add("[Prices]", fmt.Sprintf("provider %q is not one of %s", c.Prices.Provider, strings.Join(priceProviderNames(), ", ")))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s): %s", len(problems), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// enables reports whether the collector called name runs.
+func (c CollectorsConfig) enables(name string) bool {
+	return len(c.Enabled) == 0 || contains(c.Enabled, name)
+}
+
+// problems lists the invalid notification settings.
+func (n NotificationsConfig) problems() []string {
+	var problems []string
+	urls := []struct{ key, value string }{{"slack", n.Slack}, {"discord", n.Discord}}
+	for _, u := range urls {
+		if parsed, err := url.Parse(u.value); u.value != "" && (err != nil || parsed.Scheme == "" || parsed.Host == "") {
+			// The URL holds the webhook token, so it is not echoed.
+			problems = append(problems, fmt.Sprintf("%s is not an absolute URL", u.key))
+		}
+	}
+	if n.ErrorRate < 0 || n.ErrorRate > 1 {
+		problems = append(problems, "errorRate must be between 0 and 1")
+	}
+	if n.AlertMinRepos < 0 {
+		problems = append(problems, "alertMinRepos must not be negative")
+	}
+	return problems
+}
+
+// problems lists the invalid SMTP settings. An empty host leaves mailing off.
+func (c SMTPConfig) problems() []string {
+	var problems []string
+	if c.Port < 0 || c.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("port %d is out of range", c.Port))
+	}
+	if c.Host != "" && c.From == "" {
+		problems = append(problems, "from is required with a host")
+	}
+	if c.Host != "" && len(c.To) == 0 {
+		problems = append(problems, "to needs at least one recipient")
+	}
+	return problems
+}
+
+// problems lists the invalid time series settings, including a missing
+// secret of the selected driver.
+func (t TimeSeriesConfig) problems() []string {
+	var problems []string
+	switch t.Driver {
+	case "":
+	case timeSeriesInfluxDB:
+		if u, err := url.Parse(t.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("url %q is not an absolute URL", t.URL))
+		}
+		if t.Org == "" || t.Bucket == "" {
+			problems = append(problems, "org and bucket are required by influxdb")
+		}
+		if t.Token == "" {
+			problems = append(problems, "INFLUXDB_TOKEN is required by influxdb")
+		}
+	case timeSeriesTimescale:
+		if t.DSN == "" {
+			problems = append(problems, "TIMESCALE_DSN is required by timescale")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("driver %q is not influxdb or timescale", t.Driver))
+	}
+	return problems
+}
+
+// problems lists the invalid event settings.
+func (e EventsConfig) problems() []string {
+	var problems []string
+	switch e.Driver {
+	case "":
+	case eventsKafka:
+		if len(e.Brokers) == 0 {
+			problems = append(problems, "brokers are required by kafka")
+		}
+	case eventsNATS:
+		if e.URL == "" {
+			problems = append(problems, "url is required by nats")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("driver %q is not kafka or nats", e.Driver))
+	}
+	return problems
+}
+
+// problems lists the invalid tracing settings.
+func (t TracingConfig) problems() []string {
+	var problems []string
+	if t.Endpoint != "" {
+		if u, err := url.Parse(t.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("endpoint %q is not an absolute URL", t.Endpoint))
+		}
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("sampleRatio %g is not between 0 and 1", t.SampleRatio))
+	}
+	return problems
+}
+
+// problems lists the invalid Sentry settings.
+func (s SentryConfig) problems() []string {
+	if s.DSN == "" {
+		return nil
+	}
+	if _, err := sentry.NewDsn(s.DSN); err != nil {
+		return []string{fmt.Sprintf("dsn is invalid: %v", err)}
+	}
+	return nil
+}
+
+// problems lists the invalid HTTP client settings.
+func (h HTTPConfig) problems() []string {
+	var problems []string
+	if h.Timeout.Duration < 0 || h.Delay.Duration < 0 || h.Jitter.Duration < 0 {
+		problems = append(problems, "timeout, delay and jitter must not be negative")
+	}
+	if h.Proxy != "" {
+		if u, err := url.Parse(h.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("proxy %q is not an absolute URL", h.Proxy))
+		}
+	}
+	return problems
+}
+
+// problems lists the invalid Redis settings.
+func (r RedisConfig) problems() []string {
+	var problems []string
+	if r.DB < 0 || r.TTL.Duration < 0 {
+		problems = append(problems, "db and ttl must not be negative")
+	}
+	return problems
+}
+
+// problems lists the invalid GitHub settings. credentials requires either
+// GITHUB_TOKEN or a complete GitHub App setup.
+func (g GitHubConfig) problems(credentials bool) []string {
+	var problems []string
+	switch g.OnExhausted {
+	case "", "wait", "abort":
+	default:
+		problems = append(problems, fmt.Sprintf("onExhausted %q is not supported, use \"wait\" or \"abort\"", g.OnExhausted))
+	}
+	if g.BatchSize < 0 || g.MaxAttempts < 0 || g.MinRemaining < 0 || g.ConditionalMaxAge.Duration < 0 || g.BreakerThreshold < 0 || g.BreakerCoolDown.Duration < 0 {
+		problems = append(problems, "batchSize, maxAttempts, minRemaining, conditionalMaxAge, breakerThreshold and breakerCoolDown must not be negative")
+	}
+	switch g.CassetteMode {
+	case "", cassetteRecord, cassetteReplay:
+	default:
+		problems = append(problems, fmt.Sprintf("cassetteMode %q is not supported, use \"record\" or \"replay\"", g.CassetteMode))
+	}
+	urls := []struct{ key, value string }{{"graphqlUrl", g.GraphQLURL}, {"apiUrl", g.APIURL}, {"webUrl", g.WebURL}}
+	for _, u := range urls {
+		if parsed, err := url.Parse(u.value); u.value != "" && (err != nil || parsed.Scheme == "" || parsed.Host == "") {
+			problems = append(problems, fmt.Sprintf("%s %q is not an absolute URL", u.key, u.value))
+		}
+	}
+	if g.AppID != 0 {
+		if g.InstallationID == 0 {
+			problems = append(problems, "installationId is required with appId")
+		}
+		if credentials && g.PrivateKey == "" && g.PrivateKeyPath == "" {
+			problems = append(problems, "privateKeyPath or GITHUB_APP_PRIVATE_KEY is required with appId")
+		}
+	} else if credentials && g.Token == "" {
+		problems = append(problems, "GITHUB_TOKEN is not set")
+	}
+	return problems
+}
+
+// problems lists the invalid scraping strategies.
+func (s ScrapeConfig) problems() []string {
+	var problems []string
+	for _, list := range []struct {
+		name       string
+		strategies []ScrapeSelector
+	}{{"commits", s.Commits}, {"contributors", s.Contributors}} {
+		for i, strategy := range list.strategies {
+			if _, err := cascadia.Compile(strategy.Selector); err != nil {
+				problems = append(problems, fmt.Sprintf("%s[%d] selector %q is invalid: %v", list.name, i, strategy.Selector, err))
+			}
+		}
+	}
+	if s.BrowserWait.Duration < 0 {
+		problems = append(problems, "browserWait must not be negative")
+	}
+	return problems
+}
+
+// problems lists the patterns that are not valid globs.
+func (c RepositoriesConfig) problems() []string {
+	var problems []string
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"skip", c.Skip}, {"allow", c.Allow}} {
+		for _, p := range list.patterns {
+			_, glob := splitListPattern(p)
+			if _, err := path.Match(glob, ""); err != nil || glob == "" || strings.HasPrefix(p, ":") {
+				problems = append(problems, fmt.Sprintf("%s pattern %q is invalid", list.name, p))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"gorm.io/gorm"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webhookMaxBody is the largest payload GitHub delivers.
+const webhookMaxBody = 25 << 20
+
+type (
+	webhookServer struct {
+		db     *gorm.DB
+		secret []byte
+	}
+
+	// webhookPayload holds the fields read from push, star and issues
+	// events.
+	webhookPayload struct {
+		Action     string `json:"action"`
+		Ref        string `json:"ref"`
+		Repository struct {
+			Name          string `json:"name"`
+			DefaultBranch string `json:"default_branch"`
+			Stargazers    int    `json:"stargazers_count"`
+			Owner         struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+		} `json:"repository"`
+		Commits []struct {
+			Distinct bool `json:"distinct"`
+		} `json:"commits"`
+		HeadCommit *struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"head_commit"`
+	}
+)
+
+// serveWebhooks receives GitHub webhooks on /webhooks/github and applies
+// push, star and issues events to the counters of the matching repository,
+// so they move between runs. The next run overwrites them with exact values.
+func serveWebhooks(db *gorm.DB, secret, addr string) error {
+	s := &webhookServer{db: db, secret: []byte(secret)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhooks/github", s.github)
+
+	slog.Info("Listening for webhooks.", "addr", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// verify checks the X-Hub-Signature-256 header, the HMAC-SHA256 of the body
+// keyed with the webhook secret.
+func (s *webhookServer) verify(body []byte, signature string) bool {
+	sum, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	return hmac.Equal(sum, mac.Sum(nil))
+}
+
+func (s *webhookServer) github(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBody))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "payload too large")
+		return
+	}
+	if !s.verify(body, r.Header.Get("X-Hub-Signature-256")) {
+		writeError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	logger := slog.With("event", event, "delivery", r.Header.Get("X-GitHub-Delivery"))
+	if event == "ping" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	changes := webhookChanges(event, payload)
+	if changes == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	db := s.db.WithContext(r.Context())
+	var repo Repository
+	err = db.Joins("JOIN coins ON coins.id = repositories.coin_id").
+		Where("LOWER(coins.owner) = ? AND LOWER(repositories.name) = ? AND repositories.provider = ?",
+			strings.ToLower(payload.Repository.Owner.Login), strings.ToLower(payload.Repository.Name), providerGitHub).
+		First(&repo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Debug("Webhook for an untracked repository.", "repo", payload.Repository.Owner.Login+"/"+payload.Repository.Name)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to read the DB.", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to read the DB")
+		return
+	}
+
+	// UpdateColumns leaves updated_at alone, which still tells when the
+	// repository was last collected, and last_run_id, so the write of a run
+	// that selected the repository before the event is not taken as stale
+	// and replaces the counters with its exact values. The counters move in
+	// the UPDATE itself, which waits for a run writing the row to commit, so
+	// an event landing after a run's write adds to what the run wrote.
+	if err := db.Model(&repo).UpdateColumns(changes).Error; err != nil {
+		logger.Error("Failed to update the repository.", "repository_id", repo.Id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to update the DB")
+		return
+	}
+	logger.Info("Applied webhook.", "repository_id", repo.Id, "action", payload.Action)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// webhookChanges are the column updates an event implies, nil when it
+// changes nothing that is tracked.
+func webhookChanges(event string, p webhookPayload) map[string]interface{} {
+	switch event {
+	case "push":
+		if p.Ref != "refs/heads/"+p.Repository.DefaultBranch {
+			return nil
+		}
+		commits := 0
+		for _, c := range p.Commits {
+			if c.Distinct {
+				commits++
+			}
+		}
+		if commits == 0 {
+			return nil
+		}
+		changes := map[string]interface{}{
+			"commits_count":                    gorm.Expr("commits_count + ?", commits),
+			"commits_count_for_the_last_week":  gorm.Expr("commits_count_for_the_last_week + ?", commits),
+			"commits_count_for_the_last_month": gorm.Expr("commits_count_for_the_last_month + ?", commits),
+		}
+		if p.HeadCommit != nil && !p.HeadCommit.Timestamp.IsZero() {
+			changes["last_commit_at"] = p.HeadCommit.Timestamp.UTC()
+		}
+		return changes
+	case "star":
+		// The payload carries the new total, which is exact.
+		return map[string]interface{}{"stargazers_count": p.Repository.Stargazers}
+	case "issues":
+		switch p.Action {
+		case "opened":
+			return map[string]interface{}{
+				"issues_count":      gorm.Expr("issues_count + 1"),
+				"open_issues_count": gorm.Expr("open_issues_count + 1"),
+			}
+		case "closed":
+			return map[string]interface{}{
+				"open_issues_count":   gorm.Expr("open_issues_count - 1"),
+				"closed_issues_count": gorm.Expr("closed_issues_count + 1"),
+			}
+		case "reopened":
+			return map[string]interface{}{
+				"open_issues_count":   gorm.Expr("open_issues_count + 1"),
+				"closed_issues_count": gorm.Expr("closed_issues_count - 1"),
+			}
+		}
+	}
+	return nil
+}
+
+func runServeWebhooks(args []string) {
+	fs := flag.NewFlagSet("serve-webhooks", flag.ExitOnError)
+	addConfigFlag(fs)
+	addr := fs.String("addr", ":8081", "address to listen on")
+	logOpts := addLogFlags(fs)
+	fs.Parse(args)
+	loggingSettings(logOpts)
+
+	config := loadConfig()
+	if config.GitHub.WebhookSecret == "" {
+		fatal("GITHUB_WEBHOOK_SECRET is required to verify the webhooks.")
+	}
+	db := dbConnect(config)
+	defer closeDB(db)
+
+	if err := serveWebhooks(db, config.GitHub.WebhookSecret, *addr); err != nil {
+		fatal("The webhook server stopped.", "error", err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookOrderingWithRuns(t *testing.T) {
+	db := dbConnect(testConfig(t))
+	defer closeDB(db)
+	seedBitcoin(t, db)
+	s := &webhookServer{db: db, secret: []byte("secret")}
+
+	// push delivers a push of two new commits to the default branch.
+	push := func() {
+		t.Helper()
+		body := []byte(`{"ref":"refs/heads/master","repository":{"name":"bitcoin","default_branch":"master","owner":{"login":"bitcoin"}},"commits":[{"distinct":true},{"distinct":true}]}`)
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body)
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+		r.Header.Set("X-GitHub-Event", "push")
+		r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		rec := httptest.NewRecorder()
+		s.github(rec, r)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("push = %d, want %d", rec.Code, http.StatusNoContent)
+		}
+	}
+	commits := func() int {
+		t.Helper()
+		var repo Repository
+		if err := db.First(&repo).Error; err != nil {
+			t.Fatal(err)
+		}
+		return repo.CommitsCount
+	}
+
+	// An event landing after a run's write adds to what the run wrote.
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"commits_count"}
+	writeMetrics(t, db, now, Repository{CommitsCount: 10}, columns)
+	push()
+	if got := commits(); got != 12 {
+		t.Errorf("commits_count after the push = %d, want 12", got)
+	}
+
+	// A run that selected the repository before the event still writes its
+	// exact count over it.
+	var repo Repository
+	if err := db.Preload("Coin").First(&repo).Error; err != nil {
+		t.Fatal(err)
+	}
+	push()
+	later := now.AddDate(0, 0, 1)
+	run, err := startRun(db, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &pendingWrite{
+		result: result{job: job{Repository: repo}, Metrics: Repository{CommitsCount: 13}, Location: locationOf(repo), Columns: columns},
+		Before: repo,
+		Logger: slog.Default(),
+	}
+	if done := flushWrites(db, run.Id, []*pendingWrite{w}, nil, later); len(done) != 1 {
+		t.Fatal("the run's write was taken as stale")
+	}
+	if got := commits(); got != 13 {
+		t.Errorf("commits_count after the run = %d, want the 13 it collected", got)
+	}
+}
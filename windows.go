@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultCommitWindows are used when [Commits] windows is not configured.
+var defaultCommitWindows = []string{"7d", "30d"}
+
+// commitWindow is a trailing period over which commits are counted, written
+// as a number of days such as "90d".
+type commitWindow struct {
+	Name string
+	Days int
+}
+
+func parseCommitWindows(names []string) ([]commitWindow, error) {
+	if len(names) == 0 {
+		names = defaultCommitWindows
+	}
+	windows := make([]commitWindow, 0, len(names))
+	for _, name := range names {
+		days, err := strconv.Atoi(strings.TrimSuffix(name, "d"))
+		if err != nil || !strings.HasSuffix(name, "d") || days < 1 {
+			return nil, fmt.Errorf("commit window %q must be a number of days such as \"30d\"", name)
+		}
+		windows = append(windows, commitWindow{Name: name, Days: days})
+	}
+	return windows, nil
+}
+
+// historySince returns how far back the commit history has to be read to
+// fill every window, the weekly and monthly columns and the contributors.
+func historySince(now time.Time, windows []commitWindow) time.Time {
+	since := now.AddDate(0, 0, -contributorsHistoryDays)
+	for _, w := range windows {
+		if t := now.AddDate(0, 0, -w.Days); t.Before(since) {
+			since = t
+		}
+	}
+	return since
+}
+
+// commitsCountSince counts the commits dated at or after since.
+func commitsCountSince(n []commitNode, since time.Time) int {
+	var count int
+	for _, v := range n {
+		if !v.CommittedDate.Before(since) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func commitWindowCounts(n []commitNode, now time.Time, windows []commitWindow) map[string]int {
+	counts := make(map[string]int, len(windows))
+	for _, w := range windows {
+		counts[w.Name] = commitsCountSince(n, now.AddDate(0, 0, -w.Days))
+	}
+	return counts
+}
+
+// saveCommitWindows upserts one row per window of a repository.
+func saveCommitWindows(db *gorm.DB, repositoryId int, counts map[string]int, windows []commitWindow, now time.Time) error {
+	for _, w := range windows {
+		count, ok := counts[w.Name]
+		if !ok {
+			continue
+		}
+		row := RepositoryCommitWindow{RepositoryId: repositoryId, Window: w.Name}
+		err := db.Where(row).
+			Assign(map[string]interface{}{"days": w.Days, "commits_count": count, "updated_at": now}).
+			FirstOrCreate(&row).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// fixedClock is a Clock stopped at one time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// useClock replaces clock for the rest of the test.
+func useClock(t *testing.T, now time.Time) {
+	saved := clock
+	clock = fixedClock(now)
+	t.Cleanup(func() { clock = saved })
+}
+
+func TestCommitWindowCountsEdgeWithOffset(t *testing.T) {
+	windows, err := parseCommitWindows([]string{"7d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2024-03-24 12:00 UTC is the start of the window, written in +09:00 the
+	// way GitLab returns the dates of a committer in Tokyo.
+	var commits []struct {
+		CommittedDate time.Time `json:"committed_date"`
+	}
+	body := `[
+		{"committed_date": "2024-03-24T21:00:00.000+09:00"},
+		{"committed_date": "2024-03-24T20:59:59.000+09:00"},
+		{"committed_date": "2024-03-24T07:00:00.000-05:00"}
+	]`
+	if err := json.Unmarshal([]byte(body), &commits); err != nil {
+		t.Fatal(err)
+	}
+	nodes := make([]commitNode, len(commits))
+	for i, c := range commits {
+		nodes[i].CommittedDate = c.CommittedDate
+	}
+
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	for _, loc := range []*time.Location{time.UTC, time.FixedZone("JST", 9*60*60)} {
+		t.Run(loc.String(), func(t *testing.T) {
+			useClock(t, now.In(loc))
+			counts := commitWindowCounts(nodes, clock.Now(), windows)
+			// The first commit is at the edge and the third one the same
+			// instant in -05:00; the second one is a second before it.
+			if got := counts["7d"]; got != 2 {
+				t.Errorf("counts[\"7d\"] = %d, want 2", got)
+			}
+		})
+	}
+}
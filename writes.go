@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultWriteBatch = 50
+
+// repositorySchemas caches the parsed schema of Repository for
+// mergeMetrics.
+var repositorySchemas sync.Map
+
+// pendingWrite is a collected repository waiting for its batch to be
+// written. Before is the repository as it was selected.
+type pendingWrite struct {
+	result
+	Before Repository
+	Logger *slog.Logger
+	// Snapshot and Anomalies are filled in by the write. Stale means
+	// another run wrote the repository first, so this one did not.
+	Snapshot  RepositorySnapshot
+	Anomalies []Anomaly
+	Stale     bool
+}
+
+// flushWrites writes the pending repositories in one transaction and returns
+// the ones whose metrics were written. When the batch fails, each repository
+// is written in a transaction of its own, so one bad row only loses itself.
+func flushWrites(db *gorm.DB, runId int, pending []*pendingWrite, windows []commitWindow, now time.Time) []*pendingWrite {
+	if len(pending) == 0 {
+		return nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return writeBatch(tx, runId, pending, windows, now)
+	})
+	if err == nil {
+		return written(pending)
+	}
+	if len(pending) > 1 {
+		slog.Warn("Failed to write the batch, writing its repositories one by one.", "run_id", runId, "count", len(pending), "error", err)
+	}
+
+	var done []*pendingWrite
+	for _, w := range pending {
+		// The failed attempt may have merged the metrics already.
+		w.Repository, w.Anomalies, w.Stale = w.Before, nil, false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return writeBatch(tx, runId, []*pendingWrite{w}, windows, now)
+		})
+		if err != nil {
+			w.Logger.Error("Failed to write the repository, rolled back.", "error", err)
+			continue
+		}
+		done = append(done, w)
+	}
+	return written(done)
+}
+
+// written drops the stale writes.
+func written(writes []*pendingWrite) []*pendingWrite {
+	var ok []*pendingWrite
+	for _, w := range writes {
+		if !w.Stale {
+			ok = append(ok, w)
+		}
+	}
+	return ok
+}
+
+// writeBatch writes the metrics of the collected repositories with one
+// multi-row upsert and their snapshots with one insert, then their side
+// tables, all in tx so a failure leaves none of them behind. The new metrics
+// are merged into the Repository of each write.
+func writeBatch(tx *gorm.DB, runId int, writes []*pendingWrite, windows []commitWindow, now time.Time) error {
+	writes, err := currentWrites(tx, runId, writes)
+	if err != nil {
+		return err
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	repos := make([]Repository, 0, len(writes))
+	snapshots := make([]RepositorySnapshot, 0, len(writes))
+	var anomalies []Anomaly
+	columns := map[string]bool{"last_run_id": true}
+	for _, w := range writes {
+		if w.Location.moved(locationOf(w.Repository)) {
+			if err := applyMove(tx, &w.Repository, w.Location); err != nil {
+				return fmt.Errorf("%s: record the move: %w", repoName(w.Repository), err)
+			}
+		}
+		updated, err := mergeMetrics(tx, &w.Repository, w.Metrics, w.Columns)
+		if err != nil {
+			return err
+		}
+		for _, c := range updated {
+			columns[c] = true
+		}
+		w.Repository.LastRunId = runId
+		w.Anomalies = detectAnomalies(runId, w.Before, w.Metrics, now)
+		anomalies = append(anomalies, w.Anomalies...)
+		repos = append(repos, w.Repository)
+		snapshots = append(snapshots, newSnapshot(runId, w.Repository.Id, w.Metrics, now))
+	}
+
+	// Rows whose metrics left a column out get the value they already had.
+	assigned := make([]string, 0, len(columns))
+	for c := range columns {
+		assigned = append(assigned, c)
+	}
+	sort.Strings(assigned)
+	err = tx.Omit(clause.Associations).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoUpdates: clause.AssignmentColumns(assigned)}).
+		Create(&repos).Error
+	if err != nil {
+		return fmt.Errorf("update the repositories: %w", err)
+	}
+	if err := tx.Create(&snapshots).Error; err != nil {
+		return fmt.Errorf("write the snapshots: %w", err)
+	}
+	if len(anomalies) > 0 {
+		if err := tx.Create(&anomalies).Error; err != nil {
+			return fmt.Errorf("write the anomalies: %w", err)
+		}
+	}
+
+	for i, w := range writes {
+		w.Snapshot = snapshots[i]
+		if err := writeSideTables(tx, runId, w.Repository.Id, w.Metrics, windows, now); err != nil {
+			return fmt.Errorf("%s: %w", repoName(w.Repository), err)
+		}
+	}
+	return nil
+}
+
+// currentWrites returns the writes whose repository no other run wrote since
+// it was selected, locking their rows until tx ends. The others are only
+// recorded as processed, keeping the fresher metrics of the other run.
+func currentWrites(tx *gorm.DB, runId int, writes []*pendingWrite) ([]*pendingWrite, error) {
+	ids := make([]int, len(writes))
+	for i, w := range writes {
+		ids[i] = w.Repository.Id
+	}
+	var rows []struct{ Id, LastRunId int }
+	q := tx.Model(&Repository{}).Select("id", "last_run_id").Where("id IN ?", ids)
+	// SQLite has no row locks, as it lets one writer in at a time.
+	if tx.Dialector.Name() != "sqlite" {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read the repositories: %w", err)
+	}
+	lastRun := make(map[int]int, len(rows))
+	for _, row := range rows {
+		lastRun[row.Id] = row.LastRunId
+	}
+
+	current := writes[:0:0]
+	for _, w := range writes {
+		by, ok := lastRun[w.Repository.Id]
+		if !ok || by == w.Before.LastRunId {
+			current = append(current, w)
+			continue
+		}
+		w.Logger.Warn("Written by another run since it was selected, keeping its metrics.", "by_run_id", by)
+		w.Stale = true
+		if err := markProcessed(tx, runId, w.Repository.Id); err != nil {
+			return nil, fmt.Errorf("record the run progress: %w", err)
+		}
+	}
+	return current, nil
+}
+
+// writeSideTables writes what a repository's metrics carry besides its row,
+// and records it as processed by the run.
+func writeSideTables(tx *gorm.DB, runId, id int, m Repository, windows []commitWindow, now time.Time) error {
+	growths, err := newGrowths(tx, id, m, now)
+	if err == nil {
+		err = saveGrowths(tx, growths)
+	}
+	if err != nil {
+		return fmt.Errorf("write the growth: %w", err)
+	}
+	if err := saveCommitWindows(tx, id, m.CommitWindows, windows, now); err != nil {
+		return fmt.Errorf("write the commit windows: %w", err)
+	}
+	if err := saveContributors(tx, id, m.Contributors, now); err != nil {
+		return fmt.Errorf("write the contributors: %w", err)
+	}
+	if err := saveTopContributors(tx, id, m.TopContributors, now); err != nil {
+		return fmt.Errorf("write the top contributors: %w", err)
+	}
+	if err := saveCodeFrequency(tx, id, m.CodeFrequency, now); err != nil {
+		return fmt.Errorf("write the code frequency: %w", err)
+	}
+	if err := saveLanguages(tx, id, m.Languages, now); err != nil {
+		return fmt.Errorf("write the languages: %w", err)
+	}
+	if err := saveTopics(tx, id, m.Topics, now); err != nil {
+		return fmt.Errorf("write the topics: %w", err)
+	}
+	if err := saveAuthorDomains(tx, id, m.AuthorDomains, now); err != nil {
+		return fmt.Errorf("write the author domains: %w", err)
+	}
+	if err := resolveCollectionErrors(tx, id, now); err != nil {
+		return fmt.Errorf("resolve the collection errors: %w", err)
+	}
+	if err := markProcessed(tx, runId, id); err != nil {
+		return fmt.Errorf("record the run progress: %w", err)
+	}
+	return nil
+}
+
+// mergeMetrics copies the owned columns of m into repo, zero or not, and the
+// other non-zero ones like Updates(m) would, and returns their names.
+func mergeMetrics(db *gorm.DB, repo *Repository, m Repository, owned []string) ([]string, error) {
+	s, err := schema.Parse(&Repository{}, &repositorySchemas, db.NamingStrategy)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	src, dst := reflect.ValueOf(m), reflect.ValueOf(repo).Elem()
+	var columns []string
+	for _, f := range s.Fields {
+		if f.DBName == "" || f.PrimaryKey || !f.Updatable {
+			continue
+		}
+		v, zero := f.ValueOf(ctx, src)
+		if zero && !contains(owned, f.DBName) {
+			continue
+		}
+		if err := f.Set(ctx, dst, v); err != nil {
+			return nil, err
+		}
+		columns = append(columns, f.DBName)
+	}
+	return columns, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// writeMetrics writes m as collected by a collector reading columns, in a
+// run of its own started at now.
+func writeMetrics(t *testing.T, db *gorm.DB, now time.Time, m Repository, columns []string) Repository {
+	t.Helper()
+	var repo Repository
+	if err := db.Preload("Coin").First(&repo).Error; err != nil {
+		t.Fatal(err)
+	}
+	run, err := startRun(db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &pendingWrite{
+		result: result{
+			job:      job{Repository: repo},
+			Metrics:  m,
+			Location: locationOf(repo),
+			Columns:  columns,
+		},
+		Before: repo,
+		Logger: slog.Default(),
+	}
+	if done := flushWrites(db, run.Id, []*pendingWrite{w}, nil, now); len(done) != 1 {
+		t.Fatalf("the metrics of %s were not written", now)
+	}
+	var written Repository
+	if err := db.First(&written, repo.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return written
+}
+
+// seedBitcoin seeds a coin with one repository.
+func seedBitcoin(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if _, err := seed(db, []seedCoin{{Symbol: "BTC", Name: "Bitcoin", Owner: "bitcoin", Repositories: []string{"bitcoin"}}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteBatchWritesOwnedZeroes(t *testing.T) {
+	db := dbConnect(testConfig(t))
+	defer closeDB(db)
+	seedBitcoin(t, db)
+
+	columns := []string{"commits_count_for_the_last_month", "open_issues_count", "latest_release_at", "updated_at"}
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	writeMetrics(t, db, now, Repository{
+		CommitsCountForTheLastMonth: 10,
+		OpenIssuesCount:             5,
+		LatestReleaseAt:             &now,
+		StargazersCount:             100,
+		UpdatedAt:                   now,
+	}, columns)
+
+	// The collector read no stars the second time, which it does not own.
+	later := now.AddDate(0, 0, 1)
+	repo := writeMetrics(t, db, later, Repository{UpdatedAt: later}, columns)
+	if repo.CommitsCountForTheLastMonth != 0 || repo.OpenIssuesCount != 0 || repo.LatestReleaseAt != nil {
+		t.Errorf("owned columns kept %d commits, %d open issues and release %v, want them cleared",
+			repo.CommitsCountForTheLastMonth, repo.OpenIssuesCount, repo.LatestReleaseAt)
+	}
+	if repo.StargazersCount != 100 {
+		t.Errorf("stargazers_count = %d, want the 100 it had", repo.StargazersCount)
+	}
+}
+
+func TestWriteBatchFlagsAnomalyOnce(t *testing.T) {
+	db := dbConnect(testConfig(t))
+	defer closeDB(db)
+	seedBitcoin(t, db)
+
+	// The commits drop to zero in the second run and stay there, so only
+	// the second run sees the row go from 10 to 0.
+	columns := []string{"stargazers_count", "commits_count_for_the_last_month", "contributors_count"}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for day, commits := range []int{10, 0, 0} {
+		m := Repository{StargazersCount: 100, CommitsCountForTheLastMonth: commits, ContributorsCount: 4}
+		repo := writeMetrics(t, db, start.AddDate(0, 0, day), m, columns)
+		if repo.CommitsCountForTheLastMonth != commits {
+			t.Fatalf("day %d: commits_count_for_the_last_month = %d, want %d", day, repo.CommitsCountForTheLastMonth, commits)
+		}
+	}
+
+	var anomalies []Anomaly
+	if err := db.Find(&anomalies).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(anomalies) != 1 || anomalies[0].Kind != anomalyCommitsZero || anomalies[0].Previous != 10 {
+		t.Errorf("anomalies = %+v, want one %s from 10", anomalies, anomalyCommitsZero)
+	}
+}
+
+func TestCollectorColumnsExist(t *testing.T) {
+	db := dbConnect(testConfig(t))
+	defer closeDB(db)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&Repository{}); err != nil {
+		t.Fatal(err)
+	}
+	lists := map[string][]string{
+		collectorGitHub: githubColumns, collectorGitLab: gitlabColumns, collectorBitbucket: bitbucketColumns,
+		collectorGitea: giteaColumns, collectorScrape: scrapeColumns, collectorGit: gitColumns,
+	}
+	for name, columns := range lists {
+		for _, column := range columns {
+			if stmt.Schema.LookUpField(column) == nil {
+				t.Errorf("the %s collector reads %s, which repositories has no column for", name, column)
+			}
+		}
+	}
+}